@@ -19,8 +19,12 @@ import (
 	"wsai/backend/internal/common/rabbitmq"
 	"wsai/backend/internal/common/redis"
 	"wsai/backend/internal/logger"
+	"wsai/backend/internal/middleware/jwt"
 	"wsai/backend/internal/repository/message"
 	"wsai/backend/internal/router"
+	"wsai/backend/internal/service/audit"
+	"wsai/backend/internal/service/email"
+	"wsai/backend/internal/service/rag"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -97,6 +101,10 @@ func main() {
 		zap.Int("port", config.C.App.Port),
 	)
 
+	if err := jwt.InitKeys(); err != nil {
+		logger.L().Fatal("JWT 签名密钥加载失败，无法继续运行", zap.Error(err))
+	}
+
 	if err := mysql.Init(); err != nil {
 		logger.L().Fatal("MySQL 初始化失败，无法继续运行", zap.Error(err))
 	}
@@ -110,6 +118,9 @@ func main() {
 	}
 
 	rabbitmq.InitRabbitMQ()
+	email.InitQueue()
+	audit.InitQueue()
+	rag.InitQueue()
 
 	host := config.C.App.Host
 	port := config.C.App.Port
@@ -14,6 +14,44 @@ var (
 	C    *Config
 )
 
+// ModelPrice 模型计价（每 1K token 的价格）
+type ModelPrice struct {
+	PromptPer1K     float64 `mapstructure:"prompt_per_1k"`
+	CompletionPer1K float64 `mapstructure:"completion_per_1k"`
+}
+
+// RateLimitRule 限流规则：window 时间窗口内最多 limit 次请求
+type RateLimitRule struct {
+	Limit  int    `mapstructure:"limit"`
+	Window string `mapstructure:"window"`
+}
+
+// OIDCProvider 一个 OpenID Connect 身份提供方
+type OIDCProvider struct {
+	DisplayName  string   `mapstructure:"display_name"`
+	Issuer       string   `mapstructure:"issuer"`
+	ClientID     string   `mapstructure:"client_id"`
+	ClientSecret string   `mapstructure:"client_secret"`
+	RedirectURL  string   `mapstructure:"redirect_url"` // 指向本服务的 /user/oidc/<name>/callback
+	Scopes       []string `mapstructure:"scopes"`
+	// AutoProvision 首次登录且邮箱未注册时自动创建账号
+	AutoProvision bool `mapstructure:"auto_provision"`
+	// AllowedDomains 允许登录的邮箱域名，为空表示不限制
+	AllowedDomains []string `mapstructure:"allowed_domains"`
+	// TrustEmail id_token 没有 email_verified 声明时仍视为邮箱已验证；
+	// 只对自行管理邮箱的企业 IdP 开启，否则首次登录可能绑定到同邮箱的本地账号
+	TrustEmail bool `mapstructure:"trust_email"`
+}
+
+// JWTKey 一把 JWT 签名密钥：HS256 使用 secret，RS256、EdDSA 使用 PEM 文件。
+// 只配置 public_key_file 的密钥仅用于验签，轮换后保留到旧 token 全部过期即可删除
+type JWTKey struct {
+	Algorithm      string `mapstructure:"algorithm"` // HS256、RS256、EdDSA
+	Secret         string `mapstructure:"secret"`
+	PrivateKeyFile string `mapstructure:"private_key_file"` // PKCS#8，RSA 也接受 PKCS#1
+	PublicKeyFile  string `mapstructure:"public_key_file"`
+}
+
 type Config struct {
 	App struct {
 		Name           string `mapstructure:"name"`
@@ -21,6 +59,10 @@ type Config struct {
 		Port           int    `mapstructure:"port"`
 		Env            string `mapstructure:"env"`
 		EnableRegister bool   `mapstructure:"enable_register"`
+		// Admins 启动时授予管理员角色的用户名或邮箱
+		Admins []string `mapstructure:"admins"`
+		// TrustedProxies 反向代理的 IP 或网段，只有来自这些地址的 X-Forwarded-For 才用于识别客户端 IP；为空表示不信任任何转发头
+		TrustedProxies []string `mapstructure:"trusted_proxies"`
 	} `mapstructure:"app"`
 
 	JWTConfig struct {
@@ -29,7 +71,13 @@ type Config struct {
 		RefreshTTL string `mapstructure:"refresh_ttl"`
 		Issuer     string `mapstructure:"issuer"`
 		Subject    string `mapstructure:"subject"`
-	} `mapstructuer:"jwt"`
+		// Audience 签发时写入 aud，校验时要求至少匹配一个；为空则不校验
+		Audience []string `mapstructure:"audience"`
+		// ActiveKid 用于签发新 token 的密钥，为空时使用 secret 对应的 default 密钥
+		ActiveKid string `mapstructure:"active_kid"`
+		// Keys key 为 kid（viper 会转为小写）；secret 不为空时自动作为 kid=default 的 HS256 密钥
+		Keys map[string]JWTKey `mapstructure:"keys"`
+	} `mapstructure:"jwt"`
 
 	MysqlConfig struct {
 		Host         string `mapstructure:"host"`
@@ -63,7 +111,88 @@ type Config struct {
 		Host     string `mapstructure:"host"`
 		Port     int    `mapstructure:"port"`
 		IsSSL    bool   `mapstructure:"is_ssl"`
+		FromName string `mapstructure:"from_name"`
+		// Driver 发信方式：smtp，或 file（写入 OutboxDir 目录，本地调试用）
+		Driver    string `mapstructure:"driver"`
+		OutboxDir string `mapstructure:"outbox_dir"`
+		// Async 通过 RabbitMQ 异步发送，失败后间隔 RetryDelay 重试，共 MaxAttempts 次仍失败则进入死信队列
+		Async       bool   `mapstructure:"async"`
+		MaxAttempts int    `mapstructure:"max_attempts"`
+		RetryDelay  string `mapstructure:"retry_delay"`
 	} `mapstructure:"email"`
+
+	RateLimitConfig struct {
+		Enabled bool `mapstructure:"enabled"`
+		// Rules 按路由分组配置，key 如 chat、captcha、image
+		Rules map[string]RateLimitRule `mapstructure:"rules"`
+	} `mapstructure:"ratelimit"`
+
+	CaptchaConfig struct {
+		// Cooldown 同一用途同一邮箱两次发送的最小间隔
+		Cooldown string `mapstructure:"cooldown"`
+		// EmailDailyLimit、IPDailyLimit 每个邮箱、每个 IP 每天最多发送次数，0 表示不限制
+		EmailDailyLimit int `mapstructure:"email_daily_limit"`
+		IPDailyLimit    int `mapstructure:"ip_daily_limit"`
+		// MaxAttempts 验证码输错达到该次数后作废
+		MaxAttempts int `mapstructure:"max_attempts"`
+		// ImageEnabled 发送邮箱验证码和注册前需先通过图形验证码
+		ImageEnabled bool `mapstructure:"image_enabled"`
+	} `mapstructure:"captcha"`
+
+	LoginGuardConfig struct {
+		Enabled bool `mapstructure:"enabled"`
+		// Window 失败次数的统计窗口，窗口内没有新的失败则计数清零
+		Window string `mapstructure:"window"`
+		// MaxAccountFailures 同一账号失败达到该次数后临时锁定 LockDuration
+		MaxAccountFailures int    `mapstructure:"max_account_failures"`
+		LockDuration       string `mapstructure:"lock_duration"`
+		// MaxIPFailures 同一 IP 失败达到该次数后在窗口内拒绝其登录
+		MaxIPFailures int `mapstructure:"max_ip_failures"`
+		// DelayAfter 账号失败达到该次数后，每次再失败需等待 1s、2s、4s... 才能重试，最长 MaxDelay
+		DelayAfter int    `mapstructure:"delay_after"`
+		MaxDelay   string `mapstructure:"max_delay"`
+	} `mapstructure:"login_guard"`
+
+	AuditConfig struct {
+		// MaxAttempts、RetryDelay 审计日志写库失败后间隔 RetryDelay 重试，共 MaxAttempts 次仍失败则进入死信队列
+		MaxAttempts int    `mapstructure:"max_attempts"`
+		RetryDelay  string `mapstructure:"retry_delay"`
+	} `mapstructure:"audit"`
+
+	OIDCConfig struct {
+		// FrontendRedirect 登录完成后跳转的前端地址，token 放在 URL fragment 中
+		FrontendRedirect string `mapstructure:"frontend_redirect"`
+		// Providers key 为提供方名称，出现在登录地址中
+		Providers map[string]OIDCProvider `mapstructure:"providers"`
+	} `mapstructure:"oidc"`
+
+	RAGConfig struct {
+		// StorageDir 上传文档的保存目录
+		StorageDir string `mapstructure:"storage_dir"`
+		// MaxFileSizeMB 单个文档的大小上限
+		MaxFileSizeMB int `mapstructure:"max_file_size_mb"`
+		// ChunkSize、ChunkOverlap 切块的目标长度及相邻块重叠的长度（字符数）
+		ChunkSize    int `mapstructure:"chunk_size"`
+		ChunkOverlap int `mapstructure:"chunk_overlap"`
+		// TopK 每次回答最多引用的切块数，相似度低于 MinScore 的切块不引用
+		TopK     int     `mapstructure:"top_k"`
+		MinScore float64 `mapstructure:"min_score"`
+	} `mapstructure:"rag"`
+
+	EmbeddingConfig struct {
+		// Provider 向量模型类型：openai（OpenAI 兼容接口）或 ollama
+		Provider string `mapstructure:"provider"`
+		// Model 为空时 openai 读取环境变量 OPENAI_EMBEDDING_MODEL；BaseURL 为空时 openai 使用 OPENAI_BASE_URL，ollama 使用本机默认地址
+		Model   string `mapstructure:"model"`
+		BaseURL string `mapstructure:"base_url"`
+		// BatchSize 单次请求向量化的文本数
+		BatchSize int `mapstructure:"batch_size"`
+		// CacheTTL 向量在 Redis 中按内容哈希缓存的时间，0 表示不缓存
+		CacheTTL string `mapstructure:"cache_ttl"`
+	} `mapstructure:"embedding"`
+
+	// Pricing 计价表，key 为模型类型（openai、ollama 等）
+	Pricing map[string]ModelPrice `mapstructure:"pricing"`
 }
 
 func InitConfig() {
@@ -97,6 +226,35 @@ func InitConfig() {
 		v.SetDefault("app.port", "9091")
 		v.SetDefault("jwt.access_ttl", "2h")
 		v.SetDefault("jwt.refresh_ttl", "30d")
+		v.SetDefault("email.from_name", "wsai")
+		v.SetDefault("email.driver", "smtp")
+		v.SetDefault("email.outbox_dir", "data/mail")
+		v.SetDefault("email.async", true)
+		v.SetDefault("email.max_attempts", 5)
+		v.SetDefault("email.retry_delay", "30s")
+		v.SetDefault("captcha.cooldown", "60s")
+		v.SetDefault("captcha.email_daily_limit", 10)
+		v.SetDefault("captcha.ip_daily_limit", 30)
+		v.SetDefault("captcha.max_attempts", 5)
+		v.SetDefault("captcha.image_enabled", true)
+		v.SetDefault("login_guard.enabled", true)
+		v.SetDefault("login_guard.window", "15m")
+		v.SetDefault("login_guard.max_account_failures", 10)
+		v.SetDefault("login_guard.lock_duration", "15m")
+		v.SetDefault("login_guard.max_ip_failures", 50)
+		v.SetDefault("login_guard.delay_after", 3)
+		v.SetDefault("login_guard.max_delay", "30s")
+		v.SetDefault("audit.max_attempts", 5)
+		v.SetDefault("audit.retry_delay", "30s")
+		v.SetDefault("rag.storage_dir", "data/documents")
+		v.SetDefault("rag.max_file_size_mb", 20)
+		v.SetDefault("rag.chunk_size", 800)
+		v.SetDefault("rag.chunk_overlap", 100)
+		v.SetDefault("rag.top_k", 5)
+		v.SetDefault("rag.min_score", 0.3)
+		v.SetDefault("embedding.provider", "openai")
+		v.SetDefault("embedding.batch_size", 32)
+		v.SetDefault("embedding.cache_ttl", "720h")
 
 		if err := v.ReadInConfig(); err != nil {
 			log.Printf("警告: 未找到配置文件，使用默认值+环境变量: %v", err)
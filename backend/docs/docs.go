@@ -15,6 +15,26 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/.well-known/jwks.json": {
+            "get": {
+                "description": "按 RFC 7517 返回用于验证 access token 的 RS256、EdDSA 公钥，token header 中的 kid 对应其中一项；HS256 密钥不公开。",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户认证"
+                ],
+                "summary": "JWT 公钥集",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/wsai_backend_internal_middleware_jwt.JWKSet"
+                        }
+                    }
+                }
+            }
+        },
         "/api/v1/AI/chatMessage/sessions": {
             "get": {
                 "security": [
@@ -82,6 +102,52 @@ const docTemplate = `{
                 }
             }
         },
+        "/api/v1/AI/chatMessage/sessions/{session_id}/knowledge-base": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "绑定后该会话的每次提问都会先在知识库中检索相关片段作为参考资料，回答开始前推送 citations 事件；knowledge_base_id 为 null 时解除绑定",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "会话管理"
+                ],
+                "summary": "为会话绑定或解除知识库",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "会话ID",
+                        "name": "session_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "知识库ID",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_session.SetSessionKnowledgeBaseRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/wsai_backend_internal_common.Response"
+                        }
+                    }
+                }
+            }
+        },
         "/api/v1/AI/chatMessage/sessions/{session_id}/messages": {
             "get": {
                 "security": [
@@ -165,335 +231,3346 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/image/recognize": {
-            "post": {
-                "description": "上传单张图片，服务端返回识别出的主要类别名称。\n成功时返回 class_name，失败时通过 status_code 和 status_msg 返回错误信息。",
-                "consumes": [
-                    "multipart/form-data"
+        "/api/v1/AI/chatMessage/sessions/{session_id}/messages/stream/{generation_id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "根据 generation 事件中的 generationId 重新连接，回放 Last-Event-ID 之后的事件并继续接收实时输出；生成结束 5 分钟后不可再续传",
                 "produces": [
-                    "application/json"
+                    "text/event-stream"
                 ],
                 "tags": [
-                    "图像识别"
+                    "会话管理"
                 ],
-                "summary": "上传图片进行分类/识别",
+                "summary": "续传 AI 回复（SSE 流式返回）",
                 "parameters": [
                     {
-                        "type": "file",
-                        "description": "要识别的图片文件",
-                        "name": "image",
-                        "in": "formData",
+                        "type": "string",
+                        "description": "会话ID",
+                        "name": "session_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "生成ID",
+                        "name": "generation_id",
+                        "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "最后收到的事件 id",
+                        "name": "Last-Event-ID",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "业务错误时也统一返回 200，通过 status_code 区分",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/backend_internal_handler_image.RecognizeImageResponse"
+                            "$ref": "#/definitions/wsai_backend_internal_common.Response"
                         }
                     }
                 }
             }
         },
-        "/api/v1/user/captcha": {
-            "post": {
-                "description": "向指定邮箱发送注册验证码。",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+        "/api/v1/AI/ws": {
+            "get": {
+                "description": "通过 ?token= 携带 JWT 建立 WebSocket 连接，一个连接可同时收发多个会话的消息，帧协议见 internal/service/ws 包文档",
                 "tags": [
-                    "用户认证"
+                    "会话管理"
                 ],
-                "summary": "发送邮箱验证码",
+                "summary": "聊天 WebSocket",
                 "parameters": [
                     {
-                        "description": "邮箱参数",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/backend_internal_handler_user.CaptchaRequest"
-                        }
+                        "type": "string",
+                        "description": "JWT Token",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "验证码发送成功",
-                        "schema": {
-                            "$ref": "#/definitions/backend_internal_handler_user.CaptchaResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "邮箱格式错误",
-                        "schema": {
-                            "$ref": "#/definitions/wsai_backend_internal_common.Response"
-                        }
-                    },
-                    "429": {
-                        "description": "发送过于频繁",
+                    "101": {
+                        "description": "Switching Protocols",
                         "schema": {
-                            "$ref": "#/definitions/wsai_backend_internal_common.Response"
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/api/v1/user/login": {
-            "post": {
-                "description": "根据用户名和密码登录，登录成功后返回 JWT Token。",
-                "consumes": [
-                    "application/json"
+        "/api/v1/admin/audit-logs": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "按条件分页查询审计日志，最新的在前。仅管理员可用。",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "用户认证"
+                    "管理后台"
                 ],
-                "summary": "用户登录",
+                "summary": "审计日志",
                 "parameters": [
                     {
-                        "description": "登录参数",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/backend_internal_handler_user.LoginRequest"
-                        }
+                        "type": "string",
+                        "description": "动作，如 login、password.change、apikey.create",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "操作者用户 ID",
+                        "name": "actor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "操作者用户名（登录失败时为输入的用户名或邮箱）",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "来源 IP",
+                        "name": "ip",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "对象类型：user、login、api_key",
+                        "name": "target_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "对象 ID",
+                        "name": "target_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否成功",
+                        "name": "success",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "开始时间（含），RFC3339 或 2006-01-02",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "结束时间（不含），RFC3339 或 2006-01-02",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "页码，默认 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量，默认 20，最大 100",
+                        "name": "page_size",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "登录成功，返回 Token",
-                        "schema": {
-                            "$ref": "#/definitions/backend_internal_handler_user.LoginResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "请求参数错误",
-                        "schema": {
-                            "$ref": "#/definitions/wsai_backend_internal_common.Response"
-                        }
-                    },
-                    "401": {
-                        "description": "用户名或密码错误",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/wsai_backend_internal_common.Response"
+                            "$ref": "#/definitions/backend_internal_handler_admin.ListAuditLogsResponse"
                         }
                     }
                 }
             }
         },
-        "/api/v1/user/logout": {
-            "post": {
+        "/api/v1/admin/audit-logs/export": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "将当前请求携带的 JWT Token 加入 Redis 黑名单，后续该 Token 将无法再访问受保护接口。",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "按与查询接口相同的条件导出 CSV（UTF-8 带 BOM），单次最多 100000 行。仅管理员可用。",
                 "produces": [
-                    "application/json"
+                    "text/csv"
                 ],
                 "tags": [
-                    "用户认证"
+                    "管理后台"
                 ],
-                "summary": "用户退出登录",
+                "summary": "导出审计日志",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Bearer Token，例如：Bearer eyJhbGciOi...",
-                        "name": "Authorization",
-                        "in": "header",
-                        "required": true
+                        "description": "动作",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "操作者用户 ID",
+                        "name": "actor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "操作者用户名",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "来源 IP",
+                        "name": "ip",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "对象类型",
+                        "name": "target_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "对象 ID",
+                        "name": "target_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否成功",
+                        "name": "success",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "开始时间（含）",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "结束时间（不含）",
+                        "name": "to",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "退出登录成功",
-                        "schema": {
-                            "$ref": "#/definitions/backend_internal_handler_user.LogoutResponse"
-                        }
-                    },
-                    "401": {
-                        "description": "未携带有效 Token",
-                        "schema": {
-                            "$ref": "#/definitions/wsai_backend_internal_common.Response"
-                        }
-                    },
-                    "500": {
-                        "description": "服务繁忙或 Redis 不可用",
+                        "description": "CSV 文件",
                         "schema": {
-                            "$ref": "#/definitions/wsai_backend_internal_common.Response"
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/api/v1/user/users": {
-            "post": {
-                "description": "通过邮箱、密码和验证码注册新用户，成功后直接返回 JWT Token。",
-                "consumes": [
-                    "application/json"
+        "/api/v1/admin/users": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "分页查询用户，keyword 按用户名、邮箱、昵称模糊匹配。仅管理员可用。",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "用户认证"
+                    "管理后台"
                 ],
-                "summary": "用户注册",
+                "summary": "用户列表",
                 "parameters": [
                     {
-                        "description": "注册参数",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/backend_internal_handler_user.RegisterRequest"
-                        }
+                        "type": "string",
+                        "description": "关键字",
+                        "name": "keyword",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "页码，默认 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量，默认 20，最大 100",
+                        "name": "page_size",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "注册成功，返回 Token",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/backend_internal_handler_user.RegisterResponse"
+                            "$ref": "#/definitions/backend_internal_handler_admin.ListUsersResponse"
                         }
-                    },
-                    "400": {
-                        "description": "请求参数错误或验证码错误",
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/users/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "查询用户信息及当前剩余额度。仅管理员可用。",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理后台"
+                ],
+                "summary": "用户详情",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "用户 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/wsai_backend_internal_common.Response"
+                            "$ref": "#/definitions/backend_internal_handler_admin.GetUserResponse"
                         }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "修改用户角色、套餐或禁用状态，未传的字段保持不变。禁用用户或修改角色会吊销其全部登录；不能禁用自己或修改自己的角色。仅管理员可用。",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理后台"
+                ],
+                "summary": "修改用户",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "用户 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     },
-                    "409": {
-                        "description": "用户已存在",
+                    {
+                        "description": "修改参数",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_admin.UpdateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_admin.UpdateUserResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/users/{id}/quota/reset": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "清空用户当天与当月已使用的额度。仅管理员可用。",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理后台"
+                ],
+                "summary": "重置用户额度",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "用户 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_admin.ResetQuotaResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/users/{id}/unlock": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "清除用户因密码错误次数过多造成的临时锁定及失败计数。仅管理员可用。",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理后台"
+                ],
+                "summary": "解除登录锁定",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "用户 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_admin.UnlockUserResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/users/{id}/usage": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "按天、按会话汇总指定用户最近 days 天的 token 用量及估算费用。仅管理员可用。",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理后台"
+                ],
+                "summary": "查询用户用量",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "用户 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "统计天数，默认 30，最大 365",
+                        "name": "days",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_admin.GetUserUsageResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/knowledge-bases": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "列出当前用户的知识库及文档数量。",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "知识库"
+                ],
+                "summary": "列出知识库",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_knowledge.ListKnowledgeBasesResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "新建一个空知识库，名称不超过 100 字，描述不超过 500 字。",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "知识库"
+                ],
+                "summary": "新建知识库",
+                "parameters": [
+                    {
+                        "description": "知识库信息",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_knowledge.CreateKnowledgeBaseRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_knowledge.KnowledgeBaseResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/knowledge-bases/documents/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "删除文档及其切块，之后的检索不再引用该文档。",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "知识库"
+                ],
+                "summary": "删除文档",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "文档ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/wsai_backend_internal_common.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/knowledge-bases/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "删除知识库及其全部文档，绑定了该知识库的会话自动解除绑定。",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "知识库"
+                ],
+                "summary": "删除知识库",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "知识库ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/wsai_backend_internal_common.Response"
                         }
                     }
                 }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "修改知识库名称或描述，未传的字段保持不变。",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "知识库"
+                ],
+                "summary": "修改知识库",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "知识库ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "修改参数",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_knowledge.UpdateKnowledgeBaseRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_knowledge.KnowledgeBaseResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/knowledge-bases/{id}/documents": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "列出知识库中的文档及处理状态（pending、processing、ready、failed）。",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "知识库"
+                ],
+                "summary": "列出文档",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "知识库ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_knowledge.ListDocumentsResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "上传 txt、md、pdf、docx 文档到知识库，文档在后台解析、切块并向量化，返回时状态为 pending，可轮询文档列表查看进度。",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "知识库"
+                ],
+                "summary": "上传文档",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "知识库ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "文档",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_knowledge.DocumentResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/2fa": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "查询当前用户是否开启两步验证，以及剩余可用的恢复码数量。",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "两步验证"
+                ],
+                "summary": "两步验证状态",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_twofactor.StatusResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "提交动态验证码或恢复码后关闭两步验证，同时删除全部恢复码。",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "两步验证"
+                ],
+                "summary": "关闭两步验证",
+                "parameters": [
+                    {
+                        "description": "动态验证码或恢复码",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_twofactor.PasscodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_twofactor.DisableResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/2fa/activate": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "提交验证器 App 中的动态验证码确认登记，开启两步验证并返回 10 个一次性恢复码（仅此一次，请妥善保存）。",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "两步验证"
+                ],
+                "summary": "激活两步验证",
+                "parameters": [
+                    {
+                        "description": "动态验证码",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_twofactor.PasscodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_twofactor.RecoveryCodesResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/2fa/enroll": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "生成新的 TOTP 密钥，返回密钥和 otpauth URI（可生成二维码供验证器 App 扫描）。登记后需调用激活接口验证一次才会开启。",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "两步验证"
+                ],
+                "summary": "登记两步验证",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_twofactor.EnrollResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/2fa/recovery-codes": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "提交动态验证码后重新生成恢复码，旧的恢复码全部作废。",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "两步验证"
+                ],
+                "summary": "重新生成恢复码",
+                "parameters": [
+                    {
+                        "description": "动态验证码",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_twofactor.PasscodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_twofactor.RecoveryCodesResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/api-keys": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "列出当前用户未吊销的 API Key（不含明文），包含最近使用时间。",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API Key"
+                ],
+                "summary": "列出 API Key",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_apikey.ListAPIKeysResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "为当前用户创建长期 API Key，可限定权限范围（chat、image、usage），明文只在本次响应中返回，请妥善保存。",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API Key"
+                ],
+                "summary": "创建 API Key",
+                "parameters": [
+                    {
+                        "description": "API Key 参数",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_apikey.CreateAPIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_apikey.CreateAPIKeyResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/api-keys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "吊销后该 API Key 立即失效。",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API Key"
+                ],
+                "summary": "吊销 API Key",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "API Key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_apikey.RevokeAPIKeyResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "修改 API Key 的名称或权限范围，未传的字段保持不变。",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API Key"
+                ],
+                "summary": "修改 API Key",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "API Key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "修改参数",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_apikey.UpdateAPIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_apikey.UpdateAPIKeyResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/captcha": {
+            "post": {
+                "description": "向指定邮箱发送注册验证码，需先通过图形验证码。",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户认证"
+                ],
+                "summary": "发送邮箱验证码",
+                "parameters": [
+                    {
+                        "description": "邮箱参数",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_user.CaptchaRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "验证码发送成功",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_user.CaptchaResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "邮箱格式错误",
+                        "schema": {
+                            "$ref": "#/definitions/wsai_backend_internal_common.Response"
+                        }
+                    },
+                    "429": {
+                        "description": "发送过于频繁",
+                        "schema": {
+                            "$ref": "#/definitions/wsai_backend_internal_common.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/captcha/image": {
+            "get": {
+                "description": "生成一张图形验证码（2 分钟内有效，只能校验一次）。发送邮箱验证码、注册前需在请求中携带 captcha_id 与识别出的 captcha_answer。",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户认证"
+                ],
+                "summary": "获取图形验证码",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_user.ImageCaptchaResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/email-login": {
+            "post": {
+                "description": "根据注册邮箱和密码登录，登录成功后返回 JWT Token。",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户认证"
+                ],
+                "summary": "用户邮箱登录",
+                "parameters": [
+                    {
+                        "description": "邮箱登录参数",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_user.EmailLoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "登录成功，返回 access/refresh Token；开启两步验证时返回 challenge_token",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_user.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/wsai_backend_internal_common.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "邮箱或密码错误",
+                        "schema": {
+                            "$ref": "#/definitions/wsai_backend_internal_common.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/login": {
+            "post": {
+                "description": "根据用户名和密码登录，登录成功后返回 JWT Token。",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户认证"
+                ],
+                "summary": "用户登录",
+                "parameters": [
+                    {
+                        "description": "登录参数",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_user.LoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "登录成功，返回 access/refresh Token；开启两步验证时返回 challenge_token",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_user.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/wsai_backend_internal_common.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "用户名或密码错误",
+                        "schema": {
+                            "$ref": "#/definitions/wsai_backend_internal_common.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/login/2fa": {
+            "post": {
+                "description": "密码登录返回 two_factor_required 时，携带 challenge_token 和验证器 App 中的动态验证码（或一次性恢复码）换取正式的 access/refresh Token。挑战 token 5 分钟内有效，最多尝试 5 次。",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户认证"
+                ],
+                "summary": "两步验证登录",
+                "parameters": [
+                    {
+                        "description": "挑战 token 与验证码",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_user.TwoFactorLoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "验证码错误或挑战已过期",
+                        "schema": {
+                            "$ref": "#/definitions/wsai_backend_internal_common.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/login/code": {
+            "post": {
+                "description": "凭邮箱收到的一次性验证码登录，无需密码。与密码登录共用失败次数限制和账号锁定。",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户认证"
+                ],
+                "summary": "邮箱验证码登录",
+                "parameters": [
+                    {
+                        "description": "邮箱与验证码",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_user.CodeLoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "登录成功，返回 access/refresh Token；开启两步验证时返回 challenge_token",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_user.LoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/login/code/send": {
+            "post": {
+                "description": "向注册邮箱发送免密登录验证码（5 分钟内有效，仅可使用一次），需先通过图形验证码。为避免探测账号，邮箱未注册时同样返回成功。",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户认证"
+                ],
+                "summary": "发送登录验证码",
+                "parameters": [
+                    {
+                        "description": "注册邮箱",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_user.SendLoginCodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_user.SendLoginCodeResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "发送过于频繁",
+                        "schema": {
+                            "$ref": "#/definitions/wsai_backend_internal_common.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/logins": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "列出当前用户所有有效的登录（设备名称、IP、User-Agent、登录时间、最近活跃时间），current 标记发起本次请求的登录。",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户认证"
+                ],
+                "summary": "列出登录设备",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_loginsession.ListLoginsResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "吊销当前用户除本次请求所用登录之外的全部登录，返回吊销数量；退出当前登录请使用 /user/logout。",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户认证"
+                ],
+                "summary": "吊销其他登录设备",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_loginsession.RevokeAllLoginsResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/logins/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "吊销指定的登录，该设备上的 access/refresh token 立即失效。",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户认证"
+                ],
+                "summary": "吊销登录设备",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "登录 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_loginsession.RevokeLoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/logout": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "吊销当前登录，该登录签发的 access token 和 refresh token 随即失效。",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户认证"
+                ],
+                "summary": "用户退出登录",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer Token，例如：Bearer eyJhbGciOi...",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "退出登录成功",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_user.LogoutResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未携带有效 Token",
+                        "schema": {
+                            "$ref": "#/definitions/wsai_backend_internal_common.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "服务繁忙或 Redis 不可用",
+                        "schema": {
+                            "$ref": "#/definitions/wsai_backend_internal_common.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/me": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "查询当前用户的资料与偏好设置（默认模型、界面语言）。",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "个人资料"
+                ],
+                "summary": "个人资料",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_user.ProfileResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "注销当前账号：删除全部会话和消息、API Key，匿名化用户信息，全部登录随即失效，操作不可恢复。confirm 需填写自己的用户名，有密码的账号还需提供密码。",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "个人资料"
+                ],
+                "summary": "注销账号",
+                "parameters": [
+                    {
+                        "description": "确认信息",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_user.DeleteAccountRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_user.AccountResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "修改昵称、头像和偏好设置，未传的字段保持不变。",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "个人资料"
+                ],
+                "summary": "修改个人资料",
+                "parameters": [
+                    {
+                        "description": "修改参数",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_user.UpdateProfileRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_user.ProfileResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/me/email": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "校验密码后向新邮箱发送验证码（10 分钟内有效），再调用确认接口完成更换。只通过单点登录的账号无需密码。",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "个人资料"
+                ],
+                "summary": "更换邮箱",
+                "parameters": [
+                    {
+                        "description": "新邮箱与当前密码",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_user.ChangeEmailRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_user.AccountResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/me/email/confirm": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "提交新邮箱收到的验证码完成更换，旧邮箱会收到通知。",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "个人资料"
+                ],
+                "summary": "确认更换邮箱",
+                "parameters": [
+                    {
+                        "description": "新邮箱与验证码",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_user.ConfirmEmailRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_user.ProfileResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/oidc/providers": {
+            "get": {
+                "description": "列出已配置的 OpenID Connect 身份提供方，前端据此展示单点登录入口。",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户认证"
+                ],
+                "summary": "单点登录提供方",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_user.ListOIDCProvidersResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/oidc/{provider}/callback": {
+            "get": {
+                "description": "身份提供方授权后的回调地址。登录成功后跳转到配置的前端地址，token、refresh_token、expires_in（开启两步验证时为 challenge_token）放在 URL fragment 中；失败时 fragment 携带 status_code 和 status_msg。未配置前端地址时直接返回 JSON。",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户认证"
+                ],
+                "summary": "单点登录回调",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "提供方名称",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "授权码",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "state",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "跳转到前端"
+                    }
+                }
+            }
+        },
+        "/api/v1/user/oidc/{provider}/login": {
+            "get": {
+                "description": "跳转到身份提供方的授权页面（授权码 + PKCE）。",
+                "tags": [
+                    "用户认证"
+                ],
+                "summary": "发起单点登录",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "提供方名称",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "跳转到身份提供方"
+                    }
+                }
+            }
+        },
+        "/api/v1/user/password": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "校验旧密码后设置新密码。包括当前登录在内的全部登录随即失效，响应中返回当前设备的新 token。",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户认证"
+                ],
+                "summary": "修改密码",
+                "parameters": [
+                    {
+                        "description": "旧密码与新密码",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_user.ChangePasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "旧密码错误",
+                        "schema": {
+                            "$ref": "#/definitions/wsai_backend_internal_common.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/password/forgot": {
+            "post": {
+                "description": "向注册邮箱发送密码重置验证码（10 分钟内有效，仅可使用一次），需先通过图形验证码。为避免探测账号，邮箱未注册时同样返回成功。",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户认证"
+                ],
+                "summary": "忘记密码",
+                "parameters": [
+                    {
+                        "description": "注册邮箱",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_user.ForgotPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_user.PasswordResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "发送过于频繁",
+                        "schema": {
+                            "$ref": "#/definitions/wsai_backend_internal_common.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/password/reset": {
+            "post": {
+                "description": "使用邮箱收到的验证码设置新密码，成功后该账号的全部登录失效，需要重新登录。",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户认证"
+                ],
+                "summary": "重置密码",
+                "parameters": [
+                    {
+                        "description": "验证码与新密码",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_user.ResetPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "验证码错误或已过期",
+                        "schema": {
+                            "$ref": "#/definitions/wsai_backend_internal_common.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/token/refresh": {
+            "post": {
+                "description": "使用 refresh token 换取新的 access/refresh token，旧 refresh token 随即失效；重复使用已失效的 refresh token 会吊销该登录下的全部 refresh token。",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户认证"
+                ],
+                "summary": "刷新登录凭证",
+                "parameters": [
+                    {
+                        "description": "refresh token",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_user.RefreshTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "refresh token 无效、过期或被重复使用",
+                        "schema": {
+                            "$ref": "#/definitions/wsai_backend_internal_common.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/usage": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "按天、按会话汇总当前用户最近 days 天的 token 用量及估算费用。",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户认证"
+                ],
+                "summary": "查询当前用户的 token 用量",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "统计天数，默认 30，最大 365",
+                        "name": "days",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/wsai_backend_internal_common.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/users": {
+            "post": {
+                "description": "通过邮箱、密码和验证码注册新用户，需先通过图形验证码，成功后直接返回 JWT Token。",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户认证"
+                ],
+                "summary": "用户注册",
+                "parameters": [
+                    {
+                        "description": "注册参数",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_user.RegisterRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "注册成功，返回 Token",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_user.RegisterResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误或验证码错误",
+                        "schema": {
+                            "$ref": "#/definitions/wsai_backend_internal_common.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "用户已存在",
+                        "schema": {
+                            "$ref": "#/definitions/wsai_backend_internal_common.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/chat/completions": {
+            "post": {
+                "description": "使用 API Key（Authorization: Bearer wsai-...）调用，支持 stream；wsai_persist / wsai_session_id 扩展字段可将调用保存为 wsAI 会话",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OpenAI 兼容"
+                ],
+                "summary": "OpenAI 兼容对话补全",
+                "parameters": [
+                    {
+                        "description": "请求参数",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/wsai_backend_internal_service_openai.ChatCompletionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_openai.ChatCompletionResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/models": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OpenAI 兼容"
+                ],
+                "summary": "OpenAI 兼容模型列表",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/backend_internal_handler_openai.ModelListResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "backend_internal_handler_admin.GetUserResponse": {
+            "type": "object",
+            "properties": {
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/wsai_backend_internal_service_admin.UserDetail"
+                }
+            }
+        },
+        "backend_internal_handler_admin.GetUserUsageResponse": {
+            "type": "object",
+            "properties": {
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                },
+                "usage": {
+                    "$ref": "#/definitions/wsai_backend_internal_service_usage.Report"
+                }
+            }
+        },
+        "backend_internal_handler_admin.ListAuditLogsResponse": {
+            "type": "object",
+            "properties": {
+                "logs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/wsai_backend_internal_model.AuditLog"
+                    }
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "backend_internal_handler_admin.ListUsersResponse": {
+            "type": "object",
+            "properties": {
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/wsai_backend_internal_model.User"
+                    }
+                }
+            }
+        },
+        "backend_internal_handler_admin.ResetQuotaResponse": {
+            "type": "object",
+            "properties": {
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_admin.UnlockUserResponse": {
+            "type": "object",
+            "properties": {
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_admin.UpdateUserRequest": {
+            "type": "object",
+            "properties": {
+                "disabled": {
+                    "type": "boolean"
+                },
+                "plan": {
+                    "type": "string"
+                },
+                "role": {
+                    "description": "user、admin",
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_admin.UpdateUserResponse": {
+            "type": "object",
+            "properties": {
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/wsai_backend_internal_model.User"
+                }
+            }
+        },
+        "backend_internal_handler_apikey.CreateAPIKeyRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "scopes": {
+                    "description": "chat、image、usage，为空表示全部",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "backend_internal_handler_apikey.CreateAPIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "api_key": {
+                    "$ref": "#/definitions/wsai_backend_internal_service_apikey.KeyInfo"
+                },
+                "key": {
+                    "description": "明文，仅此一次",
+                    "type": "string"
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_apikey.ListAPIKeysResponse": {
+            "type": "object",
+            "properties": {
+                "api_keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/wsai_backend_internal_service_apikey.KeyInfo"
+                    }
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_apikey.RevokeAPIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_apikey.UpdateAPIKeyRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "backend_internal_handler_apikey.UpdateAPIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "api_key": {
+                    "$ref": "#/definitions/wsai_backend_internal_service_apikey.KeyInfo"
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_knowledge.CreateKnowledgeBaseRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_knowledge.DocumentResponse": {
+            "type": "object",
+            "properties": {
+                "document": {
+                    "$ref": "#/definitions/wsai_backend_internal_model.Document"
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_knowledge.KnowledgeBaseResponse": {
+            "type": "object",
+            "properties": {
+                "knowledge_base": {
+                    "$ref": "#/definitions/wsai_backend_internal_model.KnowledgeBase"
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_knowledge.ListDocumentsResponse": {
+            "type": "object",
+            "properties": {
+                "documents": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/wsai_backend_internal_model.Document"
+                    }
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_knowledge.ListKnowledgeBasesResponse": {
+            "type": "object",
+            "properties": {
+                "knowledge_bases": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/wsai_backend_internal_model.KnowledgeBase"
+                    }
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_knowledge.UpdateKnowledgeBaseRequest": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_loginsession.ListLoginsResponse": {
+            "type": "object",
+            "properties": {
+                "logins": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/wsai_backend_internal_service_loginsession.Info"
+                    }
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_loginsession.RevokeAllLoginsResponse": {
+            "type": "object",
+            "properties": {
+                "revoked": {
+                    "type": "integer"
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_loginsession.RevokeLoginResponse": {
+            "type": "object",
+            "properties": {
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_openai.ChatCompletionResponse": {
+            "type": "object",
+            "properties": {
+                "choices": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/backend_internal_handler_openai.Choice"
+                    }
+                },
+                "created": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "model": {
+                    "type": "string"
+                },
+                "object": {
+                    "type": "string"
+                },
+                "usage": {
+                    "$ref": "#/definitions/backend_internal_handler_openai.Usage"
+                },
+                "wsai_session_id": {
+                    "description": "SessionID wsAI 扩展：持久化时对应的会话 ID",
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_openai.Choice": {
+            "type": "object",
+            "properties": {
+                "delta": {
+                    "$ref": "#/definitions/wsai_backend_internal_service_openai.ChatMessage"
+                },
+                "finish_reason": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "message": {
+                    "$ref": "#/definitions/wsai_backend_internal_service_openai.ChatMessage"
+                }
+            }
+        },
+        "backend_internal_handler_openai.ModelInfo": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "object": {
+                    "type": "string"
+                },
+                "owned_by": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_openai.ModelListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/backend_internal_handler_openai.ModelInfo"
+                    }
+                },
+                "object": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_openai.Usage": {
+            "type": "object",
+            "properties": {
+                "completion_tokens": {
+                    "type": "integer"
+                },
+                "prompt_tokens": {
+                    "type": "integer"
+                },
+                "total_tokens": {
+                    "type": "integer"
+                }
+            }
+        },
+        "backend_internal_handler_session.CreateSessionAndSendFirstMessageRequest": {
+            "type": "object",
+            "required": [
+                "question"
+            ],
+            "properties": {
+                "modelType": {
+                    "description": "为空时使用个人资料中的默认模型",
+                    "type": "string"
+                },
+                "question": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_session.GetMeaasgeHistoryResponse": {
+            "type": "object",
+            "properties": {
+                "history": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/wsai_backend_internal_model.History"
+                    }
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_session.GetUserSessionsResponse": {
+            "type": "object",
+            "properties": {
+                "sessions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/wsai_backend_internal_model.SessionInfo"
+                    }
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_session.SendMessageStreamRequest": {
+            "type": "object",
+            "required": [
+                "question",
+                "sessionId"
+            ],
+            "properties": {
+                "modelType": {
+                    "description": "为空时使用个人资料中的默认模型",
+                    "type": "string"
+                },
+                "question": {
+                    "type": "string"
+                },
+                "sessionId": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_session.SetSessionKnowledgeBaseRequest": {
+            "type": "object",
+            "properties": {
+                "knowledge_base_id": {
+                    "description": "为 null 表示解除绑定",
+                    "type": "integer"
+                }
+            }
+        },
+        "backend_internal_handler_twofactor.DisableResponse": {
+            "type": "object",
+            "properties": {
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_twofactor.EnrollResponse": {
+            "type": "object",
+            "properties": {
+                "otpauth_uri": {
+                    "description": "可生成二维码供验证器 App 扫描",
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_twofactor.PasscodeRequest": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_twofactor.RecoveryCodesResponse": {
+            "type": "object",
+            "properties": {
+                "recovery_codes": {
+                    "description": "明文，仅此一次",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_twofactor.StatusResponse": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "remaining_recovery_codes": {
+                    "type": "integer"
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_usage.GetUserUsageResponse": {
+            "type": "object",
+            "properties": {
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                },
+                "usage": {
+                    "$ref": "#/definitions/wsai_backend_internal_service_usage.Report"
+                }
+            }
+        },
+        "backend_internal_handler_user.AccountResponse": {
+            "type": "object",
+            "properties": {
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_user.CaptchaRequest": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "captcha_answer": {
+                    "type": "string"
+                },
+                "captcha_id": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_user.CaptchaResponse": {
+            "type": "object",
+            "properties": {
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_user.ChangeEmailRequest": {
+            "type": "object",
+            "required": [
+                "new_email"
+            ],
+            "properties": {
+                "new_email": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_user.ChangePasswordRequest": {
+            "type": "object",
+            "required": [
+                "new_password",
+                "old_password"
+            ],
+            "properties": {
+                "device_name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "new_password": {
+                    "type": "string",
+                    "maxLength": 128,
+                    "minLength": 6
+                },
+                "old_password": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_user.CodeLoginRequest": {
+            "type": "object",
+            "required": [
+                "code",
+                "email"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "device_name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_user.ConfirmEmailRequest": {
+            "type": "object",
+            "required": [
+                "code",
+                "new_email"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "new_email": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "backend_internal_handler_user.DeleteAccountRequest": {
+            "type": "object",
+            "required": [
+                "confirm"
+            ],
+            "properties": {
+                "confirm": {
+                    "description": "需填写自己的用户名",
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_user.EmailLoginRequest": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "device_name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_user.ForgotPasswordRequest": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "captcha_answer": {
+                    "type": "string"
+                },
+                "captcha_id": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_user.ImageCaptchaResponse": {
+            "type": "object",
+            "properties": {
+                "captcha_id": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "description": "为 false 时无需图形验证码",
+                    "type": "boolean"
+                },
+                "image": {
+                    "description": "data:image/png;base64,...",
+                    "type": "string"
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_user.ListOIDCProvidersResponse": {
+            "type": "object",
+            "properties": {
+                "providers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/wsai_backend_internal_service_sso.ProviderInfo"
+                    }
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_user.LoginRequest": {
+            "type": "object",
+            "properties": {
+                "device_name": {
+                    "description": "可选，为空时根据 User-Agent 推断",
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "password": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_user.LoginResponse": {
+            "type": "object",
+            "properties": {
+                "challenge_token": {
+                    "type": "string"
+                },
+                "expires_in": {
+                    "description": "access token 有效秒数",
+                    "type": "integer"
+                },
+                "refresh_token": {
+                    "type": "string"
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "two_factor_required": {
+                    "description": "开启两步验证时不返回 token，需携带 challenge_token 调用 /user/login/2fa",
+                    "type": "boolean"
+                }
+            }
+        },
+        "backend_internal_handler_user.LogoutResponse": {
+            "type": "object",
+            "properties": {
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_user.PasswordResponse": {
+            "type": "object",
+            "properties": {
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_user.ProfileResponse": {
+            "type": "object",
+            "properties": {
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/wsai_backend_internal_model.User"
+                }
+            }
+        },
+        "backend_internal_handler_user.RefreshTokenRequest": {
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_user.RegisterRequest": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "captcha": {
+                    "type": "string"
+                },
+                "captcha_answer": {
+                    "type": "string"
+                },
+                "captcha_id": {
+                    "type": "string"
+                },
+                "device_name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_user.RegisterResponse": {
+            "type": "object",
+            "properties": {
+                "expires_in": {
+                    "type": "integer"
+                },
+                "refresh_token": {
+                    "type": "string"
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_user.ResetPasswordRequest": {
+            "type": "object",
+            "required": [
+                "code",
+                "email",
+                "new_password"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "new_password": {
+                    "type": "string",
+                    "maxLength": 128,
+                    "minLength": 6
+                }
+            }
+        },
+        "backend_internal_handler_user.SendLoginCodeRequest": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "captcha_answer": {
+                    "type": "string"
+                },
+                "captcha_id": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_user.SendLoginCodeResponse": {
+            "type": "object",
+            "properties": {
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "backend_internal_handler_user.TwoFactorLoginRequest": {
+            "type": "object",
+            "required": [
+                "challenge_token",
+                "code"
+            ],
+            "properties": {
+                "challenge_token": {
+                    "type": "string"
+                },
+                "code": {
+                    "description": "6 位动态验证码或恢复码",
+                    "type": "string"
+                },
+                "device_name": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "backend_internal_handler_user.UpdateProfileRequest": {
+            "type": "object",
+            "properties": {
+                "avatar": {
+                    "description": "http(s) 图片地址，空字符串表示清除",
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "default_model": {
+                    "description": "已注册的模型类型，空字符串表示清除",
+                    "type": "string"
+                },
+                "language": {
+                    "description": "zh-CN、en-US",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 50
+                }
+            }
+        },
+        "wsai_backend_internal_common.Response": {
+            "type": "object",
+            "properties": {
+                "status_code": {
+                    "type": "integer"
+                },
+                "status_msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "wsai_backend_internal_middleware_jwt.JWK": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "string"
+                },
+                "crv": {
+                    "type": "string"
+                },
+                "e": {
+                    "type": "string"
+                },
+                "kid": {
+                    "type": "string"
+                },
+                "kty": {
+                    "type": "string"
+                },
+                "n": {
+                    "type": "string"
+                },
+                "use": {
+                    "type": "string"
+                },
+                "x": {
+                    "type": "string"
+                }
+            }
+        },
+        "wsai_backend_internal_middleware_jwt.JWKSet": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/wsai_backend_internal_middleware_jwt.JWK"
+                    }
+                }
+            }
+        },
+        "wsai_backend_internal_model.AuditLog": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "actor_id": {
+                    "description": "未登录的操作（如登录失败）为 0",
+                    "type": "integer"
+                },
+                "actor_name": {
+                    "description": "用户名，登录失败时为输入的用户名或邮箱",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                },
+                "target_id": {
+                    "type": "string"
+                },
+                "target_type": {
+                    "type": "string"
+                },
+                "user_agent": {
+                    "type": "string"
+                }
             }
-        }
-    },
-    "definitions": {
-        "backend_internal_handler_image.RecognizeImageResponse": {
+        },
+        "wsai_backend_internal_model.Document": {
             "type": "object",
             "properties": {
-                "class_name": {
+                "chunk_count": {
+                    "type": "integer"
+                },
+                "created_at": {
                     "type": "string"
                 },
-                "status_code": {
+                "error": {
+                    "type": "string"
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "id": {
                     "type": "integer"
                 },
-                "status_msg": {
+                "knowledge_base_id": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
                     "type": "string"
                 }
             }
         },
-        "backend_internal_handler_session.CreateSessionAndSendFirstMessageRequest": {
+        "wsai_backend_internal_model.History": {
             "type": "object",
-            "required": [
-                "modelType",
-                "question"
-            ],
             "properties": {
-                "modelType": {
+                "content": {
                     "type": "string"
                 },
-                "question": {
-                    "type": "string"
+                "is_user": {
+                    "type": "boolean"
                 }
             }
         },
-        "backend_internal_handler_session.GetMeaasgeHistoryResponse": {
+        "wsai_backend_internal_model.KnowledgeBase": {
             "type": "object",
             "properties": {
-                "history": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/wsai_backend_internal_model.History"
-                    }
+                "created_at": {
+                    "type": "string"
                 },
-                "status_code": {
+                "description": {
+                    "type": "string"
+                },
+                "document_count": {
                     "type": "integer"
                 },
-                "status_msg": {
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "updated_at": {
                     "type": "string"
                 }
             }
         },
-        "backend_internal_handler_session.GetUserSessionsResponse": {
+        "wsai_backend_internal_model.SessionInfo": {
             "type": "object",
             "properties": {
-                "sessions": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/wsai_backend_internal_model.SessionInfo"
-                    }
-                },
-                "status_code": {
+                "knowledgeBaseId": {
+                    "description": "KnowledgeBaseID 绑定的知识库",
                     "type": "integer"
                 },
-                "status_msg": {
+                "sessionId": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updatedAt": {
                     "type": "string"
                 }
             }
         },
-        "backend_internal_handler_session.SendMessageStreamRequest": {
+        "wsai_backend_internal_model.User": {
             "type": "object",
-            "required": [
-                "modelType",
-                "question",
-                "sessionId"
-            ],
             "properties": {
-                "modelType": {
+                "avatar": {
                     "type": "string"
                 },
-                "question": {
+                "created_at": {
                     "type": "string"
                 },
-                "sessionId": {
+                "default_model": {
+                    "description": "偏好：对话未指定模型时使用",
+                    "type": "string"
+                },
+                "disabled": {
+                    "description": "禁用后无法登录，已有登录全部吊销",
+                    "type": "boolean"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "language": {
+                    "description": "偏好：界面语言",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "plan": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "totp_enabled": {
+                    "type": "boolean"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "username": {
                     "type": "string"
                 }
             }
         },
-        "backend_internal_handler_user.CaptchaRequest": {
+        "wsai_backend_internal_repository_usage.DailyUsage": {
             "type": "object",
-            "required": [
-                "email"
-            ],
             "properties": {
-                "email": {
+                "completion_tokens": {
+                    "type": "integer"
+                },
+                "cost": {
+                    "type": "number"
+                },
+                "date": {
                     "type": "string"
+                },
+                "prompt_tokens": {
+                    "type": "integer"
+                },
+                "requests": {
+                    "type": "integer"
+                },
+                "total_tokens": {
+                    "type": "integer"
                 }
             }
         },
-        "backend_internal_handler_user.CaptchaResponse": {
+        "wsai_backend_internal_repository_usage.SessionUsage": {
             "type": "object",
             "properties": {
-                "status_code": {
+                "completion_tokens": {
                     "type": "integer"
                 },
-                "status_msg": {
+                "cost": {
+                    "type": "number"
+                },
+                "prompt_tokens": {
+                    "type": "integer"
+                },
+                "requests": {
+                    "type": "integer"
+                },
+                "session_id": {
                     "type": "string"
+                },
+                "total_tokens": {
+                    "type": "integer"
                 }
             }
         },
-        "backend_internal_handler_user.LoginRequest": {
+        "wsai_backend_internal_repository_usage.Summary": {
             "type": "object",
             "properties": {
-                "password": {
+                "completion_tokens": {
+                    "type": "integer"
+                },
+                "cost": {
+                    "type": "number"
+                },
+                "prompt_tokens": {
+                    "type": "integer"
+                },
+                "requests": {
+                    "type": "integer"
+                },
+                "total_tokens": {
+                    "type": "integer"
+                }
+            }
+        },
+        "wsai_backend_internal_service_admin.UserDetail": {
+            "type": "object",
+            "properties": {
+                "avatar": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "default_model": {
+                    "description": "偏好：对话未指定模型时使用",
+                    "type": "string"
+                },
+                "disabled": {
+                    "description": "禁用后无法登录，已有登录全部吊销",
+                    "type": "boolean"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "language": {
+                    "description": "偏好：界面语言",
+                    "type": "string"
+                },
+                "locked_until": {
+                    "description": "密码错误过多被临时锁定时的解锁时间",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "plan": {
+                    "type": "string"
+                },
+                "quota": {
+                    "$ref": "#/definitions/wsai_backend_internal_service_quota.Remaining"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "totp_enabled": {
+                    "type": "boolean"
+                },
+                "updated_at": {
                     "type": "string"
                 },
                 "username": {
@@ -501,92 +3578,168 @@ const docTemplate = `{
                 }
             }
         },
-        "backend_internal_handler_user.LoginResponse": {
+        "wsai_backend_internal_service_apikey.KeyInfo": {
             "type": "object",
             "properties": {
-                "status_code": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
                     "type": "integer"
                 },
-                "status_msg": {
+                "last_used_at": {
                     "type": "string"
                 },
-                "token": {
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
                     "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "backend_internal_handler_user.LogoutResponse": {
+        "wsai_backend_internal_service_loginsession.Info": {
             "type": "object",
             "properties": {
-                "status_code": {
+                "created_at": {
+                    "type": "string"
+                },
+                "current": {
+                    "description": "是否是发起本次请求的登录",
+                    "type": "boolean"
+                },
+                "device_name": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
                     "type": "integer"
                 },
-                "status_msg": {
+                "ip": {
+                    "type": "string"
+                },
+                "last_seen_at": {
+                    "type": "string"
+                },
+                "user_agent": {
                     "type": "string"
                 }
             }
         },
-        "backend_internal_handler_user.RegisterRequest": {
+        "wsai_backend_internal_service_openai.ChatCompletionRequest": {
             "type": "object",
             "required": [
-                "email"
+                "messages",
+                "model"
             ],
             "properties": {
-                "captcha": {
+                "messages": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/wsai_backend_internal_service_openai.ChatMessage"
+                    }
+                },
+                "model": {
                     "type": "string"
                 },
-                "email": {
+                "stream": {
+                    "type": "boolean"
+                },
+                "stream_options": {
+                    "$ref": "#/definitions/wsai_backend_internal_service_openai.StreamOptions"
+                },
+                "user": {
                     "type": "string"
                 },
-                "password": {
+                "wsai_persist": {
+                    "description": "Persist 为 true 时把本次对话保存为新的 wsAI 会话",
+                    "type": "boolean"
+                },
+                "wsai_session_id": {
+                    "description": "SessionID 续写已有 wsAI 会话，只取 messages 中最后一条用户消息，历史以会话为准",
                     "type": "string"
                 }
             }
         },
-        "backend_internal_handler_user.RegisterResponse": {
+        "wsai_backend_internal_service_openai.ChatMessage": {
             "type": "object",
             "properties": {
-                "status_code": {
-                    "type": "integer"
-                },
-                "status_msg": {
+                "content": {
                     "type": "string"
                 },
-                "token": {
+                "role": {
                     "type": "string"
                 }
             }
         },
-        "wsai_backend_internal_common.Response": {
+        "wsai_backend_internal_service_openai.StreamOptions": {
             "type": "object",
             "properties": {
-                "status_code": {
+                "include_usage": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "wsai_backend_internal_service_quota.Remaining": {
+            "type": "object",
+            "properties": {
+                "daily_requests": {
                     "type": "integer"
                 },
-                "status_msg": {
-                    "type": "string"
+                "daily_tokens": {
+                    "type": "integer"
+                },
+                "monthly_requests": {
+                    "type": "integer"
+                },
+                "monthly_tokens": {
+                    "type": "integer"
                 }
             }
         },
-        "wsai_backend_internal_model.History": {
+        "wsai_backend_internal_service_sso.ProviderInfo": {
             "type": "object",
             "properties": {
-                "content": {
+                "display_name": {
                     "type": "string"
                 },
-                "is_user": {
-                    "type": "boolean"
+                "name": {
+                    "type": "string"
                 }
             }
         },
-        "wsai_backend_internal_model.SessionInfo": {
+        "wsai_backend_internal_service_usage.Report": {
             "type": "object",
             "properties": {
-                "sessionId": {
+                "daily": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/wsai_backend_internal_repository_usage.DailyUsage"
+                    }
+                },
+                "from": {
                     "type": "string"
                 },
-                "title": {
+                "sessions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/wsai_backend_internal_repository_usage.SessionUsage"
+                    }
+                },
+                "to": {
                     "type": "string"
+                },
+                "total": {
+                    "$ref": "#/definitions/wsai_backend_internal_repository_usage.Summary"
                 }
             }
         }
@@ -14,6 +14,7 @@ import (
 // AIHelper 一个会话绑定一个AIHelper
 type AIHelper struct {
 	model     AIModel
+	modelType string
 	messages  []*model.Message
 	muRW      sync.RWMutex
 	SessionID string
@@ -21,12 +22,13 @@ type AIHelper struct {
 }
 
 // NewAIHelper 创建新的AIHelper实例
-func NewAIHelper(model_ AIModel, SessionID string) *AIHelper {
+func NewAIHelper(model_ AIModel, modelType string, SessionID string) *AIHelper {
 	return &AIHelper{
-		model:    model_,
-		messages: make([]*model.Message, 0, 20),
+		model:     model_,
+		modelType: modelType,
+		messages:  make([]*model.Message, 0, 20),
 		saveFunc: func(msg *model.Message) (*model.Message, error) {
-			data, genErr := rabbitmq.GenerateMessageMQPara(msg.SessionID, msg.Content, msg.UserName, msg.IsUser)
+			data, genErr := rabbitmq.GenerateMessageMQPara(msg)
 			if genErr != nil {
 				logger.L().Error("Generate RabbitMQ message param failed",
 					zap.Error(genErr),
@@ -54,20 +56,25 @@ func NewAIHelper(model_ AIModel, SessionID string) *AIHelper {
 
 // addMessage 添加消息到内存中并调用自定义存储函数
 func (a *AIHelper) AddMessage(Content string, UserName string, IsUser bool, Save bool) {
-	userMsg := model.Message{
+	a.addMessage(&model.Message{
 		SessionID: a.SessionID,
 		Content:   Content,
 		UserName:  UserName,
 		IsUser:    IsUser,
-	}
-	a.messages = append(a.messages, &userMsg)
-	if Save {
-		if _, err := a.saveFunc(&userMsg); err != nil {
+	}, Save)
+}
+
+func (a *AIHelper) addMessage(msg *model.Message, save bool) {
+	a.muRW.Lock()
+	a.messages = append(a.messages, msg)
+	a.muRW.Unlock()
+	if save {
+		if _, err := a.saveFunc(msg); err != nil {
 			logger.L().Warn("Call saveFunc failed ",
 				zap.Error(err),
 				zap.String("session_id", a.SessionID),
-				zap.String("username", UserName),
-				zap.Bool("is_user", IsUser),
+				zap.String("username", msg.UserName),
+				zap.Bool("is_user", msg.IsUser),
 			)
 		}
 	}
@@ -88,16 +95,38 @@ func (a *AIHelper) GetAllMessage() []*model.Message {
 	return out
 }
 
-// 流式生成
+// 流式生成；通过 WithRetriever 可在生成前检索参考资料
 func (a *AIHelper) StreamResponse(username string,
 	ctx context.Context, cb StreamCallback,
-	userQuestion string) (*model.Message, error) {
+	userQuestion string, opts ...StreamOption) (*model.Message, error) {
+
+	var o streamOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
 
 	a.AddMessage(userQuestion, username, true, true)
 	a.muRW.RLock()
 	messages := utils.ConvertToSchemaMessages(a.messages)
 	a.muRW.RUnlock()
-	content, err := a.model.StreamResponse(ctx, messages, cb)
+
+	if o.retriever != nil {
+		// 检索失败不影响回答，只是没有参考资料
+		refs, err := o.retriever.Retrieve(ctx, userQuestion)
+		if err != nil {
+			logger.L().Warn("检索参考资料失败",
+				zap.Error(err),
+				zap.String("session_id", a.SessionID),
+				zap.String("username", username))
+		} else if len(refs) > 0 {
+			messages = injectReferences(messages, refs)
+			if o.onRetrieve != nil {
+				o.onRetrieve(refs)
+			}
+		}
+	}
+
+	content, usage, err := a.model.StreamResponse(ctx, messages, cb)
 	if err != nil {
 		logger.L().Error("AI model StreamResponse failed",
 			zap.Error(err),
@@ -106,15 +135,22 @@ func (a *AIHelper) StreamResponse(username string,
 		return nil, err
 	}
 
-	//构造保存完整AI回复
+	//构造保存完整AI回复，附带本次调用的 token 用量与估算费用
 
 	modelMsg := &model.Message{
 		SessionID: a.SessionID,
 		Content:   content,
 		UserName:  username,
 		IsUser:    false,
+		ModelType: a.modelType,
+	}
+	if usage != nil {
+		modelMsg.PromptTokens = usage.PromptTokens
+		modelMsg.CompletionTokens = usage.CompletionTokens
+		modelMsg.TotalTokens = usage.TotalTokens
+		modelMsg.Cost = EstimateCost(a.modelType, usage)
 	}
-	a.AddMessage(content, username, false, true)
+	a.addMessage(modelMsg, true)
 
 	return modelMsg, nil
 
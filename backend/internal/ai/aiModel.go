@@ -17,7 +17,7 @@ import (
 type StreamCallback func(msg string)
 
 type AIModel interface {
-	StreamResponse(ctx context.Context, messages []*schema.Message, cb StreamCallback) (string, error)
+	StreamResponse(ctx context.Context, messages []*schema.Message, cb StreamCallback) (string, *schema.TokenUsage, error)
 	GetModelType() string
 }
 
@@ -66,34 +66,51 @@ func normalizeOpenAIBaseURL(raw string) string {
 	return baseURL
 }
 
-func (o *OpenAIModel) StreamResponse(ctx context.Context, messages []*schema.Message, cb StreamCallback) (string, error) {
+func (o *OpenAIModel) StreamResponse(ctx context.Context, messages []*schema.Message, cb StreamCallback) (string, *schema.TokenUsage, error) {
 	stream, err := o.llm.Stream(ctx, messages)
 	if err != nil {
-		return "", fmt.Errorf("openai stream failed: %v", err)
+		return "", nil, fmt.Errorf("openai stream failed: %v", err)
 	}
 	defer stream.Close()
 
 	var fullResp strings.Builder
+	var usage *schema.TokenUsage
 	for {
 		msg, err := stream.Recv()
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
-			return "", fmt.Errorf("openai stream recv failed: %v", err)
+			return "", nil, fmt.Errorf("openai stream recv failed: %v", err)
 		}
 		if len(msg.Content) > 0 {
 			fullResp.WriteString(msg.Content)
 			cb(msg.Content)
 		}
+		usage = mergeUsage(usage, msg)
 
 	}
-	return fullResp.String(), nil
+	return fullResp.String(), usage, nil
 }
 func (o *OpenAIModel) GetModelType() string {
 	return "OpenAI"
 }
 
+// mergeUsage 从流式分片中提取 token 用量，取各字段的最大值（部分实现只在最后一个分片返回）
+func mergeUsage(usage *schema.TokenUsage, msg *schema.Message) *schema.TokenUsage {
+	if msg.ResponseMeta == nil || msg.ResponseMeta.Usage == nil {
+		return usage
+	}
+	if usage == nil {
+		usage = &schema.TokenUsage{}
+	}
+	u := msg.ResponseMeta.Usage
+	usage.PromptTokens = max(usage.PromptTokens, u.PromptTokens)
+	usage.CompletionTokens = max(usage.CompletionTokens, u.CompletionTokens)
+	usage.TotalTokens = max(usage.TotalTokens, u.TotalTokens, usage.PromptTokens+usage.CompletionTokens)
+	return usage
+}
+
 //ollama
 
 type OllamaModel struct {
@@ -110,28 +127,30 @@ func NewOllamaModel(ctx context.Context, baseURL, modelName string) (*OllamaMode
 	}
 	return &OllamaModel{llm: llm}, nil
 }
-func (o *OllamaModel) StreamResponse(ctx context.Context, messages []*schema.Message, cb StreamCallback) (string, error) {
+func (o *OllamaModel) StreamResponse(ctx context.Context, messages []*schema.Message, cb StreamCallback) (string, *schema.TokenUsage, error) {
 	stream, err := o.llm.Stream(ctx, messages)
 	if err != nil {
-		return "", fmt.Errorf("ollama stream failed: %v", err)
+		return "", nil, fmt.Errorf("ollama stream failed: %v", err)
 	}
 	defer stream.Close()
 	var fullResp strings.Builder
+	var usage *schema.TokenUsage
 	for {
 		msg, err := stream.Recv()
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
-			return "", fmt.Errorf("ollama stream recv failed: %v", err)
+			return "", nil, fmt.Errorf("ollama stream recv failed: %v", err)
 		}
 		if len(msg.Content) > 0 {
 			fullResp.WriteString(msg.Content)
 			cb(msg.Content)
 		}
+		usage = mergeUsage(usage, msg)
 
 	}
-	return fullResp.String(), nil
+	return fullResp.String(), usage, nil
 }
 func (o *OllamaModel) GetModelType() string {
 	return "Ollama"
@@ -0,0 +1,205 @@
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"wsai/backend/config"
+	redisclient "wsai/backend/internal/common/redis"
+	"wsai/backend/internal/logger"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const embeddingKeyPrefix = "embedding:"
+
+// Embedder 在向量模型之上做分批请求和 Redis 缓存，检索、RAG、去重等功能统一通过它向量化
+type Embedder struct {
+	model     EmbeddingModel
+	batchSize int
+	cacheTTL  time.Duration
+}
+
+// NewEmbedder batchSize 不大于 0 时不分批，cacheTTL 为 0 时不缓存
+func NewEmbedder(model EmbeddingModel, batchSize int, cacheTTL time.Duration) *Embedder {
+	return &Embedder{model: model, batchSize: batchSize, cacheTTL: cacheTTL}
+}
+
+var (
+	defaultEmbedder    *Embedder
+	defaultEmbedderErr error
+	embedderOnce       sync.Once
+)
+
+// GetEmbedder 按 [embedding] 配置创建全局 Embedder
+func GetEmbedder() (*Embedder, error) {
+	embedderOnce.Do(func() {
+		c := config.C.EmbeddingConfig
+		provider := strings.TrimSpace(c.Provider)
+		if provider == "" {
+			provider = ModelTypeOpenAI
+		}
+		modelName := c.Model
+		if strings.TrimSpace(modelName) == "" && provider == ModelTypeOpenAI {
+			modelName = os.Getenv("OPENAI_EMBEDDING_MODEL")
+		}
+		model, err := GetGlobalFactory().CreateEmbeddingModel(context.Background(), provider, map[string]interface{}{
+			"baseURL":   c.BaseURL,
+			"modelName": modelName,
+		})
+		if err != nil {
+			defaultEmbedderErr = err
+			return
+		}
+		ttl, err := time.ParseDuration(c.CacheTTL)
+		if err != nil || ttl < 0 {
+			ttl = 0
+		}
+		defaultEmbedder = NewEmbedder(model, c.BatchSize, ttl)
+		logger.L().Info("Embedding model created",
+			zap.String("model_type", provider),
+			zap.String("model_name", modelName))
+	})
+	return defaultEmbedder, defaultEmbedderErr
+}
+
+// Embed 使用全局 Embedder 向量化，返回的向量与 texts 一一对应
+func Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	e, err := GetEmbedder()
+	if err != nil {
+		return nil, err
+	}
+	return e.Embed(ctx, texts)
+}
+
+// Embed 先查缓存，未命中的文本去重后分批请求模型
+func (e *Embedder) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	vectors := make([][]float64, len(texts))
+	keys := make([]string, len(texts))
+	for i, text := range texts {
+		keys[i] = e.cacheKey(text)
+	}
+	e.loadCache(ctx, keys, vectors)
+
+	// 相同内容只请求一次
+	var pending []string
+	positions := make(map[string][]int)
+	for i, v := range vectors {
+		if v != nil {
+			continue
+		}
+		if _, ok := positions[keys[i]]; !ok {
+			pending = append(pending, texts[i])
+		}
+		positions[keys[i]] = append(positions[keys[i]], i)
+	}
+
+	batchSize := e.batchSize
+	if batchSize <= 0 {
+		batchSize = max(len(pending), 1)
+	}
+	for start := 0; start < len(pending); start += batchSize {
+		batch := pending[start:min(start+batchSize, len(pending))]
+		out, err := e.model.EmbedStrings(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		if len(out) != len(batch) {
+			return nil, fmt.Errorf("向量接口返回 %d 条，期望 %d 条", len(out), len(batch))
+		}
+		fresh := make(map[string][]float64, len(batch))
+		for j, v := range out {
+			key := e.cacheKey(batch[j])
+			fresh[key] = v
+			for _, i := range positions[key] {
+				vectors[i] = v
+			}
+		}
+		e.storeCache(ctx, fresh)
+	}
+	return vectors, nil
+}
+
+// cacheKey 按模型和内容哈希区分
+func (e *Embedder) cacheKey(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return embeddingKeyPrefix + strings.ToLower(e.model.GetModelType()) + ":" + e.model.GetModelName() + ":" + hex.EncodeToString(sum[:])
+}
+
+// loadCache 把命中的向量填入 vectors，Redis 不可用时全部视为未命中
+func (e *Embedder) loadCache(ctx context.Context, keys []string, vectors [][]float64) {
+	if e.cacheTTL <= 0 || redisclient.Rdb == nil || len(keys) == 0 {
+		return
+	}
+	vals, err := redisclient.Rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		logger.L().Warn("读取向量缓存失败", zap.Error(err))
+		return
+	}
+	for i, val := range vals {
+		if s, ok := val.(string); ok {
+			vectors[i] = decodeEmbedding([]byte(s))
+		}
+	}
+}
+
+func (e *Embedder) storeCache(ctx context.Context, vectors map[string][]float64) {
+	if e.cacheTTL <= 0 || redisclient.Rdb == nil || len(vectors) == 0 {
+		return
+	}
+	_, err := redisclient.Rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for key, v := range vectors {
+			pipe.Set(ctx, key, encodeEmbedding(v), e.cacheTTL)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, context.Canceled) {
+		logger.L().Warn("写入向量缓存失败", zap.Error(err))
+	}
+}
+
+// encodeEmbedding 缓存中按 float32 小端存储，精度足够且体积减半
+func encodeEmbedding(v []float64) []byte {
+	buf := make([]byte, 4*len(v))
+	for i, x := range v {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(float32(x)))
+	}
+	return buf
+}
+
+func decodeEmbedding(b []byte) []float64 {
+	if len(b) == 0 || len(b)%4 != 0 {
+		return nil
+	}
+	v := make([]float64, len(b)/4)
+	for i := range v {
+		v[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:])))
+	}
+	return v
+}
+
+// CosineSimilarity 两个向量的余弦相似度，长度不同或为零向量时返回 0
+func CosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,126 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino-ext/libs/acl/openai"
+	"github.com/eino-contrib/ollama/api"
+)
+
+// embeddingTimeout 单次向量化请求的超时时间
+const embeddingTimeout = 60 * time.Second
+
+// defaultOllamaBaseURL 未配置地址时使用本机 Ollama
+const defaultOllamaBaseURL = "http://localhost:11434"
+
+type EmbeddingModel interface {
+	// EmbedStrings 向量化一批文本，返回的向量与 texts 一一对应
+	EmbedStrings(ctx context.Context, texts []string) ([][]float64, error)
+	GetModelType() string
+	// GetModelName 不同模型的向量不能混用，缓存按模型区分
+	GetModelName() string
+}
+
+//openAI
+
+type OpenAIEmbeddingModel struct {
+	client    *openai.EmbeddingClient
+	modelName string
+}
+
+// NewOpenAIEmbeddingModel 使用 OpenAI 兼容的向量接口，baseURL 为空时与对话模型共用 OPENAI_BASE_URL
+func NewOpenAIEmbeddingModel(ctx context.Context, baseURL, modelName string) (*OpenAIEmbeddingModel, error) {
+	key := os.Getenv("OPENAI_API_KEY")
+	if strings.TrimSpace(baseURL) == "" {
+		baseURL = os.Getenv("OPENAI_BASE_URL")
+	}
+	baseURL = normalizeOpenAIBaseURL(baseURL)
+
+	if strings.TrimSpace(key) == "" {
+		return nil, fmt.Errorf("OPENAI_API_KEY is required")
+	}
+	if strings.TrimSpace(modelName) == "" {
+		return nil, fmt.Errorf("openai embedding requires non-empty modelName")
+	}
+	if baseURL == "" {
+		return nil, fmt.Errorf("OPENAI_BASE_URL is required")
+	}
+
+	client, err := openai.NewEmbeddingClient(ctx, &openai.EmbeddingConfig{
+		APIKey:     key,
+		BaseURL:    baseURL,
+		Model:      modelName,
+		HTTPClient: &http.Client{Timeout: embeddingTimeout},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create openai embedding model failed: %v", err)
+	}
+	return &OpenAIEmbeddingModel{client: client, modelName: modelName}, nil
+}
+
+func (o *OpenAIEmbeddingModel) EmbedStrings(ctx context.Context, texts []string) ([][]float64, error) {
+	vectors, err := o.client.EmbedStrings(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("openai embedding failed: %v", err)
+	}
+	return vectors, nil
+}
+func (o *OpenAIEmbeddingModel) GetModelType() string {
+	return "OpenAI"
+}
+func (o *OpenAIEmbeddingModel) GetModelName() string {
+	return o.modelName
+}
+
+//ollama
+
+type OllamaEmbeddingModel struct {
+	client    *api.Client
+	modelName string
+}
+
+func NewOllamaEmbeddingModel(ctx context.Context, baseURL, modelName string) (*OllamaEmbeddingModel, error) {
+	if strings.TrimSpace(modelName) == "" {
+		return nil, fmt.Errorf("ollama embedding requires non-empty modelName")
+	}
+	if strings.TrimSpace(baseURL) == "" {
+		baseURL = defaultOllamaBaseURL
+	}
+	u, err := url.Parse(strings.TrimRight(strings.TrimSpace(baseURL), "/"))
+	if err != nil {
+		return nil, fmt.Errorf("create ollama embedding model failed: invalid base URL: %v", err)
+	}
+	client := api.NewClient(u, &http.Client{Timeout: embeddingTimeout})
+	return &OllamaEmbeddingModel{client: client, modelName: modelName}, nil
+}
+
+func (o *OllamaEmbeddingModel) EmbedStrings(ctx context.Context, texts []string) ([][]float64, error) {
+	resp, err := o.client.Embed(ctx, &api.EmbedRequest{
+		Model: o.modelName,
+		Input: texts,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ollama embedding failed: %v", err)
+	}
+	vectors := make([][]float64, len(resp.Embeddings))
+	for i, e := range resp.Embeddings {
+		v := make([]float64, len(e))
+		for j, x := range e {
+			v[j] = float64(x)
+		}
+		vectors[i] = v
+	}
+	return vectors, nil
+}
+func (o *OllamaEmbeddingModel) GetModelType() string {
+	return "Ollama"
+}
+func (o *OllamaEmbeddingModel) GetModelName() string {
+	return o.modelName
+}
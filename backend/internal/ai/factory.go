@@ -3,6 +3,7 @@ package ai
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"wsai/backend/internal/logger"
 
@@ -18,10 +19,14 @@ const (
 // ModelCreator 定义模型创建函数类型
 type ModelCreator func(ctx context.Context, config map[string]interface{}) (AIModel, error)
 
+// EmbeddingCreator 定义向量模型创建函数类型
+type EmbeddingCreator func(ctx context.Context, config map[string]interface{}) (EmbeddingModel, error)
+
 // AIModelFactory AI模型工厂
 type AIModelFactory struct {
-	creators map[string]ModelCreator
-	mu       sync.Mutex
+	creators          map[string]ModelCreator
+	embeddingCreators map[string]EmbeddingCreator
+	mu                sync.Mutex
 }
 
 var (
@@ -33,7 +38,8 @@ var (
 func GetGlobalFactory() *AIModelFactory {
 	factoryOnce.Do(func() {
 		globalFactory = &AIModelFactory{
-			creators: make(map[string]ModelCreator),
+			creators:          make(map[string]ModelCreator),
+			embeddingCreators: make(map[string]EmbeddingCreator),
 		}
 		globalFactory.registerCreators()
 
@@ -50,7 +56,7 @@ func (f *AIModelFactory) registerCreators() {
 
 	//ollama
 	f.creators[ModelTypeOllama] = func(ctx context.Context, config map[string]interface{}) (AIModel, error) {
-		baseURL := config["baseURL"].(string)
+		baseURL, _ := config["baseURL"].(string)
 		modelName, ok := config["modelName"].(string)
 		if !ok {
 			err := fmt.Errorf("ollama requires non-empty modelName")
@@ -63,6 +69,30 @@ func (f *AIModelFactory) registerCreators() {
 		return NewOllamaModel(ctx, baseURL, modelName)
 
 	}
+
+	// 向量模型，config 支持 baseURL、modelName
+	f.embeddingCreators[ModelTypeOpenAI] = func(ctx context.Context, config map[string]interface{}) (EmbeddingModel, error) {
+		baseURL, _ := config["baseURL"].(string)
+		modelName, _ := config["modelName"].(string)
+		return NewOpenAIEmbeddingModel(ctx, baseURL, modelName)
+	}
+	f.embeddingCreators[ModelTypeOllama] = func(ctx context.Context, config map[string]interface{}) (EmbeddingModel, error) {
+		baseURL, _ := config["baseURL"].(string)
+		modelName, _ := config["modelName"].(string)
+		return NewOllamaEmbeddingModel(ctx, baseURL, modelName)
+	}
+}
+
+// ModelTypes 返回已注册的模型类型
+func (f *AIModelFactory) ModelTypes() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	types := make([]string, 0, len(f.creators))
+	for modelType := range f.creators {
+		types = append(types, modelType)
+	}
+	sort.Strings(types)
+	return types
 }
 
 // CreateAIModel 根据 modelType 创建具体 AIModel 实例
@@ -81,7 +111,7 @@ func (f *AIModelFactory) CreateAIHelper(ctx context.Context, modelType string, s
 	if err != nil {
 		return nil, err
 	}
-	helper := NewAIHelper(model, sessionID)
+	helper := NewAIHelper(model, modelType, sessionID)
 	logger.L().Info("AIHelper created",
 		zap.String("session_id", sessionID),
 		zap.String("model_type", modelType),
@@ -103,3 +133,27 @@ func (f *AIModelFactory) RegisterModel(modelType string, creator ModelCreator) {
 		zap.String("model_type", modelType))
 
 }
+
+// CreateEmbeddingModel 根据 modelType 创建向量模型
+func (f *AIModelFactory) CreateEmbeddingModel(ctx context.Context, modelType string, config map[string]interface{}) (EmbeddingModel, error) {
+	f.mu.Lock()
+	creator, ok := f.embeddingCreators[modelType]
+	f.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unsupported embedding model type: %s", modelType)
+	}
+	return creator(ctx, config)
+}
+
+// RegisterEmbeddingModel 可扩展注册向量模型
+func (f *AIModelFactory) RegisterEmbeddingModel(modelType string, creator EmbeddingCreator) {
+	if modelType == "" || creator == nil {
+		logger.L().Warn("embedding model type or creator is nil")
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.embeddingCreators[modelType] = creator
+	logger.L().Info("New embedding model registered",
+		zap.String("model_type", modelType))
+}
@@ -0,0 +1,20 @@
+package ai
+
+import (
+	"wsai/backend/config"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// EstimateCost 根据配置中的计价表估算一次调用的费用，未配置的模型按 0 计
+func EstimateCost(modelType string, usage *schema.TokenUsage) float64 {
+	if usage == nil || config.C == nil {
+		return 0
+	}
+	price, ok := config.C.Pricing[modelType]
+	if !ok {
+		return 0
+	}
+	return float64(usage.PromptTokens)/1000*price.PromptPer1K +
+		float64(usage.CompletionTokens)/1000*price.CompletionPer1K
+}
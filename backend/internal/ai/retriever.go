@@ -0,0 +1,58 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// Reference 检索到的一段参考资料，Index 从 1 开始，对应回答中的 [n] 标注
+type Reference struct {
+	Index      int     `json:"index"`
+	DocumentID int64   `json:"documentId"`
+	ChunkID    int64   `json:"chunkId"`
+	Source     string  `json:"source"` // 文件名
+	Content    string  `json:"content"`
+	Score      float64 `json:"score"`
+}
+
+// Retriever 按问题检索参考资料，会话绑定知识库时使用
+type Retriever interface {
+	Retrieve(ctx context.Context, query string) ([]Reference, error)
+}
+
+// StreamOption StreamResponse 的可选参数
+type StreamOption func(*streamOptions)
+
+type streamOptions struct {
+	retriever  Retriever
+	onRetrieve func([]Reference)
+}
+
+// WithRetriever 生成前先检索参考资料并注入提示词；onRetrieve 在检索到资料后、开始生成前调用，可为 nil
+func WithRetriever(r Retriever, onRetrieve func([]Reference)) StreamOption {
+	return func(o *streamOptions) {
+		o.retriever = r
+		o.onRetrieve = onRetrieve
+	}
+}
+
+// injectReferences 把参考资料作为系统消息插入到最后一条用户消息之前，不写入会话历史
+func injectReferences(messages []*schema.Message, refs []Reference) []*schema.Message {
+	if len(refs) == 0 || len(messages) == 0 {
+		return messages
+	}
+	var b strings.Builder
+	b.WriteString("以下是从用户知识库中检索到的参考资料。回答时优先依据这些资料，")
+	b.WriteString("引用时在句末用 [编号] 标注来源；资料与问题无关时忽略它们，不要编造引用。\n")
+	for _, ref := range refs {
+		fmt.Fprintf(&b, "\n[%d] 《%s》\n%s\n", ref.Index, ref.Source, ref.Content)
+	}
+
+	out := make([]*schema.Message, 0, len(messages)+1)
+	out = append(out, messages[:len(messages)-1]...)
+	out = append(out, schema.SystemMessage(b.String()), messages[len(messages)-1])
+	return out
+}
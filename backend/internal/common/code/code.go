@@ -16,14 +16,35 @@ const (
 	CodeRecordNotFound   Code = 2009
 	CodeIllegalPassword  Code = 2010
 	CodeInvalidImageType Code = 2011
+	CodeTooManyAPIKeys   Code = 2012
+	CodeInvalidRefresh   Code = 2013
+	CodeRefreshReused    Code = 2014
+	CodeTwoFactorEnabled Code = 2015
+	CodeTwoFactorOff     Code = 2016
+	CodeInvalidOTP       Code = 2017
+	CodeInvalidChallenge Code = 2018
+	CodeOIDCFailed       Code = 2019
+	CodeOIDCDomain       Code = 2020
+	CodeUserDisabled     Code = 2021
+	CodeAccountLocked    Code = 2022
+	CodeLoginThrottled   Code = 2023
+	CodeIPBlocked        Code = 2024
+	CodeCaptchaCooldown  Code = 2025
+	CodeCaptchaLimit     Code = 2026
+	CodeImageCaptcha     Code = 2027
+	CodeDocumentType     Code = 2028
+	CodeDocumentTooLarge Code = 2029
 
-	CodeForbidden Code = 3001
+	CodeForbidden       Code = 3001
+	CodeQuotaExceeded   Code = 3002
+	CodeTooManyRequests Code = 3003
 
 	CodeServerBusy Code = 4001
 
 	AIModelNotFind    Code = 5001
 	AIModelCannotOpen Code = 5002
 	AIModelFail       Code = 5003
+	AIModelCanceled   Code = 5004
 )
 
 var msg = map[Code]string{
@@ -40,14 +61,35 @@ var msg = map[Code]string{
 	CodeRecordNotFound:   "记录不存在",
 	CodeIllegalPassword:  "密码不合法",
 	CodeInvalidImageType: "图片格式不合适，仅支持 png jpeg gif",
+	CodeTooManyAPIKeys:   "API Key 数量已达上限",
+	CodeInvalidRefresh:   "登录已过期，请重新登录",
+	CodeRefreshReused:    "登录凭证异常，请重新登录",
+	CodeTwoFactorEnabled: "两步验证已开启",
+	CodeTwoFactorOff:     "两步验证未开启",
+	CodeInvalidOTP:       "动态验证码错误",
+	CodeInvalidChallenge: "登录验证已过期，请重新登录",
+	CodeOIDCFailed:       "单点登录失败，请重试",
+	CodeOIDCDomain:       "该邮箱域名不允许登录",
+	CodeUserDisabled:     "账号已被禁用",
+	CodeAccountLocked:    "密码错误次数过多，账号已临时锁定，请稍后再试或重置密码",
+	CodeLoginThrottled:   "登录失败次数较多，请稍后再试",
+	CodeIPBlocked:        "当前网络登录失败次数过多，请稍后再试",
+	CodeCaptchaCooldown:  "验证码发送过于频繁，请稍后再试",
+	CodeCaptchaLimit:     "今日验证码发送次数已达上限",
+	CodeImageCaptcha:     "图形验证码错误或已过期",
+	CodeDocumentType:     "文档格式不支持，仅支持 txt、md、pdf、docx",
+	CodeDocumentTooLarge: "文档超过大小上限",
 
-	CodeForbidden: "权限不足",
+	CodeForbidden:       "权限不足",
+	CodeQuotaExceeded:   "额度已用完",
+	CodeTooManyRequests: "请求过于频繁，请稍后再试",
 
 	CodeServerBusy: "服务繁忙",
 
 	AIModelNotFind:    "模型不存在",
 	AIModelCannotOpen: "无法打开模型",
 	AIModelFail:       "模型运行失败",
+	AIModelCanceled:   "生成已取消",
 }
 
 func (code Code) Code() int64 {
@@ -73,6 +73,20 @@ func Init() error {
 		return err
 	}
 
+	if err := seedPlans(); err != nil {
+		logger.L().Error("MySQL 写入内置套餐失败",
+			zap.Error(err),
+		)
+		return err
+	}
+
+	if err := seedAdmins(); err != nil {
+		logger.L().Error("MySQL 授予管理员角色失败",
+			zap.Error(err),
+		)
+		return err
+	}
+
 	logger.L().Info("MySQL 初始化成功",
 		zap.String("database", database),
 	)
@@ -85,9 +99,48 @@ func autoMigrate() error {
 		new(model.User),
 		new(model.Session),
 		new(model.Message),
+		new(model.Plan),
+		new(model.APIKey),
+		new(model.LoginSession),
+		new(model.RecoveryCode),
+		new(model.UserIdentity),
+		new(model.AuditLog),
+		new(model.KnowledgeBase),
+		new(model.Document),
+		new(model.DocumentChunk),
 	)
 }
 
+// defaultPlans 内置套餐，首次启动时写入，之后可直接在数据库中调整
+var defaultPlans = []model.Plan{
+	{Name: model.DefaultPlan, DailyTokens: 100000, MonthlyTokens: 2000000, DailyRequests: 200, MonthlyRequests: 4000},
+	{Name: "pro", DailyTokens: 1000000, MonthlyTokens: 20000000, DailyRequests: 2000, MonthlyRequests: 40000},
+	{Name: "unlimited"},
+}
+
+// seedPlans 写入缺失的内置套餐，已存在的不覆盖
+func seedPlans() error {
+	for i := range defaultPlans {
+		plan := defaultPlans[i]
+		if err := DB.Where("name = ?", plan.Name).FirstOrCreate(&plan).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seedAdmins 为配置中的用户授予管理员角色，便于首次部署时无需直接改库
+func seedAdmins() error {
+	admins := config.C.App.Admins
+	if len(admins) == 0 {
+		return nil
+	}
+	return DB.Model(&model.User{}).
+		Where("username IN ? OR email IN ?", admins, admins).
+		Update("role", model.RoleAdmin).
+		Error
+}
+
 // Close 关闭连接
 func Close() error {
 	if DB != nil {
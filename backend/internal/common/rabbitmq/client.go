@@ -74,6 +74,7 @@ type RabbitMQ struct {
 	Exchange  string
 	Key       string
 	queueName string
+	args      amqp.Table // 队列参数，如死信交换机、消息 TTL
 }
 
 func NewRabbitMQ(exchange, key string) *RabbitMQ {
@@ -97,8 +98,14 @@ func (r *RabbitMQ) Destroy() {
 }
 
 func NewWorkRabbitMQ(queue string) *RabbitMQ {
+	return NewWorkRabbitMQWithArgs(queue, nil)
+}
+
+// NewWorkRabbitMQWithArgs 声明带参数的工作队列，同一队列每次声明的参数必须一致
+func NewWorkRabbitMQWithArgs(queue string, args amqp.Table) *RabbitMQ {
 	rabbitmq := NewRabbitMQ("", queue)
 	rabbitmq.queueName = queue
+	rabbitmq.args = args
 	if conn == nil {
 		_ = initConn()
 	}
@@ -111,7 +118,7 @@ func NewWorkRabbitMQ(queue string) *RabbitMQ {
 		false,
 		false,
 		false,
-		nil)
+		args)
 	if err != nil {
 		logger.L().Fatal("RabbitMQ channel failed",
 			zap.Error(err),
@@ -140,7 +147,7 @@ func (r *RabbitMQ) reconnect() {
 	}
 	r.channel = ch
 
-	_, err = ch.QueueDeclare(r.queueName, true, false, false, false, nil)
+	_, err = ch.QueueDeclare(r.queueName, true, false, false, false, r.args)
 	if err != nil {
 		logger.L().Error("Reconnect: queue declare failed", zap.Error(err))
 	}
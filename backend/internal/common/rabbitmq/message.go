@@ -12,27 +12,37 @@ import (
 )
 
 type MessageMQPara struct {
-	SessionID string `json:"session_id"`
-	Content   string `json:"content"`
-	Username  string `json:"username"`
-	IsUser    bool   `json:"is_user"`
+	SessionID        string  `json:"session_id"`
+	Content          string  `json:"content"`
+	Username         string  `json:"username"`
+	IsUser           bool    `json:"is_user"`
+	ModelType        string  `json:"model_type,omitempty"`
+	PromptTokens     int     `json:"prompt_tokens,omitempty"`
+	CompletionTokens int     `json:"completion_tokens,omitempty"`
+	TotalTokens      int     `json:"total_tokens,omitempty"`
+	Cost             float64 `json:"cost,omitempty"`
 }
 
-func GenerateMessageMQPara(sessionID string, content string, username string, isUser bool) ([]byte, error) {
+func GenerateMessageMQPara(msg *model.Message) ([]byte, error) {
 	para := MessageMQPara{
-		SessionID: sessionID,
-		Content:   content,
-		Username:  username,
-		IsUser:    isUser,
+		SessionID:        msg.SessionID,
+		Content:          msg.Content,
+		Username:         msg.UserName,
+		IsUser:           msg.IsUser,
+		ModelType:        msg.ModelType,
+		PromptTokens:     msg.PromptTokens,
+		CompletionTokens: msg.CompletionTokens,
+		TotalTokens:      msg.TotalTokens,
+		Cost:             msg.Cost,
 	}
 
 	data, err := json.Marshal(para)
 	if err != nil {
 		logger.L().Error("RabbitMQ message marshal failed in generateMessageMQPara",
 			zap.Error(err),
-			zap.String("sessionID", sessionID),
-			zap.String("username", username),
-			zap.Bool("isUser", isUser))
+			zap.String("sessionID", msg.SessionID),
+			zap.String("username", msg.UserName),
+			zap.Bool("isUser", msg.IsUser))
 		return nil, err
 	}
 	return data, nil
@@ -57,11 +67,16 @@ func ProcessMessageDelivery(msg *amqp.Delivery) error {
 	)
 
 	newMsg := &model.Message{
-		SessionID: para.SessionID,
-		Content:   para.Content,
-		UserName:  para.Username,
-		IsUser:    para.IsUser,
-		CreatedAt: time.Now(),
+		SessionID:        para.SessionID,
+		Content:          para.Content,
+		UserName:         para.Username,
+		IsUser:           para.IsUser,
+		ModelType:        para.ModelType,
+		PromptTokens:     para.PromptTokens,
+		CompletionTokens: para.CompletionTokens,
+		TotalTokens:      para.TotalTokens,
+		Cost:             para.Cost,
+		CreatedAt:        time.Now(),
 	}
 	if _, err := message.CreateMessage(newMsg); err != nil {
 		logger.L().Error("Save chatMessage message to DB failed",
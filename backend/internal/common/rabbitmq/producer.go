@@ -33,7 +33,7 @@ func (r *RabbitMQ) publishWorkOnce(message []byte) error {
 		false,
 		false,
 		false,
-		nil,
+		r.args,
 	)
 	if err != nil {
 		logger.L().Error("RabbitMQ queue declare failed",
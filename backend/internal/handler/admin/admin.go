@@ -0,0 +1,265 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"wsai/backend/internal/common"
+	"wsai/backend/internal/common/code"
+	"wsai/backend/internal/model"
+	"wsai/backend/internal/service/admin"
+	"wsai/backend/internal/service/audit"
+	"wsai/backend/internal/service/usage"
+
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	ListUsersResponse struct {
+		*admin.UserPage
+		common.Response
+	}
+	GetUserResponse struct {
+		User *admin.UserDetail `json:"user,omitempty"`
+		common.Response
+	}
+	UpdateUserRequest struct {
+		Role     *string `json:"role"` // user、admin
+		Plan     *string `json:"plan"`
+		Disabled *bool   `json:"disabled"`
+	}
+	UpdateUserResponse struct {
+		User *model.User `json:"user,omitempty"`
+		common.Response
+	}
+	ResetQuotaResponse struct {
+		common.Response
+	}
+	UnlockUserResponse struct {
+		common.Response
+	}
+	GetUserUsageResponse struct {
+		Usage *usage.Report `json:"usage,omitempty"`
+		common.Response
+	}
+)
+
+// describe 拼出本次修改的字段，写入审计日志
+func (r *UpdateUserRequest) describe() string {
+	var parts []string
+	if r.Role != nil {
+		parts = append(parts, "role="+*r.Role)
+	}
+	if r.Plan != nil {
+		parts = append(parts, "plan="+*r.Plan)
+	}
+	if r.Disabled != nil {
+		parts = append(parts, "disabled="+strconv.FormatBool(*r.Disabled))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func parseID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	return id, err == nil
+}
+
+// ListUsers godoc
+// @Summary 用户列表
+// @Description 分页查询用户，keyword 按用户名、邮箱、昵称模糊匹配。仅管理员可用。
+// @Tags 管理后台
+// @Produce json
+// @Security ApiKeyAuth
+// @Param keyword query string false "关键字"
+// @Param page query int false "页码，默认 1"
+// @Param page_size query int false "每页数量，默认 20，最大 100"
+// @Success 200 {object} ListUsersResponse
+// @Router /api/v1/admin/users [get]
+func ListUsers(c *gin.Context) {
+	res := new(ListUsersResponse)
+	page, _ := strconv.Atoi(c.Query("page"))
+	pageSize, _ := strconv.Atoi(c.Query("page_size"))
+	result, code_ := admin.ListUsers(c.Query("keyword"), page, pageSize)
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	res.UserPage = result
+	c.JSON(http.StatusOK, res)
+}
+
+// GetUser godoc
+// @Summary 用户详情
+// @Description 查询用户信息及当前剩余额度。仅管理员可用。
+// @Tags 管理后台
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "用户 ID"
+// @Success 200 {object} GetUserResponse
+// @Router /api/v1/admin/users/{id} [get]
+func GetUser(c *gin.Context) {
+	res := new(GetUserResponse)
+	id, ok := parseID(c)
+	if !ok {
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+	detail, code_ := admin.GetUser(ctx, id)
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	res.User = detail
+	c.JSON(http.StatusOK, res)
+}
+
+// UpdateUser godoc
+// @Summary 修改用户
+// @Description 修改用户角色、套餐或禁用状态，未传的字段保持不变。禁用用户或修改角色会吊销其全部登录；不能禁用自己或修改自己的角色。仅管理员可用。
+// @Tags 管理后台
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "用户 ID"
+// @Param request body UpdateUserRequest true "修改参数"
+// @Success 200 {object} UpdateUserResponse
+// @Router /api/v1/admin/users/{id} [patch]
+func UpdateUser(c *gin.Context) {
+	req := new(UpdateUserRequest)
+	res := new(UpdateUserResponse)
+	id, ok := parseID(c)
+	if !ok {
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+	u, code_ := admin.UpdateUser(ctx, c.GetInt64("userID"), id, admin.UserUpdate{
+		Role:     req.Role,
+		Plan:     req.Plan,
+		Disabled: req.Disabled,
+	})
+	audit.RecordResult(c, audit.Entry{
+		Action:     audit.ActionAdminUser,
+		TargetType: audit.TargetUser,
+		TargetID:   c.Param("id"),
+		Detail:     req.describe(),
+	}, code_)
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	res.User = u
+	c.JSON(http.StatusOK, res)
+}
+
+// ResetQuota godoc
+// @Summary 重置用户额度
+// @Description 清空用户当天与当月已使用的额度。仅管理员可用。
+// @Tags 管理后台
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "用户 ID"
+// @Success 200 {object} ResetQuotaResponse
+// @Router /api/v1/admin/users/{id}/quota/reset [post]
+func ResetQuota(c *gin.Context) {
+	res := new(ResetQuotaResponse)
+	id, ok := parseID(c)
+	if !ok {
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+	code_ := admin.ResetQuota(ctx, id)
+	audit.RecordResult(c, audit.Entry{
+		Action:     audit.ActionAdminQuota,
+		TargetType: audit.TargetUser,
+		TargetID:   c.Param("id"),
+	}, code_)
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	c.JSON(http.StatusOK, res)
+}
+
+// UnlockUser godoc
+// @Summary 解除登录锁定
+// @Description 清除用户因密码错误次数过多造成的临时锁定及失败计数。仅管理员可用。
+// @Tags 管理后台
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "用户 ID"
+// @Success 200 {object} UnlockUserResponse
+// @Router /api/v1/admin/users/{id}/unlock [post]
+func UnlockUser(c *gin.Context) {
+	res := new(UnlockUserResponse)
+	id, ok := parseID(c)
+	if !ok {
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+	code_ := admin.UnlockUser(ctx, id)
+	audit.RecordResult(c, audit.Entry{
+		Action:     audit.ActionAdminUnlock,
+		TargetType: audit.TargetUser,
+		TargetID:   c.Param("id"),
+	}, code_)
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	c.JSON(http.StatusOK, res)
+}
+
+// GetUserUsage godoc
+// @Summary 查询用户用量
+// @Description 按天、按会话汇总指定用户最近 days 天的 token 用量及估算费用。仅管理员可用。
+// @Tags 管理后台
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "用户 ID"
+// @Param days query int false "统计天数，默认 30，最大 365"
+// @Success 200 {object} GetUserUsageResponse
+// @Router /api/v1/admin/users/{id}/usage [get]
+func GetUserUsage(c *gin.Context) {
+	res := new(GetUserUsageResponse)
+	id, ok := parseID(c)
+	if !ok {
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	days := 0
+	if raw := c.Query("days"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+			return
+		}
+		days = n
+	}
+	report, code_ := admin.GetUserUsage(id, days)
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	res.Usage = report
+	c.JSON(http.StatusOK, res)
+}
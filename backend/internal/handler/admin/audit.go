@@ -0,0 +1,145 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"wsai/backend/internal/common"
+	"wsai/backend/internal/common/code"
+	"wsai/backend/internal/logger"
+	"wsai/backend/internal/service/audit"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ListAuditLogsResponse struct {
+	*audit.Page
+	common.Response
+}
+
+// parseTime 支持 RFC3339 和 2006-01-02 两种格式
+func parseTime(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", raw, time.Local)
+}
+
+// parseAuditFilter 从查询参数解析过滤条件
+func parseAuditFilter(c *gin.Context) (audit.Filter, bool) {
+	f := audit.Filter{
+		Action:     c.Query("action"),
+		ActorName:  c.Query("actor"),
+		IP:         c.Query("ip"),
+		TargetType: c.Query("target_type"),
+		TargetID:   c.Query("target_id"),
+	}
+	if raw := c.Query("actor_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return f, false
+		}
+		f.ActorID = id
+	}
+	if raw := c.Query("success"); raw != "" {
+		ok, err := strconv.ParseBool(raw)
+		if err != nil {
+			return f, false
+		}
+		f.Success = &ok
+	}
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"from", &f.From}, {"to", &f.To}} {
+		if raw := c.Query(p.name); raw != "" {
+			t, err := parseTime(raw)
+			if err != nil {
+				return f, false
+			}
+			*p.dst = t
+		}
+	}
+	return f, true
+}
+
+// ListAuditLogs godoc
+// @Summary 审计日志
+// @Description 按条件分页查询审计日志，最新的在前。仅管理员可用。
+// @Tags 管理后台
+// @Produce json
+// @Security ApiKeyAuth
+// @Param action query string false "动作，如 login、password.change、apikey.create"
+// @Param actor_id query int false "操作者用户 ID"
+// @Param actor query string false "操作者用户名（登录失败时为输入的用户名或邮箱）"
+// @Param ip query string false "来源 IP"
+// @Param target_type query string false "对象类型：user、login、api_key"
+// @Param target_id query string false "对象 ID"
+// @Param success query bool false "是否成功"
+// @Param from query string false "开始时间（含），RFC3339 或 2006-01-02"
+// @Param to query string false "结束时间（不含），RFC3339 或 2006-01-02"
+// @Param page query int false "页码，默认 1"
+// @Param page_size query int false "每页数量，默认 20，最大 100"
+// @Success 200 {object} ListAuditLogsResponse
+// @Router /api/v1/admin/audit-logs [get]
+func ListAuditLogs(c *gin.Context) {
+	res := new(ListAuditLogsResponse)
+	f, ok := parseAuditFilter(c)
+	if !ok {
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	page, _ := strconv.Atoi(c.Query("page"))
+	pageSize, _ := strconv.Atoi(c.Query("page_size"))
+	result, code_ := audit.List(f, page, pageSize)
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	res.Page = result
+	c.JSON(http.StatusOK, res)
+}
+
+// ExportAuditLogs godoc
+// @Summary 导出审计日志
+// @Description 按与查询接口相同的条件导出 CSV（UTF-8 带 BOM），单次最多 100000 行。仅管理员可用。
+// @Tags 管理后台
+// @Produce text/csv
+// @Security ApiKeyAuth
+// @Param action query string false "动作"
+// @Param actor_id query int false "操作者用户 ID"
+// @Param actor query string false "操作者用户名"
+// @Param ip query string false "来源 IP"
+// @Param target_type query string false "对象类型"
+// @Param target_id query string false "对象 ID"
+// @Param success query bool false "是否成功"
+// @Param from query string false "开始时间（含）"
+// @Param to query string false "结束时间（不含）"
+// @Success 200 {string} string "CSV 文件"
+// @Router /api/v1/admin/audit-logs/export [get]
+func ExportAuditLogs(c *gin.Context) {
+	f, ok := parseAuditFilter(c)
+	if !ok {
+		res := new(common.Response)
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	filename := fmt.Sprintf("audit-%s.csv", time.Now().Format("20060102-150405"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+	// 已开始输出文件内容，出错时只能中断并记录日志
+	if err := audit.ExportCSV(c.Writer, f); err != nil {
+		logger.L().Error("导出审计日志失败", zap.Error(err))
+		return
+	}
+	audit.Record(audit.Entry{
+		Actor:   audit.ActorOf(c),
+		Action:  audit.ActionAuditExport,
+		Success: true,
+		Detail:  c.Request.URL.RawQuery,
+	})
+}
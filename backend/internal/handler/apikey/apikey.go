@@ -0,0 +1,164 @@
+package apikey
+
+import (
+	"net/http"
+	"strconv"
+	"wsai/backend/internal/common"
+	"wsai/backend/internal/common/code"
+	"wsai/backend/internal/service/apikey"
+	"wsai/backend/internal/service/audit"
+
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	CreateAPIKeyRequest struct {
+		Name   string   `json:"name" binding:"max=50"`
+		Scopes []string `json:"scopes"` // chat、image、usage，为空表示全部
+	}
+	CreateAPIKeyResponse struct {
+		Key    string          `json:"key,omitempty"` // 明文，仅此一次
+		APIKey *apikey.KeyInfo `json:"api_key,omitempty"`
+		common.Response
+	}
+	ListAPIKeysResponse struct {
+		APIKeys []apikey.KeyInfo `json:"api_keys"`
+		common.Response
+	}
+	UpdateAPIKeyRequest struct {
+		Name   *string  `json:"name" binding:"omitempty,max=50"`
+		Scopes []string `json:"scopes"`
+	}
+	UpdateAPIKeyResponse struct {
+		APIKey *apikey.KeyInfo `json:"api_key,omitempty"`
+		common.Response
+	}
+	RevokeAPIKeyResponse struct {
+		common.Response
+	}
+)
+
+// CreateAPIKey godoc
+// @Summary 创建 API Key
+// @Description 为当前用户创建长期 API Key，可限定权限范围（chat、image、usage），明文只在本次响应中返回，请妥善保存。
+// @Tags API Key
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body CreateAPIKeyRequest true "API Key 参数"
+// @Success 200 {object} CreateAPIKeyResponse
+// @Router /api/v1/user/api-keys [post]
+func CreateAPIKey(c *gin.Context) {
+	req := new(CreateAPIKeyRequest)
+	res := new(CreateAPIKeyResponse)
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	plain, key, code_ := apikey.Create(c.GetInt64("userID"), c.GetString("username"), req.Name, req.Scopes)
+	entry := audit.Entry{
+		Action:     audit.ActionAPIKeyCreate,
+		TargetType: audit.TargetAPIKey,
+		Detail:     "name=" + req.Name,
+	}
+	if key != nil {
+		entry.TargetID = strconv.FormatInt(key.ID, 10)
+	}
+	audit.RecordResult(c, entry, code_)
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	res.Key = plain
+	res.APIKey = key
+	c.JSON(http.StatusOK, res)
+}
+
+// ListAPIKeys godoc
+// @Summary 列出 API Key
+// @Description 列出当前用户未吊销的 API Key（不含明文），包含最近使用时间。
+// @Tags API Key
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} ListAPIKeysResponse
+// @Router /api/v1/user/api-keys [get]
+func ListAPIKeys(c *gin.Context) {
+	res := new(ListAPIKeysResponse)
+	keys, code_ := apikey.List(c.GetInt64("userID"))
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	res.APIKeys = keys
+	c.JSON(http.StatusOK, res)
+}
+
+// UpdateAPIKey godoc
+// @Summary 修改 API Key
+// @Description 修改 API Key 的名称或权限范围，未传的字段保持不变。
+// @Tags API Key
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "API Key ID"
+// @Param request body UpdateAPIKeyRequest true "修改参数"
+// @Success 200 {object} UpdateAPIKeyResponse
+// @Router /api/v1/user/api-keys/{id} [patch]
+func UpdateAPIKey(c *gin.Context) {
+	req := new(UpdateAPIKeyRequest)
+	res := new(UpdateAPIKeyResponse)
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	key, code_ := apikey.Update(c.GetInt64("userID"), id, req.Name, req.Scopes)
+	audit.RecordResult(c, audit.Entry{
+		Action:     audit.ActionAPIKeyUpdate,
+		TargetType: audit.TargetAPIKey,
+		TargetID:   c.Param("id"),
+	}, code_)
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	res.APIKey = key
+	c.JSON(http.StatusOK, res)
+}
+
+// RevokeAPIKey godoc
+// @Summary 吊销 API Key
+// @Description 吊销后该 API Key 立即失效。
+// @Tags API Key
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "API Key ID"
+// @Success 200 {object} RevokeAPIKeyResponse
+// @Router /api/v1/user/api-keys/{id} [delete]
+func RevokeAPIKey(c *gin.Context) {
+	res := new(RevokeAPIKeyResponse)
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	code_ := apikey.Revoke(c.GetInt64("userID"), id)
+	audit.RecordResult(c, audit.Entry{
+		Action:     audit.ActionAPIKeyRevoke,
+		TargetType: audit.TargetAPIKey,
+		TargetID:   c.Param("id"),
+	}, code_)
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,244 @@
+package knowledge
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"wsai/backend/internal/common"
+	"wsai/backend/internal/common/code"
+	"wsai/backend/internal/model"
+	"wsai/backend/internal/service/rag"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead 上传请求体除文件外预留的大小
+const multipartOverhead = 1 << 20
+
+type (
+	CreateKnowledgeBaseRequest struct {
+		Name        string `json:"name" binding:"required"`
+		Description string `json:"description"`
+	}
+	UpdateKnowledgeBaseRequest struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+	}
+	KnowledgeBaseResponse struct {
+		KnowledgeBase *model.KnowledgeBase `json:"knowledge_base,omitempty"`
+		common.Response
+	}
+	ListKnowledgeBasesResponse struct {
+		KnowledgeBases []*model.KnowledgeBase `json:"knowledge_bases"`
+		common.Response
+	}
+	DocumentResponse struct {
+		Document *model.Document `json:"document,omitempty"`
+		common.Response
+	}
+	ListDocumentsResponse struct {
+		Documents []*model.Document `json:"documents"`
+		common.Response
+	}
+)
+
+func paramID(c *gin.Context, name string) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param(name), 10, 64)
+	return id, err == nil && id > 0
+}
+
+// ListKnowledgeBases godoc
+// @Summary 列出知识库
+// @Description 列出当前用户的知识库及文档数量。
+// @Tags 知识库
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} ListKnowledgeBasesResponse
+// @Router /api/v1/knowledge-bases [get]
+func ListKnowledgeBases(c *gin.Context) {
+	res := new(ListKnowledgeBasesResponse)
+	bases, code_ := rag.ListKnowledgeBases(c.GetInt64("userID"))
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	res.KnowledgeBases = bases
+	c.JSON(http.StatusOK, res)
+}
+
+// CreateKnowledgeBase godoc
+// @Summary 新建知识库
+// @Description 新建一个空知识库，名称不超过 100 字，描述不超过 500 字。
+// @Tags 知识库
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body CreateKnowledgeBaseRequest true "知识库信息"
+// @Success 200 {object} KnowledgeBaseResponse
+// @Router /api/v1/knowledge-bases [post]
+func CreateKnowledgeBase(c *gin.Context) {
+	req := new(CreateKnowledgeBaseRequest)
+	res := new(KnowledgeBaseResponse)
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	kb, code_ := rag.CreateKnowledgeBase(c.GetInt64("userID"), req.Name, req.Description)
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	res.KnowledgeBase = kb
+	c.JSON(http.StatusOK, res)
+}
+
+// UpdateKnowledgeBase godoc
+// @Summary 修改知识库
+// @Description 修改知识库名称或描述，未传的字段保持不变。
+// @Tags 知识库
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "知识库ID"
+// @Param request body UpdateKnowledgeBaseRequest true "修改参数"
+// @Success 200 {object} KnowledgeBaseResponse
+// @Router /api/v1/knowledge-bases/{id} [patch]
+func UpdateKnowledgeBase(c *gin.Context) {
+	req := new(UpdateKnowledgeBaseRequest)
+	res := new(KnowledgeBaseResponse)
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	kb, code_ := rag.UpdateKnowledgeBase(c.GetInt64("userID"), id, rag.KnowledgeBaseUpdate{
+		Name:        req.Name,
+		Description: req.Description,
+	})
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	res.KnowledgeBase = kb
+	c.JSON(http.StatusOK, res)
+}
+
+// DeleteKnowledgeBase godoc
+// @Summary 删除知识库
+// @Description 删除知识库及其全部文档，绑定了该知识库的会话自动解除绑定。
+// @Tags 知识库
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "知识库ID"
+// @Success 200 {object} common.Response
+// @Router /api/v1/knowledge-bases/{id} [delete]
+func DeleteKnowledgeBase(c *gin.Context) {
+	res := new(common.Response)
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	if code_ := rag.DeleteKnowledgeBase(c.GetInt64("userID"), id); code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	c.JSON(http.StatusOK, res)
+}
+
+// ListDocuments godoc
+// @Summary 列出文档
+// @Description 列出知识库中的文档及处理状态（pending、processing、ready、failed）。
+// @Tags 知识库
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "知识库ID"
+// @Success 200 {object} ListDocumentsResponse
+// @Router /api/v1/knowledge-bases/{id}/documents [get]
+func ListDocuments(c *gin.Context) {
+	res := new(ListDocumentsResponse)
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	docs, code_ := rag.ListDocuments(c.GetInt64("userID"), id)
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	res.Documents = docs
+	c.JSON(http.StatusOK, res)
+}
+
+// UploadDocument godoc
+// @Summary 上传文档
+// @Description 上传 txt、md、pdf、docx 文档到知识库，文档在后台解析、切块并向量化，返回时状态为 pending，可轮询文档列表查看进度。
+// @Tags 知识库
+// @Accept multipart/form-data
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "知识库ID"
+// @Param file formData file true "文档"
+// @Success 200 {object} DocumentResponse
+// @Router /api/v1/knowledge-bases/{id}/documents [post]
+func UploadDocument(c *gin.Context) {
+	res := new(DocumentResponse)
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, rag.MaxFileSize()+multipartOverhead)
+	file, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusOK, res.CodeOf(code.CodeDocumentTooLarge))
+			return
+		}
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	doc, code_ := rag.UploadDocument(c.GetInt64("userID"), id, file)
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	res.Document = doc
+	c.JSON(http.StatusOK, res)
+}
+
+// DeleteDocument godoc
+// @Summary 删除文档
+// @Description 删除文档及其切块，之后的检索不再引用该文档。
+// @Tags 知识库
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "文档ID"
+// @Success 200 {object} common.Response
+// @Router /api/v1/knowledge-bases/documents/{id} [delete]
+func DeleteDocument(c *gin.Context) {
+	res := new(common.Response)
+	id, ok := paramID(c, "id")
+	if !ok {
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	if code_ := rag.DeleteDocument(c.GetInt64("userID"), id); code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,108 @@
+package loginsession
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+	"wsai/backend/internal/common"
+	"wsai/backend/internal/common/code"
+	"wsai/backend/internal/middleware/jwt"
+	"wsai/backend/internal/service/audit"
+	"wsai/backend/internal/service/loginsession"
+
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	ListLoginsResponse struct {
+		Logins []loginsession.Info `json:"logins"`
+		common.Response
+	}
+	RevokeLoginResponse struct {
+		common.Response
+	}
+	RevokeAllLoginsResponse struct {
+		Revoked int `json:"revoked"`
+		common.Response
+	}
+)
+
+// ListLogins godoc
+// @Summary 列出登录设备
+// @Description 列出当前用户所有有效的登录（设备名称、IP、User-Agent、登录时间、最近活跃时间），current 标记发起本次请求的登录。
+// @Tags 用户认证
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} ListLoginsResponse
+// @Router /api/v1/user/logins [get]
+func ListLogins(c *gin.Context) {
+	res := new(ListLoginsResponse)
+	logins, code_ := loginsession.List(c.GetInt64("userID"), c.GetString(jwt.JTIContextKey))
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	res.Logins = logins
+	c.JSON(http.StatusOK, res)
+}
+
+// RevokeLogin godoc
+// @Summary 吊销登录设备
+// @Description 吊销指定的登录，该设备上的 access/refresh token 立即失效。
+// @Tags 用户认证
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "登录 ID"
+// @Success 200 {object} RevokeLoginResponse
+// @Router /api/v1/user/logins/{id} [delete]
+func RevokeLogin(c *gin.Context) {
+	res := new(RevokeLoginResponse)
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+	code_ := loginsession.Revoke(ctx, c.GetInt64("userID"), id)
+	audit.RecordResult(c, audit.Entry{
+		Action:     audit.ActionLoginRevoke,
+		TargetType: audit.TargetLogin,
+		TargetID:   c.Param("id"),
+	}, code_)
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	c.JSON(http.StatusOK, res)
+}
+
+// RevokeAllLogins godoc
+// @Summary 吊销其他登录设备
+// @Description 吊销当前用户除本次请求所用登录之外的全部登录，返回吊销数量；退出当前登录请使用 /user/logout。
+// @Tags 用户认证
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} RevokeAllLoginsResponse
+// @Router /api/v1/user/logins [delete]
+func RevokeAllLogins(c *gin.Context) {
+	res := new(RevokeAllLoginsResponse)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+	n, code_ := loginsession.RevokeAll(ctx, c.GetInt64("userID"), c.GetString(jwt.JTIContextKey))
+	audit.RecordResult(c, audit.Entry{
+		Action:     audit.ActionLoginRevoke,
+		TargetType: audit.TargetLogin,
+		Detail:     "scope=others; revoked=" + strconv.Itoa(n),
+	}, code_)
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	res.Revoked = n
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,203 @@
+package openai
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+	"wsai/backend/internal/common/code"
+	"wsai/backend/internal/logger"
+	"wsai/backend/internal/service/openai"
+	"wsai/backend/internal/service/quota"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// 以下类型与 OpenAI Chat Completions API 的响应结构保持一致
+type (
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	}
+	Choice struct {
+		Index        int                 `json:"index"`
+		Message      *openai.ChatMessage `json:"message,omitempty"`
+		Delta        *openai.ChatMessage `json:"delta,omitempty"`
+		FinishReason *string             `json:"finish_reason"`
+	}
+	ChatCompletionResponse struct {
+		ID      string   `json:"id"`
+		Object  string   `json:"object"`
+		Created int64    `json:"created"`
+		Model   string   `json:"model"`
+		Choices []Choice `json:"choices"`
+		Usage   *Usage   `json:"usage,omitempty"`
+		// SessionID wsAI 扩展：持久化时对应的会话 ID
+		SessionID string `json:"wsai_session_id,omitempty"`
+	}
+	ModelInfo struct {
+		ID      string `json:"id"`
+		Object  string `json:"object"`
+		OwnedBy string `json:"owned_by"`
+	}
+	ModelListResponse struct {
+		Object string      `json:"object"`
+		Data   []ModelInfo `json:"data"`
+	}
+)
+
+var finishStop = "stop"
+
+// ChatCompletions OpenAI 兼容的对话补全接口
+// @Summary      OpenAI 兼容对话补全
+// @Description  使用 API Key（Authorization: Bearer wsai-...）调用，支持 stream；wsai_persist / wsai_session_id 扩展字段可将调用保存为 wsAI 会话
+// @Tags         OpenAI 兼容
+// @Accept       json
+// @Produce      json
+// @Param        body  body  openai.ChatCompletionRequest  true  "请求参数"
+// @Success      200   {object}  ChatCompletionResponse
+// @Router       /v1/chat/completions [post]
+func ChatCompletions(c *gin.Context) {
+	req := new(openai.ChatCompletionRequest)
+	if err := c.ShouldBindJSON(req); err != nil {
+		writeError(c, code.CodeInvalidParams, err.Error())
+		return
+	}
+	userName := c.GetString("username")
+
+	reservation, remaining, code_ := quota.Reserve(c.Request.Context(), userName)
+	if remaining != nil {
+		remaining.WriteHeaders(c.Writer.Header())
+	}
+	if code_ != code.CodeSuccess {
+		writeError(c, code_, "")
+		return
+	}
+
+	id := "chatcmpl-" + uuid.New().String()
+	created := time.Now().Unix()
+	if !req.Stream {
+		result, code_ := openai.Complete(c.Request.Context(), userName, reservation, req, nil)
+		if code_ != code.CodeSuccess {
+			writeError(c, code_, "")
+			return
+		}
+		c.JSON(http.StatusOK, ChatCompletionResponse{
+			ID:      id,
+			Object:  "chat.completion",
+			Created: created,
+			Model:   req.Model,
+			Choices: []Choice{{
+				Message:      &openai.ChatMessage{Role: "assistant", Content: result.Content},
+				FinishReason: &finishStop,
+			}},
+			Usage:     toUsage(result),
+			SessionID: result.SessionID,
+		})
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	chunk := func(choice Choice, usage *Usage, sessionID string) ChatCompletionResponse {
+		choices := []Choice{}
+		if usage == nil {
+			choices = append(choices, choice)
+		}
+		return ChatCompletionResponse{
+			ID:        id,
+			Object:    "chat.completion.chunk",
+			Created:   created,
+			Model:     req.Model,
+			Choices:   choices,
+			Usage:     usage,
+			SessionID: sessionID,
+		}
+	}
+
+	writeChunk(c, chunk(Choice{Delta: &openai.ChatMessage{Role: "assistant"}}, nil, ""))
+	result, code_ := openai.Complete(c.Request.Context(), userName, reservation, req, func(msg string) {
+		writeChunk(c, chunk(Choice{Delta: &openai.ChatMessage{Content: msg}}, nil, ""))
+	})
+	if code_ != code.CodeSuccess {
+		_, status := errorStatus(code_)
+		writeChunk(c, gin.H{"error": gin.H{"message": code_.Msg(), "type": status, "code": code_}})
+		return
+	}
+	writeChunk(c, chunk(Choice{Delta: &openai.ChatMessage{}, FinishReason: &finishStop}, nil, result.SessionID))
+	if req.StreamOptions != nil && req.StreamOptions.IncludeUsage {
+		writeChunk(c, chunk(Choice{}, toUsage(result), result.SessionID))
+	}
+	_, _ = c.Writer.WriteString("data: [DONE]\n\n")
+	c.Writer.Flush()
+}
+
+// ListModels OpenAI 兼容的模型列表接口
+// @Summary      OpenAI 兼容模型列表
+// @Tags         OpenAI 兼容
+// @Produce      json
+// @Success      200   {object}  ModelListResponse
+// @Router       /v1/models [get]
+func ListModels(c *gin.Context) {
+	models := openai.ListModels()
+	res := ModelListResponse{Object: "list", Data: make([]ModelInfo, 0, len(models))}
+	for _, m := range models {
+		res.Data = append(res.Data, ModelInfo{ID: m, Object: "model", OwnedBy: "wsai"})
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func toUsage(result *openai.Result) *Usage {
+	return &Usage{
+		PromptTokens:     result.Usage.PromptTokens,
+		CompletionTokens: result.Usage.CompletionTokens,
+		TotalTokens:      result.Usage.TotalTokens,
+	}
+}
+
+func writeChunk(c *gin.Context, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		logger.L().Warn("openai chunk marshal error", zap.Error(err))
+		return
+	}
+	if _, err := fmt.Fprintf(c.Writer, "data: %s\n\n", data); err != nil {
+		return
+	}
+	c.Writer.Flush()
+}
+
+// errorStatus 把 wsAI 的错误码映射为 OpenAI 的 HTTP 状态码与错误类型
+func errorStatus(code_ code.Code) (int, string) {
+	switch code_ {
+	case code.CodeInvalidParams:
+		return http.StatusBadRequest, "invalid_request_error"
+	case code.AIModelNotFind, code.CodeRecordNotFound:
+		return http.StatusNotFound, "invalid_request_error"
+	case code.CodeQuotaExceeded:
+		return http.StatusTooManyRequests, "insufficient_quota"
+	case code.CodeForbidden:
+		return http.StatusForbidden, "permission_error"
+	default:
+		return http.StatusInternalServerError, "server_error"
+	}
+}
+
+func writeError(c *gin.Context, code_ code.Code, message string) {
+	status, errType := errorStatus(code_)
+	if message == "" {
+		message = code_.Msg()
+	}
+	c.AbortWithStatusJSON(status, gin.H{"error": gin.H{
+		"message": message,
+		"type":    errType,
+		"code":    code_,
+	}})
+}
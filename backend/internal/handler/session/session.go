@@ -1,14 +1,19 @@
 package session
 
 import (
-	"fmt"
 	"net/http"
+	"strconv"
+	"wsai/backend/internal/ai"
 	"wsai/backend/internal/common"
 	"wsai/backend/internal/common/code"
+	"wsai/backend/internal/logger"
 	"wsai/backend/internal/model"
+	"wsai/backend/internal/service/quota"
 	"wsai/backend/internal/service/session"
+	"wsai/backend/internal/service/user"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 type (
@@ -18,7 +23,7 @@ type (
 	}
 	CreateSessionAndSendFirstMessageRequest struct {
 		UserQuestion string `json:"question" binding:"required"`
-		ModelType    string `json:"modelType" binding:"required"`
+		ModelType    string `json:"modelType"` // 为空时使用个人资料中的默认模型
 	}
 	CreateSessionAndSendFirstMessageResponse struct {
 		AiInformation string `json:"Information,omitempty"` // AI回答
@@ -27,7 +32,7 @@ type (
 	}
 	SendMessageStreamRequest struct {
 		UserQuestion string `json:"question" binding:"required"`
-		ModelType    string `json:"modelType" binding:"required"`
+		ModelType    string `json:"modelType"` // 为空时使用个人资料中的默认模型
 		SessionID    string `json:"sessionId,omitempty" binding:"required"`
 	}
 	SendMessageStreamResponse struct {
@@ -41,6 +46,9 @@ type (
 		History []model.History `json:"history"`
 		common.Response
 	}
+	SetSessionKnowledgeBaseRequest struct {
+		KnowledgeBaseID *int64 `json:"knowledge_base_id"` // 为 null 表示解除绑定
+	}
 )
 
 // GetUserSessions 获取当前用户的所有会话列表
@@ -75,7 +83,7 @@ func GetUserSessionsByUsername(c *gin.Context) {
 // @Produce      text/event-stream
 // @Param        body  body  session.CreateSessionAndSendFirstMessageRequest  true  "请求参数"
 // @Security     ApiKeyAuth
-// @Success      200   {string}  string   "SSE 事件流"
+// @Success      200   {string}  string   "SSE 事件流：session、title、delta、usage、done"
 // @Failure      200   {string}  string   "SSE error 事件"
 // @Router       /api/v1/AI/chatMessage/sessions/stream [post]
 func CreateStreamSessionAndSendFirstMessage(c *gin.Context) {
@@ -85,31 +93,22 @@ func CreateStreamSessionAndSendFirstMessage(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"error": "invalid request"})
 		return
 	}
-
-	setSSEHeaders(c)
-
-	//创建会话，获取session ID
-	sessionID, code_ := session.CreateStreamSessionOnly(userName, req.UserQuestion)
-	if code_ != code.CodeSuccess {
-		c.SSEvent("error", gin.H{
-			"message": "failed to create session",
-		})
+	reservation, ok := checkQuota(c, userName)
+	if !ok {
 		return
 	}
-	//// 立即把 sessionID 返回给前端（用于前端立即显示新会话标签）
-	c.Writer.WriteString(fmt.Sprintf("data: {\"sessionId\": \"%s\"}\n\n", sessionID))
-	c.Writer.Flush()
 
-	// 然后开始把本次回答进行流式发送（包含最后的 [DONE]）
-	code_ = session.StreamMessageToExistingSession(userName, sessionID,
-		req.UserQuestion, req.ModelType, http.ResponseWriter(c.Writer))
+	//创建会话并在后台开始生成，事件依次为 generation、session、title、delta...、usage、done
+	if req.ModelType == "" {
+		req.ModelType = user.DefaultModelType(userName, ai.ModelTypeOpenAI)
+	}
+	gen, code_ := session.CreateStreamSession(userName, req.UserQuestion, req.ModelType, reservation)
 	if code_ != code.CodeSuccess {
-		c.SSEvent("error", gin.H{
-			"message": "failed to create session",
-		})
+		res := new(common.Response)
+		c.JSON(http.StatusOK, res.CodeOf(code_))
 		return
 	}
-
+	streamGeneration(c, gen, 0)
 }
 
 // SendMessageStream 向已有会话发送消息并流式返回 AI 回复
@@ -121,7 +120,7 @@ func CreateStreamSessionAndSendFirstMessage(c *gin.Context) {
 // @Param        session_id  path   string  true   "会话ID"
 // @Param        body        body   session.SendMessageStreamRequest  true  "请求参数"
 // @Security     ApiKeyAuth
-// @Success      200   {string}  string   "SSE 事件流"
+// @Success      200   {string}  string   "SSE 事件流：session、title、delta、usage、done"
 // @Failure      200   {string}  string   "SSE error 事件"
 // @Router       /api/v1/AI/chatMessage/sessions/{session_id}/messages/stream [post]
 func SendMessageStream(c *gin.Context) {
@@ -131,15 +130,73 @@ func SendMessageStream(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"error": "invalid request"})
 		return
 	}
-	setSSEHeaders(c)
+	reservation, ok := checkQuota(c, userName)
+	if !ok {
+		return
+	}
+	if req.ModelType == "" {
+		req.ModelType = user.DefaultModelType(userName, ai.ModelTypeOpenAI)
+	}
+	gen, code_ := session.ChatStreamSend(userName, req.SessionID, req.UserQuestion, req.ModelType, reservation)
+	if code_ != code.CodeSuccess {
+		res := new(common.Response)
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	streamGeneration(c, gen, 0)
+}
 
-	code_ := session.ChatStreamSend(userName, req.SessionID, req.UserQuestion, req.ModelType, http.ResponseWriter(c.Writer))
+// ResumeMessageStream 断线后续传进行中（或刚结束）的 AI 回复
+// @Summary      续传 AI 回复（SSE 流式返回）
+// @Description  根据 generation 事件中的 generationId 重新连接，回放 Last-Event-ID 之后的事件并继续接收实时输出；生成结束 5 分钟后不可再续传
+// @Tags         会话管理
+// @Produce      text/event-stream
+// @Param        session_id     path    string  true   "会话ID"
+// @Param        generation_id  path    string  true   "生成ID"
+// @Param        Last-Event-ID  header  int     false  "最后收到的事件 id"
+// @Security     ApiKeyAuth
+// @Success      200   {string}  string   "SSE 事件流"
+// @Failure      200   {object}  common.Response
+// @Router       /api/v1/AI/chatMessage/sessions/{session_id}/messages/stream/{generation_id} [get]
+func ResumeMessageStream(c *gin.Context) {
+	userName := c.GetString("username")
+	lastEventID := int64(0)
+	raw := c.GetHeader("Last-Event-ID")
+	if raw == "" {
+		raw = c.Query("lastEventId")
+	}
+	if raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || id < 0 {
+			res := new(common.Response)
+			c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+			return
+		}
+		lastEventID = id
+	}
+
+	gen, code_ := session.ResumeGeneration(userName, c.Param("session_id"), c.Param("generation_id"))
 	if code_ != code.CodeSuccess {
-		c.SSEvent("error", gin.H{
-			"message": "failed to send message stream",
-		})
+		res := new(common.Response)
+		c.JSON(http.StatusOK, res.CodeOf(code_))
 		return
 	}
+	streamGeneration(c, gen, lastEventID)
+}
+
+// streamGeneration 把生成的事件推送给当前连接，连接断开不影响生成本身
+func streamGeneration(c *gin.Context, gen *session.Generation, lastEventID int64) {
+	sse, ok := startSSE(c)
+	if !ok {
+		return
+	}
+	defer sse.Close()
+
+	if err := gen.Stream(c.Request.Context(), sse, lastEventID); err != nil {
+		logger.L().Debug("SSE client disconnected",
+			zap.String("generation_id", gen.ID),
+			zap.Error(err))
+	}
 }
 
 // // GetMessageHistory 获取指定会话的历史消息
@@ -208,6 +265,62 @@ func GetMessageHistory(c *gin.Context) {
 	c.JSON(http.StatusOK, res)
 }
 
+// SetSessionKnowledgeBase 为会话绑定知识库
+// @Summary      为会话绑定或解除知识库
+// @Description  绑定后该会话的每次提问都会先在知识库中检索相关片段作为参考资料，回答开始前推送 citations 事件；knowledge_base_id 为 null 时解除绑定
+// @Tags         会话管理
+// @Accept       json
+// @Produce      json
+// @Param        session_id  path   string                                  true  "会话ID"
+// @Param        request     body   session.SetSessionKnowledgeBaseRequest  true  "知识库ID"
+// @Security     ApiKeyAuth
+// @Success      200   {object}  common.Response
+// @Router       /api/v1/AI/chatMessage/sessions/{session_id}/knowledge-base [put]
+func SetSessionKnowledgeBase(c *gin.Context) {
+	req := new(SetSessionKnowledgeBaseRequest)
+	res := new(common.Response)
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	code_ := session.SetKnowledgeBase(c.GetString("username"), c.GetInt64("userID"), c.Param("session_id"), req.KnowledgeBaseID)
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	c.JSON(http.StatusOK, res)
+}
+
+// checkQuota 在开始流式输出前预占额度并写入剩余额度响应头，超额时直接返回 JSON；
+// 预占的额度由 session 服务在生成结束后结算
+func checkQuota(c *gin.Context, userName string) (*quota.Reservation, bool) {
+	reservation, remaining, code_ := quota.Reserve(c.Request.Context(), userName)
+	if remaining != nil {
+		remaining.WriteHeaders(c.Writer.Header())
+	}
+	if code_ != code.CodeSuccess {
+		res := new(common.Response)
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return nil, false
+	}
+	return reservation, true
+}
+
+// startSSE 写入 SSE 响应头并创建事件输出器
+func startSSE(c *gin.Context) (*session.SSEWriter, bool) {
+	sse, err := session.NewSSEWriter(c.Writer)
+	if err != nil {
+		res := new(common.Response)
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeServerBusy))
+		return nil, false
+	}
+	setSSEHeaders(c)
+	c.Writer.WriteHeader(http.StatusOK)
+	c.Writer.Flush()
+	return sse, true
+}
+
 func setSSEHeaders(c *gin.Context) {
 	c.Header("content-type", "text/event-stream")
 	c.Header("Cache-Control", "no-cache")
@@ -0,0 +1,171 @@
+package twofactor
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+	"wsai/backend/internal/common"
+	"wsai/backend/internal/common/code"
+	"wsai/backend/internal/service/audit"
+	"wsai/backend/internal/service/twofactor"
+
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	PasscodeRequest struct {
+		Code string `json:"code" binding:"required"`
+	}
+	StatusResponse struct {
+		*twofactor.Status
+		common.Response
+	}
+	EnrollResponse struct {
+		*twofactor.Enrollment
+		common.Response
+	}
+	RecoveryCodesResponse struct {
+		RecoveryCodes []string `json:"recovery_codes,omitempty"` // 明文，仅此一次
+		common.Response
+	}
+	DisableResponse struct {
+		common.Response
+	}
+)
+
+// GetStatus godoc
+// @Summary 两步验证状态
+// @Description 查询当前用户是否开启两步验证，以及剩余可用的恢复码数量。
+// @Tags 两步验证
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} StatusResponse
+// @Router /api/v1/user/2fa [get]
+func GetStatus(c *gin.Context) {
+	res := new(StatusResponse)
+	status, code_ := twofactor.GetStatus(c.GetInt64("userID"))
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	res.Status = status
+	c.JSON(http.StatusOK, res)
+}
+
+// Enroll godoc
+// @Summary 登记两步验证
+// @Description 生成新的 TOTP 密钥，返回密钥和 otpauth URI（可生成二维码供验证器 App 扫描）。登记后需调用激活接口验证一次才会开启。
+// @Tags 两步验证
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} EnrollResponse
+// @Router /api/v1/user/2fa/enroll [post]
+func Enroll(c *gin.Context) {
+	res := new(EnrollResponse)
+	enrollment, code_ := twofactor.Enroll(c.GetInt64("userID"))
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	res.Enrollment = enrollment
+	c.JSON(http.StatusOK, res)
+}
+
+// Activate godoc
+// @Summary 激活两步验证
+// @Description 提交验证器 App 中的动态验证码确认登记，开启两步验证并返回 10 个一次性恢复码（仅此一次，请妥善保存）。
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body PasscodeRequest true "动态验证码"
+// @Success 200 {object} RecoveryCodesResponse
+// @Router /api/v1/user/2fa/activate [post]
+func Activate(c *gin.Context) {
+	req := new(PasscodeRequest)
+	res := new(RecoveryCodesResponse)
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+	codes, code_ := twofactor.Activate(ctx, c.GetInt64("userID"), req.Code)
+	audit.RecordResult(c, audit.Entry{
+		Action:     audit.ActionTwoFactorOn,
+		TargetType: audit.TargetUser,
+		TargetID:   strconv.FormatInt(c.GetInt64("userID"), 10),
+	}, code_)
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	res.RecoveryCodes = codes
+	c.JSON(http.StatusOK, res)
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary 重新生成恢复码
+// @Description 提交动态验证码后重新生成恢复码，旧的恢复码全部作废。
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body PasscodeRequest true "动态验证码"
+// @Success 200 {object} RecoveryCodesResponse
+// @Router /api/v1/user/2fa/recovery-codes [post]
+func RegenerateRecoveryCodes(c *gin.Context) {
+	req := new(PasscodeRequest)
+	res := new(RecoveryCodesResponse)
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+	codes, code_ := twofactor.RegenerateRecoveryCodes(ctx, c.GetInt64("userID"), req.Code)
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	res.RecoveryCodes = codes
+	c.JSON(http.StatusOK, res)
+}
+
+// Disable godoc
+// @Summary 关闭两步验证
+// @Description 提交动态验证码或恢复码后关闭两步验证，同时删除全部恢复码。
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body PasscodeRequest true "动态验证码或恢复码"
+// @Success 200 {object} DisableResponse
+// @Router /api/v1/user/2fa [delete]
+func Disable(c *gin.Context) {
+	req := new(PasscodeRequest)
+	res := new(DisableResponse)
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+	code_ := twofactor.Disable(ctx, c.GetInt64("userID"), req.Code)
+	audit.RecordResult(c, audit.Entry{
+		Action:     audit.ActionTwoFactorOff,
+		TargetType: audit.TargetUser,
+		TargetID:   strconv.FormatInt(c.GetInt64("userID"), 10),
+	}, code_)
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	c.JSON(http.StatusOK, res)
+}
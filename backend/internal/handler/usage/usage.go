@@ -0,0 +1,50 @@
+package usage
+
+import (
+	"net/http"
+	"strconv"
+	"wsai/backend/internal/common"
+	"wsai/backend/internal/common/code"
+	"wsai/backend/internal/service/usage"
+
+	"github.com/gin-gonic/gin"
+)
+
+type GetUserUsageResponse struct {
+	Usage *usage.Report `json:"usage,omitempty"`
+	common.Response
+}
+
+// GetUserUsage godoc
+// @Summary 查询当前用户的 token 用量
+// @Description 按天、按会话汇总当前用户最近 days 天的 token 用量及估算费用。
+// @Tags 用户认证
+// @Produce json
+// @Security ApiKeyAuth
+// @Param days query int false "统计天数，默认 30，最大 365"
+// @Success 200 {object} GetUserUsageResponse
+// @Failure 200 {object} common.Response
+// @Router /api/v1/user/usage [get]
+func GetUserUsage(c *gin.Context) {
+	res := new(GetUserUsageResponse)
+	username := c.GetString("username")
+
+	days := 0
+	if raw := c.Query("days"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+			return
+		}
+		days = n
+	}
+
+	report, code_ := usage.GetUserUsage(username, days)
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	res.Usage = report
+	c.JSON(http.StatusOK, res)
+}
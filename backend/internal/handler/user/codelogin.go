@@ -0,0 +1,76 @@
+package user
+
+import (
+	"net/http"
+	"wsai/backend/internal/common"
+	"wsai/backend/internal/common/code"
+	"wsai/backend/internal/service/user"
+
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	SendLoginCodeRequest struct {
+		Email string `json:"email" binding:"required,email"`
+		ImageCaptchaFields
+	}
+	SendLoginCodeResponse struct {
+		common.Response
+	}
+	CodeLoginRequest struct {
+		Email      string `json:"email" binding:"required,email"`
+		Code       string `json:"code" binding:"required"`
+		DeviceName string `json:"device_name" binding:"max=100"`
+	}
+)
+
+// SendLoginCode godoc
+// @Summary 发送登录验证码
+// @Description 向注册邮箱发送免密登录验证码（5 分钟内有效，仅可使用一次），需先通过图形验证码。为避免探测账号，邮箱未注册时同样返回成功。
+// @Tags 用户认证
+// @Accept json
+// @Produce json
+// @Param request body SendLoginCodeRequest true "注册邮箱"
+// @Success 200 {object} SendLoginCodeResponse
+// @Failure 429 {object} common.Response "发送过于频繁"
+// @Router /api/v1/user/login/code/send [post]
+func SendLoginCode(c *gin.Context) {
+	req := new(SendLoginCodeRequest)
+	res := new(SendLoginCodeResponse)
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	if code_ := user.SendLoginCode(req.Email, c.ClientIP(), req.answer()); code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	c.JSON(http.StatusOK, res)
+}
+
+// LoginWithCode godoc
+// @Summary 邮箱验证码登录
+// @Description 凭邮箱收到的一次性验证码登录，无需密码。与密码登录共用失败次数限制和账号锁定。
+// @Tags 用户认证
+// @Accept json
+// @Produce json
+// @Param request body CodeLoginRequest true "邮箱与验证码"
+// @Success 200 {object} LoginResponse "登录成功，返回 access/refresh Token；开启两步验证时返回 challenge_token"
+// @Router /api/v1/user/login/code [post]
+func LoginWithCode(c *gin.Context) {
+	req := new(CodeLoginRequest)
+	res := new(LoginResponse)
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	result, code_ := user.LoginWithCode(req.Email, req.Code, clientOf(c, req.DeviceName))
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	res.fillResult(result)
+	c.JSON(http.StatusOK, res)
+}
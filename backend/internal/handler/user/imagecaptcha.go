@@ -0,0 +1,55 @@
+package user
+
+import (
+	"net/http"
+	"wsai/backend/internal/common"
+	"wsai/backend/internal/common/code"
+	"wsai/backend/internal/service/imagecaptcha"
+	"wsai/backend/internal/service/user"
+
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	// ImageCaptchaFields 需要先通过图形验证码的请求携带的字段
+	ImageCaptchaFields struct {
+		CaptchaID     string `json:"captcha_id"`
+		CaptchaAnswer string `json:"captcha_answer"`
+	}
+	ImageCaptchaResponse struct {
+		Enabled bool `json:"enabled"` // 为 false 时无需图形验证码
+		*imagecaptcha.Challenge
+		common.Response
+	}
+)
+
+func (f ImageCaptchaFields) answer() user.ImageAnswer {
+	return user.ImageAnswer{ID: f.CaptchaID, Answer: f.CaptchaAnswer}
+}
+
+// GetImageCaptcha godoc
+// @Summary 获取图形验证码
+// @Description 生成一张图形验证码（2 分钟内有效，只能校验一次）。发送邮箱验证码、注册前需在请求中携带 captcha_id 与识别出的 captcha_answer。
+// @Tags 用户认证
+// @Produce json
+// @Success 200 {object} ImageCaptchaResponse
+// @Router /api/v1/user/captcha/image [get]
+func GetImageCaptcha(c *gin.Context) {
+	res := new(ImageCaptchaResponse)
+	if !user.ImageCaptchaEnabled() {
+		res.Success()
+		c.JSON(http.StatusOK, res)
+		return
+	}
+	challenge, code_ := user.NewImageCaptcha()
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	res.Enabled = true
+	res.Challenge = challenge
+	// 每次都是新的图片，禁止缓存
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, res)
+}
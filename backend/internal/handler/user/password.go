@@ -0,0 +1,121 @@
+package user
+
+import (
+	"net/http"
+	"strconv"
+	"wsai/backend/internal/common"
+	"wsai/backend/internal/common/code"
+	"wsai/backend/internal/service/audit"
+	"wsai/backend/internal/service/user"
+
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	ForgotPasswordRequest struct {
+		Email string `json:"email" binding:"required,email"`
+		ImageCaptchaFields
+	}
+	ResetPasswordRequest struct {
+		Email       string `json:"email" binding:"required,email"`
+		Code        string `json:"code" binding:"required"`
+		NewPassword string `json:"new_password" binding:"required,min=6,max=128"`
+	}
+	ChangePasswordRequest struct {
+		OldPassword string `json:"old_password" binding:"required"`
+		NewPassword string `json:"new_password" binding:"required,min=6,max=128"`
+		DeviceName  string `json:"device_name" binding:"max=100"`
+	}
+	PasswordResponse struct {
+		common.Response
+	}
+)
+
+// ForgotPassword godoc
+// @Summary 忘记密码
+// @Description 向注册邮箱发送密码重置验证码（10 分钟内有效，仅可使用一次），需先通过图形验证码。为避免探测账号，邮箱未注册时同样返回成功。
+// @Tags 用户认证
+// @Accept json
+// @Produce json
+// @Param request body ForgotPasswordRequest true "注册邮箱"
+// @Success 200 {object} PasswordResponse
+// @Failure 429 {object} common.Response "发送过于频繁"
+// @Router /api/v1/user/password/forgot [post]
+func ForgotPassword(c *gin.Context) {
+	req := new(ForgotPasswordRequest)
+	res := new(PasswordResponse)
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	if code_ := user.SendPasswordResetCode(req.Email, c.ClientIP(), req.answer()); code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	c.JSON(http.StatusOK, res)
+}
+
+// ResetPassword godoc
+// @Summary 重置密码
+// @Description 使用邮箱收到的验证码设置新密码，成功后该账号的全部登录失效，需要重新登录。
+// @Tags 用户认证
+// @Accept json
+// @Produce json
+// @Param request body ResetPasswordRequest true "验证码与新密码"
+// @Success 200 {object} PasswordResponse
+// @Failure 200 {object} common.Response "验证码错误或已过期"
+// @Router /api/v1/user/password/reset [post]
+func ResetPassword(c *gin.Context) {
+	req := new(ResetPasswordRequest)
+	res := new(PasswordResponse)
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	code_ := user.ResetPassword(req.Email, req.Code, req.NewPassword)
+	audit.RecordResult(c, audit.Entry{
+		Action:     audit.ActionPasswordReset,
+		TargetType: audit.TargetUser,
+		Detail:     "email=" + req.Email,
+	}, code_)
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	c.JSON(http.StatusOK, res)
+}
+
+// ChangePassword godoc
+// @Summary 修改密码
+// @Description 校验旧密码后设置新密码。包括当前登录在内的全部登录随即失效，响应中返回当前设备的新 token。
+// @Tags 用户认证
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body ChangePasswordRequest true "旧密码与新密码"
+// @Success 200 {object} LoginResponse "修改成功，返回新的 access/refresh Token"
+// @Failure 200 {object} common.Response "旧密码错误"
+// @Router /api/v1/user/password [put]
+func ChangePassword(c *gin.Context) {
+	req := new(ChangePasswordRequest)
+	res := new(LoginResponse)
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	pair, code_ := user.ChangePassword(c.GetString("username"), req.OldPassword, req.NewPassword, clientOf(c, req.DeviceName))
+	audit.RecordResult(c, audit.Entry{
+		Action:     audit.ActionPasswordChange,
+		TargetType: audit.TargetUser,
+		TargetID:   strconv.FormatInt(c.GetInt64("userID"), 10),
+	}, code_)
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	res.fill(pair)
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,182 @@
+package user
+
+import (
+	"net/http"
+	"strconv"
+	"wsai/backend/internal/common"
+	"wsai/backend/internal/common/code"
+	"wsai/backend/internal/model"
+	"wsai/backend/internal/service/audit"
+	"wsai/backend/internal/service/user"
+
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	ProfileResponse struct {
+		User *model.User `json:"user,omitempty"`
+		common.Response
+	}
+	UpdateProfileRequest struct {
+		Name         *string `json:"name" binding:"omitempty,max=50"`
+		Avatar       *string `json:"avatar" binding:"omitempty,max=255"` // http(s) 图片地址，空字符串表示清除
+		DefaultModel *string `json:"default_model"`                      // 已注册的模型类型，空字符串表示清除
+		Language     *string `json:"language"`                           // zh-CN、en-US
+	}
+	ChangeEmailRequest struct {
+		NewEmail string `json:"new_email" binding:"required,email,max=100"`
+		Password string `json:"password"`
+	}
+	ConfirmEmailRequest struct {
+		NewEmail string `json:"new_email" binding:"required,email,max=100"`
+		Code     string `json:"code" binding:"required"`
+	}
+	DeleteAccountRequest struct {
+		Password string `json:"password"`
+		Confirm  string `json:"confirm" binding:"required"` // 需填写自己的用户名
+	}
+	AccountResponse struct {
+		common.Response
+	}
+)
+
+// GetProfile godoc
+// @Summary 个人资料
+// @Description 查询当前用户的资料与偏好设置（默认模型、界面语言）。
+// @Tags 个人资料
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} ProfileResponse
+// @Router /api/v1/user/me [get]
+func GetProfile(c *gin.Context) {
+	res := new(ProfileResponse)
+	u, code_ := user.GetProfile(c.GetInt64("userID"))
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	res.User = u
+	c.JSON(http.StatusOK, res)
+}
+
+// UpdateProfile godoc
+// @Summary 修改个人资料
+// @Description 修改昵称、头像和偏好设置，未传的字段保持不变。
+// @Tags 个人资料
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body UpdateProfileRequest true "修改参数"
+// @Success 200 {object} ProfileResponse
+// @Router /api/v1/user/me [patch]
+func UpdateProfile(c *gin.Context) {
+	req := new(UpdateProfileRequest)
+	res := new(ProfileResponse)
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	u, code_ := user.UpdateProfile(c.GetInt64("userID"), user.ProfileUpdate{
+		Name:         req.Name,
+		Avatar:       req.Avatar,
+		DefaultModel: req.DefaultModel,
+		Language:     req.Language,
+	})
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	res.User = u
+	c.JSON(http.StatusOK, res)
+}
+
+// ChangeEmail godoc
+// @Summary 更换邮箱
+// @Description 校验密码后向新邮箱发送验证码（10 分钟内有效），再调用确认接口完成更换。只通过单点登录的账号无需密码。
+// @Tags 个人资料
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body ChangeEmailRequest true "新邮箱与当前密码"
+// @Success 200 {object} AccountResponse
+// @Router /api/v1/user/me/email [post]
+func ChangeEmail(c *gin.Context) {
+	req := new(ChangeEmailRequest)
+	res := new(AccountResponse)
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	if code_ := user.RequestEmailChange(c.GetInt64("userID"), req.NewEmail, req.Password, c.ClientIP()); code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	c.JSON(http.StatusOK, res)
+}
+
+// ConfirmEmail godoc
+// @Summary 确认更换邮箱
+// @Description 提交新邮箱收到的验证码完成更换，旧邮箱会收到通知。
+// @Tags 个人资料
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body ConfirmEmailRequest true "新邮箱与验证码"
+// @Success 200 {object} ProfileResponse
+// @Router /api/v1/user/me/email/confirm [post]
+func ConfirmEmail(c *gin.Context) {
+	req := new(ConfirmEmailRequest)
+	res := new(ProfileResponse)
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	u, code_ := user.ConfirmEmailChange(c.GetInt64("userID"), req.NewEmail, req.Code)
+	audit.RecordResult(c, audit.Entry{
+		Action:     audit.ActionEmailChange,
+		TargetType: audit.TargetUser,
+		TargetID:   strconv.FormatInt(c.GetInt64("userID"), 10),
+		Detail:     "new_email=" + req.NewEmail,
+	}, code_)
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	res.User = u
+	c.JSON(http.StatusOK, res)
+}
+
+// DeleteAccount godoc
+// @Summary 注销账号
+// @Description 注销当前账号：删除全部会话和消息、API Key，匿名化用户信息，全部登录随即失效，操作不可恢复。confirm 需填写自己的用户名，有密码的账号还需提供密码。
+// @Tags 个人资料
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body DeleteAccountRequest true "确认信息"
+// @Success 200 {object} AccountResponse
+// @Router /api/v1/user/me [delete]
+func DeleteAccount(c *gin.Context) {
+	req := new(DeleteAccountRequest)
+	res := new(AccountResponse)
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	code_ := user.DeleteAccount(c.GetInt64("userID"), req.Password, req.Confirm)
+	audit.RecordResult(c, audit.Entry{
+		Action:     audit.ActionAccountDelete,
+		TargetType: audit.TargetUser,
+		TargetID:   strconv.FormatInt(c.GetInt64("userID"), 10),
+	}, code_)
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+	res.Success()
+	c.JSON(http.StatusOK, res)
+}
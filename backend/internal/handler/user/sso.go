@@ -0,0 +1,115 @@
+package user
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+	"wsai/backend/config"
+	"wsai/backend/internal/common"
+	"wsai/backend/internal/common/code"
+	"wsai/backend/internal/service/sso"
+	"wsai/backend/internal/service/user"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	oidcStateCookie = "oidc_state"
+	oidcCookiePath  = "/api/v1/user/oidc/"
+)
+
+type ListOIDCProvidersResponse struct {
+	Providers []sso.ProviderInfo `json:"providers"`
+	common.Response
+}
+
+// ListOIDCProviders godoc
+// @Summary 单点登录提供方
+// @Description 列出已配置的 OpenID Connect 身份提供方，前端据此展示单点登录入口。
+// @Tags 用户认证
+// @Produce json
+// @Success 200 {object} ListOIDCProvidersResponse
+// @Router /api/v1/user/oidc/providers [get]
+func ListOIDCProviders(c *gin.Context) {
+	res := new(ListOIDCProvidersResponse)
+	res.Success()
+	res.Providers = sso.Providers()
+	c.JSON(http.StatusOK, res)
+}
+
+// OIDCLogin godoc
+// @Summary 发起单点登录
+// @Description 跳转到身份提供方的授权页面（授权码 + PKCE）。
+// @Tags 用户认证
+// @Param provider path string true "提供方名称"
+// @Success 302 "跳转到身份提供方"
+// @Router /api/v1/user/oidc/{provider}/login [get]
+func OIDCLogin(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+	authURL, state, code_ := sso.AuthCodeURL(ctx, c.Param("provider"))
+	if code_ != code.CodeSuccess {
+		redirectOIDCResult(c, nil, code_)
+		return
+	}
+	setStateCookie(c, sso.StateBinding(state), int(sso.StateTTL.Seconds()))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// setStateCookie 把 state 绑定到发起登录的浏览器；IdP 回调是顶层 GET 跳转，SameSite=Lax 时会带上
+func setStateCookie(c *gin.Context, value string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, value, maxAge, oidcCookiePath, "", c.Request.TLS != nil, true)
+}
+
+// OIDCCallback godoc
+// @Summary 单点登录回调
+// @Description 身份提供方授权后的回调地址。登录成功后跳转到配置的前端地址，token、refresh_token、expires_in（开启两步验证时为 challenge_token）放在 URL fragment 中；失败时 fragment 携带 status_code 和 status_msg。未配置前端地址时直接返回 JSON。
+// @Tags 用户认证
+// @Produce json
+// @Param provider path string true "提供方名称"
+// @Param code query string true "授权码"
+// @Param state query string true "state"
+// @Success 302 "跳转到前端"
+// @Router /api/v1/user/oidc/{provider}/callback [get]
+func OIDCCallback(c *gin.Context) {
+	binding, _ := c.Cookie(oidcStateCookie)
+	setStateCookie(c, "", -1)
+	if errMsg := c.Query("error"); errMsg != "" {
+		redirectOIDCResult(c, nil, code.CodeOIDCFailed)
+		return
+	}
+	result, code_ := user.LoginWithOIDC(c.Param("provider"), c.Query("code"), c.Query("state"), binding, clientOf(c, ""))
+	redirectOIDCResult(c, result, code_)
+}
+
+// redirectOIDCResult 把登录结果带回前端；token 放在 fragment 中，不会出现在服务端日志和 Referer 里
+func redirectOIDCResult(c *gin.Context, result *user.LoginResult, code_ code.Code) {
+	res := new(LoginResponse)
+	if code_ == code.CodeSuccess {
+		res.Success()
+		res.fillResult(result)
+	} else {
+		res.CodeOf(code_)
+	}
+
+	target := config.C.OIDCConfig.FrontendRedirect
+	if target == "" {
+		c.JSON(http.StatusOK, res)
+		return
+	}
+	fragment := url.Values{}
+	fragment.Set("status_code", strconv.FormatInt(int64(res.StatusCode), 10))
+	fragment.Set("status_msg", res.StatusMsg)
+	if res.Token != "" {
+		fragment.Set("token", res.Token)
+		fragment.Set("refresh_token", res.RefreshToken)
+		fragment.Set("expires_in", strconv.FormatInt(res.ExpiresIn, 10))
+	}
+	if res.ChallengeToken != "" {
+		fragment.Set("challenge_token", res.ChallengeToken)
+	}
+	c.Redirect(http.StatusFound, target+"#"+fragment.Encode())
+}
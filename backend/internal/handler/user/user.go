@@ -5,8 +5,10 @@ import (
 	"wsai/backend/internal/common"
 	"wsai/backend/internal/common/code"
 	"wsai/backend/internal/middleware/jwt"
+	"wsai/backend/internal/service/audit"
 
 	//"wsai/backend/internal/service"
+	"wsai/backend/internal/service/loginsession"
 	"wsai/backend/internal/service/user"
 
 	"github.com/gin-gonic/gin"
@@ -14,28 +16,40 @@ import (
 
 type (
 	LoginRequest struct {
-		Username string `json:"username"`
-		Password string `json:"password"`
+		Username   string `json:"username"`
+		Password   string `json:"password"`
+		DeviceName string `json:"device_name" binding:"max=100"` // 可选，为空时根据 User-Agent 推断
 	}
 	EmailLoginRequest struct {
-		Email    string `json:"email" binding:"required"`
-		Password string `json:"password"`
+		Email      string `json:"email" binding:"required"`
+		Password   string `json:"password"`
+		DeviceName string `json:"device_name" binding:"max=100"`
 	}
 	LoginResponse struct {
-		Token string `json:"token"`
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token,omitempty"`
+		ExpiresIn    int64  `json:"expires_in,omitempty"` // access token 有效秒数
+		// 开启两步验证时不返回 token，需携带 challenge_token 调用 /user/login/2fa
+		TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+		ChallengeToken    string `json:"challenge_token,omitempty"`
 		common.Response
 	}
 	RegisterRequest struct {
-		Email    string `json:"email" binding:"required"`
-		Captcha  string `json:"captcha"`
-		Password string `json:"password"`
+		Email      string `json:"email" binding:"required"`
+		Captcha    string `json:"captcha"`
+		Password   string `json:"password"`
+		DeviceName string `json:"device_name" binding:"max=100"`
+		ImageCaptchaFields
 	}
 	RegisterResponse struct {
-		Token string `json:"token,omitempty"`
+		Token        string `json:"token,omitempty"`
+		RefreshToken string `json:"refresh_token,omitempty"`
+		ExpiresIn    int64  `json:"expires_in,omitempty"`
 		common.Response
 	}
 	CaptchaRequest struct {
 		Email string `json:"email" binding:"required"`
+		ImageCaptchaFields
 	}
 	CaptchaResponse struct {
 		common.Response
@@ -43,6 +57,9 @@ type (
 	LogoutResponse struct {
 		common.Response
 	}
+	RefreshTokenRequest struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
 )
 
 // Login godoc
@@ -52,7 +69,7 @@ type (
 // @Accept json
 // @Produce json
 // @Param request body LoginRequest true "登录参数"
-// @Success 200 {object} LoginResponse "登录成功，返回 Token"
+// @Success 200 {object} LoginResponse "登录成功，返回 access/refresh Token；开启两步验证时返回 challenge_token"
 // @Failure 400 {object} common.Response "请求参数错误"
 // @Failure 401 {object} common.Response "用户名或密码错误"
 // @Router /api/v1/user/login [post]
@@ -63,13 +80,13 @@ func Login(c *gin.Context) {
 		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
 		return
 	}
-	token, code_ := user.Login(req.Username, req.Password)
+	result, code_ := user.Login(req.Username, req.Password, clientOf(c, req.DeviceName))
 	if code_ != code.CodeSuccess {
 		c.JSON(http.StatusOK, res.CodeOf(code_))
 		return
 	}
 	res.Success()
-	res.Token = token
+	res.fillResult(result)
 	c.JSON(http.StatusOK, res)
 }
 
@@ -80,7 +97,7 @@ func Login(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param request body EmailLoginRequest true "邮箱登录参数"
-// @Success 200 {object} LoginResponse "登录成功，返回 Token"
+// @Success 200 {object} LoginResponse "登录成功，返回 access/refresh Token；开启两步验证时返回 challenge_token"
 // @Failure 400 {object} common.Response "请求参数错误"
 // @Failure 401 {object} common.Response "邮箱或密码错误"
 // @Router /api/v1/user/email-login [post]
@@ -92,20 +109,20 @@ func LoginWithEmail(c *gin.Context) {
 		return
 	}
 
-	token, code_ := user.LoginWithEmail(req.Email, req.Password)
+	result, code_ := user.LoginWithEmail(req.Email, req.Password, clientOf(c, req.DeviceName))
 	if code_ != code.CodeSuccess {
 		c.JSON(http.StatusOK, res.CodeOf(code_))
 		return
 	}
 
 	res.Success()
-	res.Token = token
+	res.fillResult(result)
 	c.JSON(http.StatusOK, res)
 }
 
 // Register godoc
 // @Summary 用户注册
-// @Description 通过邮箱、密码和验证码注册新用户，成功后直接返回 JWT Token。
+// @Description 通过邮箱、密码和验证码注册新用户，需先通过图形验证码，成功后直接返回 JWT Token。
 // @Tags 用户认证
 // @Accept json
 // @Produce json
@@ -121,20 +138,22 @@ func Register(c *gin.Context) {
 		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
 		return
 	}
-	token, code_ := user.Register(req.Email, req.Password, req.Captcha)
+	pair, code_ := user.Register(req.Email, req.Password, req.Captcha, c.GetHeader("Accept-Language"), req.answer(), clientOf(c, req.DeviceName))
 	if code_ != code.CodeSuccess {
 		c.JSON(http.StatusOK, res.CodeOf(code_))
 		return
 
 	}
 	res.Success()
-	res.Token = token
+	res.Token = pair.AccessToken
+	res.RefreshToken = pair.RefreshToken
+	res.ExpiresIn = pair.ExpiresIn
 	c.JSON(http.StatusOK, res)
 }
 
 // HandleCaptcha godoc
 // @Summary 发送邮箱验证码
-// @Description 向指定邮箱发送注册验证码。
+// @Description 向指定邮箱发送注册验证码，需先通过图形验证码。
 // @Tags 用户认证
 // @Accept json
 // @Produce json
@@ -151,7 +170,7 @@ func HandleCaptcha(c *gin.Context) {
 		return
 	}
 
-	code_ := user.SendCaptcha(req.Email)
+	code_ := user.SendCaptcha(req.Email, c.ClientIP(), c.GetHeader("Accept-Language"), req.answer())
 	if code_ != code.CodeSuccess {
 		c.JSON(http.StatusOK, res.CodeOf(code_))
 		return
@@ -162,7 +181,7 @@ func HandleCaptcha(c *gin.Context) {
 
 // Logout godoc
 // @Summary 用户退出登录
-// @Description 将当前请求携带的 JWT Token 加入 Redis 黑名单，后续该 Token 将无法再访问受保护接口。
+// @Description 吊销当前登录，该登录签发的 access token 和 refresh token 随即失效。
 // @Tags 用户认证
 // @Accept json
 // @Produce json
@@ -181,11 +200,100 @@ func Logout(c *gin.Context) {
 	}
 
 	code_ := user.Logout(token)
+	audit.RecordResult(c, audit.Entry{
+		Action:     audit.ActionLogout,
+		TargetType: audit.TargetLogin,
+		TargetID:   c.GetString(jwt.JTIContextKey),
+	}, code_)
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
+
+	res.Success()
+	c.JSON(http.StatusOK, res)
+}
+
+// RefreshToken godoc
+// @Summary 刷新登录凭证
+// @Description 使用 refresh token 换取新的 access/refresh token，旧 refresh token 随即失效；重复使用已失效的 refresh token 会吊销该登录下的全部 refresh token。
+// @Tags 用户认证
+// @Accept json
+// @Produce json
+// @Param request body RefreshTokenRequest true "refresh token"
+// @Success 200 {object} LoginResponse "刷新成功"
+// @Failure 200 {object} common.Response "refresh token 无效、过期或被重复使用"
+// @Router /api/v1/user/token/refresh [post]
+func RefreshToken(c *gin.Context) {
+	req := new(RefreshTokenRequest)
+	res := new(LoginResponse)
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	pair, code_ := user.RefreshToken(req.RefreshToken)
 	if code_ != code.CodeSuccess {
 		c.JSON(http.StatusOK, res.CodeOf(code_))
 		return
 	}
+	res.Success()
+	res.fill(pair)
+	c.JSON(http.StatusOK, res)
+}
+
+// clientOf 收集本次登录的客户端信息
+func clientOf(c *gin.Context, deviceName string) loginsession.Client {
+	return loginsession.Client{
+		DeviceName: deviceName,
+		IP:         c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+	}
+}
+
+func (r *LoginResponse) fillResult(result *user.LoginResult) {
+	if result.ChallengeToken != "" {
+		r.TwoFactorRequired = true
+		r.ChallengeToken = result.ChallengeToken
+		return
+	}
+	r.fill(result.Tokens)
+}
 
+func (r *LoginResponse) fill(pair *jwt.TokenPair) {
+	r.Token = pair.AccessToken
+	r.RefreshToken = pair.RefreshToken
+	r.ExpiresIn = pair.ExpiresIn
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"` // 6 位动态验证码或恢复码
+	DeviceName     string `json:"device_name" binding:"max=100"`
+}
+
+// LoginWithTwoFactor godoc
+// @Summary 两步验证登录
+// @Description 密码登录返回 two_factor_required 时，携带 challenge_token 和验证器 App 中的动态验证码（或一次性恢复码）换取正式的 access/refresh Token。挑战 token 5 分钟内有效，最多尝试 5 次。
+// @Tags 用户认证
+// @Accept json
+// @Produce json
+// @Param request body TwoFactorLoginRequest true "挑战 token 与验证码"
+// @Success 200 {object} LoginResponse "登录成功"
+// @Failure 200 {object} common.Response "验证码错误或挑战已过期"
+// @Router /api/v1/user/login/2fa [post]
+func LoginWithTwoFactor(c *gin.Context) {
+	req := new(TwoFactorLoginRequest)
+	res := new(LoginResponse)
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidParams))
+		return
+	}
+	pair, code_ := user.LoginWithTwoFactor(req.ChallengeToken, req.Code, clientOf(c, req.DeviceName))
+	if code_ != code.CodeSuccess {
+		c.JSON(http.StatusOK, res.CodeOf(code_))
+		return
+	}
 	res.Success()
+	res.fill(pair)
 	c.JSON(http.StatusOK, res)
 }
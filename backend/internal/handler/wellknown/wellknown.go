@@ -0,0 +1,32 @@
+package wellknown
+
+import (
+	"net/http"
+	"wsai/backend/internal/common"
+	"wsai/backend/internal/common/code"
+	"wsai/backend/internal/logger"
+	"wsai/backend/internal/middleware/jwt"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// JWKS godoc
+// @Summary JWT 公钥集
+// @Description 按 RFC 7517 返回用于验证 access token 的 RS256、EdDSA 公钥，token header 中的 kid 对应其中一项；HS256 密钥不公开。
+// @Tags 用户认证
+// @Produce json
+// @Success 200 {object} jwt.JWKSet
+// @Router /.well-known/jwks.json [get]
+func JWKS(c *gin.Context) {
+	set, err := jwt.PublicJWKS()
+	if err != nil {
+		logger.L().Error("jwt.PublicJWKS error", zap.Error(err))
+		res := new(common.Response)
+		c.JSON(http.StatusServiceUnavailable, res.CodeOf(code.CodeServerBusy))
+		return
+	}
+	// 轮换时新公钥需先于 active_kid 切换发布，缓存时间不宜过长
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, set)
+}
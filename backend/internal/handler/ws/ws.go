@@ -0,0 +1,37 @@
+package ws
+
+import (
+	"net/http"
+	"wsai/backend/internal/logger"
+	"wsai/backend/internal/service/ws"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+	// 跨域策略与 CORS 中间件一致，鉴权由 JWT 保证
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// Chat 聊天 WebSocket 连接
+// @Summary      聊天 WebSocket
+// @Description  通过 ?token= 携带 JWT 建立 WebSocket 连接，一个连接可同时收发多个会话的消息，帧协议见 internal/service/ws 包文档
+// @Tags         会话管理
+// @Param        token  query  string  true  "JWT Token"
+// @Success      101  {string}  string  "Switching Protocols"
+// @Router       /api/v1/AI/ws [get]
+func Chat(c *gin.Context) {
+	userName := c.GetString("username")
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		logger.L().Warn("WebSocket upgrade failed",
+			zap.String("username", userName),
+			zap.Error(err))
+		return
+	}
+	ws.Serve(conn, c.GetInt64("userID"), userName)
+}
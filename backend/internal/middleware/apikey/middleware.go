@@ -0,0 +1,53 @@
+package apikey
+
+import (
+	"net/http"
+	"strings"
+	"wsai/backend/internal/common/code"
+	"wsai/backend/internal/middleware/jwt"
+	"wsai/backend/internal/model"
+	"wsai/backend/internal/service/apikey"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OpenAIMiddleware 按 OpenAI 的方式（Authorization: Bearer <key>）校验 API Key 及 chat 权限，
+// 失败时返回 OpenAI 格式的错误，供兼容 OpenAI 的接口使用
+func OpenAIMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		key, code_ := apikey.Authenticate(strings.TrimSpace(token))
+		if code_ == code.CodeUserDisabled {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": gin.H{
+				"message": "The account that owns this API key has been disabled.",
+				"type":    "permission_error",
+				"code":    "account_disabled",
+			}})
+			return
+		}
+		if code_ != code.CodeSuccess {
+			status := http.StatusUnauthorized
+			if code_ == code.CodeServerBusy {
+				status = http.StatusInternalServerError
+			}
+			c.AbortWithStatusJSON(status, gin.H{"error": gin.H{
+				"message": "Incorrect API key provided.",
+				"type":    "invalid_request_error",
+				"code":    "invalid_api_key",
+			}})
+			return
+		}
+		if !key.HasScope(model.ScopeChat) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": gin.H{
+				"message": "This API key does not have the chat scope.",
+				"type":    "permission_error",
+				"code":    "insufficient_scope",
+			}})
+			return
+		}
+		c.Set("username", key.UserName)
+		c.Set("userID", key.UserID)
+		c.Set(jwt.APIKeyContextKey, key)
+		c.Next()
+	}
+}
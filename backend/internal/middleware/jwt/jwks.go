@@ -0,0 +1,51 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+)
+
+// JWK 公钥的 JSON Web Key 表示（RFC 7517）
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet /.well-known/jwks.json 的响应
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicJWKS 返回 RS256、EdDSA 密钥的公钥，供其他服务验证本服务签发的 token；HS256 密钥不会公开
+func PublicJWKS() (*JWKSet, error) {
+	s, err := currentKeySet()
+	if err != nil {
+		return nil, err
+	}
+	set := &JWKSet{Keys: []JWK{}}
+	for _, k := range s.publicKeys() {
+		jwk := JWK{Kid: k.kid, Use: "sig", Alg: k.method.Alg()}
+		switch pub := k.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set, nil
+}
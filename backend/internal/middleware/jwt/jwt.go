@@ -1,7 +1,6 @@
 package jwt
 
 import (
-	"fmt"
 	"strings"
 	"time"
 	"wsai/backend/config"
@@ -10,43 +9,48 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// Claims 的 RegisteredClaims.ID（jti）指向签发它的登录，见 model.LoginSession
 type Claims struct {
 	Id       int64  `json:"id"`
 	Username string `json:"username"`
+	Role     string `json:"role,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(id int64, username string) (string, error) {
-	accessTTL := 24 * time.Hour
-	if config.C != nil && config.C.JWTConfig.AccessTTL != "" {
-		if parsedTTL, err := time.ParseDuration(config.C.JWTConfig.AccessTTL); err == nil {
-			accessTTL = parsedTTL
-		}
+// GenerateToken 用当前签名密钥（active_kid）签发 access token
+func GenerateToken(id int64, username string, role string, jti string) (string, error) {
+	keys, err := currentKeySet()
+	if err != nil {
+		return "", err
 	}
-
 	claims := Claims{
 		Id:       id,
 		Username: username,
+		Role:     role,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
 			Issuer:    config.C.JWTConfig.Issuer,
 			Subject:   config.C.JWTConfig.Subject,
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(accessTTL)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(accessTTL())),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(config.C.JWTConfig.Secret))
+	if aud := config.C.JWTConfig.Audience; len(aud) > 0 {
+		claims.Audience = jwt.ClaimStrings(aud)
+	}
+	return keys.sign(claims)
 }
 
+// ParseTokenClaims 按 kid 选择密钥验签，并校验过期时间及配置的 iss、aud；
+// 密钥轮换后旧密钥仍在配置中，已签发的 token 继续有效
 func ParseTokenClaims(token string) (*Claims, bool) {
+	keys, err := currentKeySet()
+	if err != nil {
+		return nil, false
+	}
 	claims := new(Claims)
-	t, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("不支持的签名算法: %v", t.Header["alg"])
-		}
-		return []byte(config.C.JWTConfig.Secret), nil
-	})
-	if !t.Valid || err != nil {
+	t, err := jwt.ParseWithClaims(token, claims, keys.keyfunc, keys.parserOptions()...)
+	if err != nil || !t.Valid {
 		return nil, false
 	}
 	return claims, true
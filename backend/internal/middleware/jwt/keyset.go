@@ -0,0 +1,268 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"wsai/backend/config"
+	"wsai/backend/internal/logger"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+// legacyKid 由 jwt.secret 生成的 HS256 密钥；不带 kid 的旧 token 也用它验签
+const legacyKid = "default"
+
+// signingKey 一把签名密钥；signer 为空表示只用于验签
+type signingKey struct {
+	kid      string
+	method   jwt.SigningMethod
+	signer   interface{}
+	verifier interface{}
+	public   crypto.PublicKey // 可公开的公钥，HS256 为空
+}
+
+// keySet 按 kid 索引的全部密钥，active 用于签发新 token
+type keySet struct {
+	active *signingKey
+	keys   map[string]*signingKey
+	algs   []string
+}
+
+var (
+	keysMu  sync.RWMutex
+	current *keySet
+)
+
+// InitKeys 按配置加载签名密钥，启动时调用；配置有误时返回 error
+func InitKeys() error {
+	s, err := loadKeySet()
+	if err != nil {
+		return err
+	}
+	keysMu.Lock()
+	current = s
+	keysMu.Unlock()
+	if logger.L() != nil {
+		logger.L().Info("JWT 签名密钥加载完成",
+			zap.String("active_kid", s.active.kid),
+			zap.String("alg", s.active.method.Alg()),
+			zap.Int("keys", len(s.keys)))
+	}
+	return nil
+}
+
+func currentKeySet() (*keySet, error) {
+	keysMu.RLock()
+	s := current
+	keysMu.RUnlock()
+	if s != nil {
+		return s, nil
+	}
+	if err := InitKeys(); err != nil {
+		return nil, err
+	}
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+	return current, nil
+}
+
+func loadKeySet() (*keySet, error) {
+	if config.C == nil {
+		return nil, errors.New("配置未初始化")
+	}
+	cfg := config.C.JWTConfig
+	s := &keySet{keys: map[string]*signingKey{}}
+	for kid, kc := range cfg.Keys {
+		k, err := loadKey(kid, kc)
+		if err != nil {
+			return nil, fmt.Errorf("JWT 密钥 %s: %w", kid, err)
+		}
+		s.keys[kid] = k
+	}
+	if _, ok := s.keys[legacyKid]; !ok && cfg.Secret != "" {
+		s.keys[legacyKid] = &signingKey{
+			kid:      legacyKid,
+			method:   jwt.SigningMethodHS256,
+			signer:   []byte(cfg.Secret),
+			verifier: []byte(cfg.Secret),
+		}
+	}
+
+	activeKid := strings.ToLower(cfg.ActiveKid)
+	if activeKid == "" {
+		activeKid = legacyKid
+	}
+	s.active = s.keys[activeKid]
+	if s.active == nil {
+		return nil, fmt.Errorf("JWT active_kid %q 不存在", activeKid)
+	}
+	if s.active.signer == nil {
+		return nil, fmt.Errorf("JWT active_kid %q 缺少私钥，无法签发", activeKid)
+	}
+
+	seen := map[string]bool{}
+	for _, k := range s.keys {
+		if alg := k.method.Alg(); !seen[alg] {
+			seen[alg] = true
+			s.algs = append(s.algs, alg)
+		}
+	}
+	return s, nil
+}
+
+func loadKey(kid string, c config.JWTKey) (*signingKey, error) {
+	k := &signingKey{kid: kid}
+	switch {
+	case strings.EqualFold(c.Algorithm, "HS256"):
+		if len(c.Secret) < 32 {
+			return nil, errors.New("HS256 secret 至少 32 字节")
+		}
+		k.method = jwt.SigningMethodHS256
+		k.signer, k.verifier = []byte(c.Secret), []byte(c.Secret)
+		return k, nil
+	case strings.EqualFold(c.Algorithm, "RS256"):
+		k.method = jwt.SigningMethodRS256
+	case strings.EqualFold(c.Algorithm, "EdDSA"):
+		k.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("不支持的签名算法 %q", c.Algorithm)
+	}
+
+	var pub crypto.PublicKey
+	switch {
+	case c.PrivateKeyFile != "":
+		priv, err := readPrivateKey(c.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		k.signer, pub = priv, priv.Public()
+	case c.PublicKeyFile != "":
+		var err error
+		if pub, err = readPublicKey(c.PublicKeyFile); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("需要配置 private_key_file 或 public_key_file")
+	}
+
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		if k.method != jwt.SigningMethodRS256 {
+			return nil, errors.New("RSA 密钥只能用于 RS256")
+		}
+		if key.N.BitLen() < 2048 {
+			return nil, errors.New("RSA 密钥至少 2048 位")
+		}
+	case ed25519.PublicKey:
+		if k.method != jwt.SigningMethodEdDSA {
+			return nil, errors.New("Ed25519 密钥只能用于 EdDSA")
+		}
+	default:
+		return nil, fmt.Errorf("不支持的密钥类型 %T", pub)
+	}
+	k.verifier, k.public = pub, pub
+	return k, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, fmt.Errorf("%s 不是 PEM 文件", path)
+	}
+	return block, nil
+}
+
+func readPrivateKey(path string) (crypto.Signer, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		if signer, ok := key.(crypto.Signer); ok {
+			return signer, nil
+		}
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%s 无法解析为 PKCS#8 或 PKCS#1 私钥", path)
+}
+
+func readPublicKey(path string) (crypto.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if key, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%s 无法解析为公钥", path)
+}
+
+// sign 用当前密钥签名，header 中写入 kid 供验签方选择公钥
+func (s *keySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(s.active.method, claims)
+	token.Header["kid"] = s.active.kid
+	return token.SignedString(s.active.signer)
+}
+
+// keyfunc 按 kid 选择验签密钥，并要求 token 的算法与密钥一致，防止算法混淆
+func (s *keySet) keyfunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		kid = legacyKid
+	}
+	k, ok := s.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("未知的签名密钥: %s", kid)
+	}
+	if t.Method.Alg() != k.method.Alg() {
+		return nil, fmt.Errorf("签名算法与密钥不匹配: %v", t.Header["alg"])
+	}
+	return k.verifier, nil
+}
+
+// parserOptions 校验签名算法、过期时间，以及配置了的 iss、aud
+func (s *keySet) parserOptions() []jwt.ParserOption {
+	cfg := config.C.JWTConfig
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(s.algs),
+		jwt.WithExpirationRequired(),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if len(cfg.Audience) > 0 {
+		opts = append(opts, jwt.WithAudience(cfg.Audience...))
+	}
+	return opts
+}
+
+// publicKeys 可公开的公钥，按 kid 排序
+func (s *keySet) publicKeys() []*signingKey {
+	var keys []*signingKey
+	for _, k := range s.keys {
+		if k.public != nil {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].kid < keys[j].kid })
+	return keys
+}
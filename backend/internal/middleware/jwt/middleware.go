@@ -3,10 +3,14 @@ package jwt
 import (
 	"context"
 	"net/http"
+	"slices"
 	"time"
 	"wsai/backend/internal/common"
 	"wsai/backend/internal/common/code"
 	"wsai/backend/internal/logger"
+	"wsai/backend/internal/model"
+	"wsai/backend/internal/service/apikey"
+	"wsai/backend/internal/service/loginsession"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -23,28 +27,110 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// 长期 API Key 作为 JWT 之外的另一种凭证
+		if apikey.IsAPIKey(token) {
+			key, code_ := apikey.Authenticate(token)
+			if code_ != code.CodeSuccess {
+				if code_ != code.CodeUserDisabled {
+					code_ = code.CodeInvalidToken
+				}
+				c.JSON(http.StatusOK, res.CodeOf(code_))
+				c.Abort()
+				return
+			}
+			c.Set("username", key.UserName)
+			c.Set("userID", key.UserID)
+			c.Set(APIKeyContextKey, key)
+			c.Next()
+			return
+		}
+
 		claims, ok := ParseTokenClaims(token)
-		if !ok {
+		if !ok || (claims.ID == "" && !legacyTokenUsable(claims)) {
 			c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidToken))
 			c.Abort()
 			return
 		}
+		// 升级前签发的 token 没有 jti，过期前照常放行，但不记录登录也不能吊销
+		if claims.ID == "" {
+			c.Set("username", claims.Username)
+			c.Set("userID", claims.Id)
+			c.Set("role", claims.Role)
+			c.Next()
+			return
+		}
 
 		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 		defer cancel()
 
-		isBlacklisted, err := IsTokenBlacklisted(ctx, token)
+		revoked, err := loginsession.IsRevoked(ctx, claims.ID)
 		if err != nil && logger.L() != nil {
-			logger.L().Warn("检查 Token 黑名单失败", zap.Error(err))
+			logger.L().Warn("检查登录吊销状态失败", zap.Error(err))
 		}
-		if isBlacklisted {
+		if revoked {
 			c.JSON(http.StatusOK, res.CodeOf(code.CodeInvalidToken))
 			c.Abort()
 			return
 		}
+		loginsession.Touch(ctx, claims.ID)
 
 		c.Set("username", claims.Username)
 		c.Set("userID", claims.Id)
+		c.Set("role", claims.Role)
+		c.Set(JTIContextKey, claims.ID)
+		c.Next()
+	}
+}
+
+// legacyTokenUsable 不带 jti 的旧 token 只在签发后一个 access_ttl 内有效，
+// 升级后不再签发这种 token，一个 access_ttl 过去后自然全部失效
+func legacyTokenUsable(claims *Claims) bool {
+	return claims.IssuedAt != nil && time.Since(claims.IssuedAt.Time) < accessTTL()
+}
+
+// JTIContextKey 使用 JWT 鉴权时，gin.Context 中保存当前登录 jti 的 key
+const JTIContextKey = "jti"
+
+// APIKeyContextKey 使用 API Key 鉴权时，gin.Context 中保存 *model.APIKey 的 key
+const APIKeyContextKey = "apiKey"
+
+// RequireScope 使用 API Key 访问时要求其拥有指定权限范围，JWT 登录态不受限制
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if v, ok := c.Get(APIKeyContextKey); ok {
+			if key, _ := v.(*model.APIKey); key == nil || !key.HasScope(scope) {
+				res := new(common.Response)
+				c.JSON(http.StatusOK, res.CodeOf(code.CodeForbidden))
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// RequireRole 要求登录用户拥有指定角色之一，需配合 AuthMiddleware 使用；API Key 不携带角色，一律拒绝
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !slices.Contains(roles, c.GetString("role")) {
+			res := new(common.Response)
+			c.JSON(http.StatusOK, res.CodeOf(code.CodeForbidden))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// DenyAPIKey 只允许 JWT 登录态访问，用于 API Key 管理等敏感操作
+func DenyAPIKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get(APIKeyContextKey); ok {
+			res := new(common.Response)
+			c.JSON(http.StatusOK, res.CodeOf(code.CodeForbidden))
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
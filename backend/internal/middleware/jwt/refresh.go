@@ -0,0 +1,202 @@
+package jwt
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"wsai/backend/config"
+	redisclient "wsai/backend/internal/common/redis"
+	"wsai/backend/internal/service/loginsession"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// refresh token 存储在 Redis 中（只存哈希）：
+//
+//	jwt:refresh:<hash>      refresh token 记录：jti、uid、username、role
+//	jwt:refresh:used:<hash> 已被使用过的标记，再次出现即视为重放
+//
+// 同一次登录签发的 access/refresh token 共享 jti（即 token 家族）。每次刷新都会
+// 签发同一 jti 的新 refresh token 并作废旧的；已作废的 refresh token 再次被使用
+// 说明可能已泄露，整个登录随即吊销。
+const (
+	refreshKeyPrefix     = "jwt:refresh:"
+	refreshUsedKeyPrefix = "jwt:refresh:used:"
+)
+
+var (
+	ErrRefreshTokenInvalid = errors.New("refresh token 无效或已过期")
+	ErrRefreshTokenReused  = errors.New("refresh token 被重复使用")
+)
+
+// TokenPair 登录后签发的 access/refresh token
+type TokenPair struct {
+	AccessToken      string    `json:"token"`
+	RefreshToken     string    `json:"refresh_token"`
+	ExpiresIn        int64     `json:"expires_in"` // access token 有效秒数
+	JTI              string    `json:"-"`
+	RefreshExpiresAt time.Time `json:"-"`
+}
+
+// RefreshSubject refresh token 对应的登录
+type RefreshSubject struct {
+	JTI      string
+	Id       int64
+	Username string
+	Role     string
+}
+
+// parseTTL 在 time.ParseDuration 基础上支持以 d 结尾的天数，如 30d
+func parseTTL(raw string, fallback time.Duration) time.Duration {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return fallback
+	}
+	if days, ok := strings.CutSuffix(raw, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return time.Duration(n) * 24 * time.Hour
+		}
+		return fallback
+	}
+	if d, err := time.ParseDuration(raw); err == nil && d > 0 {
+		return d
+	}
+	return fallback
+}
+
+func accessTTL() time.Duration {
+	if config.C == nil {
+		return 24 * time.Hour
+	}
+	return parseTTL(config.C.JWTConfig.AccessTTL, 24*time.Hour)
+}
+
+func refreshTTL() time.Duration {
+	if config.C == nil {
+		return 30 * 24 * time.Hour
+	}
+	return parseTTL(config.C.JWTConfig.RefreshTTL, 30*24*time.Hour)
+}
+
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IssueTokenPair 登录时为新的 jti 签发 access/refresh token
+// 角色变更或禁用用户时会吊销其全部登录，因此 refresh 时沿用签发时的角色
+func IssueTokenPair(ctx context.Context, id int64, username string, role string) (*TokenPair, error) {
+	jti, err := loginsession.NewJTI()
+	if err != nil {
+		return nil, err
+	}
+	return issueTokenPair(ctx, &RefreshSubject{JTI: jti, Id: id, Username: username, Role: role})
+}
+
+func issueTokenPair(ctx context.Context, subject *RefreshSubject) (*TokenPair, error) {
+	if redisclient.Rdb == nil {
+		return nil, errors.New("Redis 客户端未初始化")
+	}
+	access, err := GenerateToken(subject.Id, subject.Username, subject.Role, subject.JTI)
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	key := refreshKeyPrefix + hashToken(refresh)
+	_, err = redisclient.Rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key,
+			"jti", subject.JTI,
+			"uid", subject.Id,
+			"username", subject.Username,
+			"role", subject.Role,
+		)
+		pipe.Expire(ctx, key, refreshTTL())
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		AccessToken:      access,
+		RefreshToken:     refresh,
+		ExpiresIn:        int64(accessTTL().Seconds()),
+		JTI:              subject.JTI,
+		RefreshExpiresAt: time.Now().Add(refreshTTL()),
+	}, nil
+}
+
+// RotateRefreshToken 用 refresh token 换取新的 token 对，旧 refresh token 随即作废；
+// 重复使用已作废的 refresh token 会吊销整个登录并返回 ErrRefreshTokenReused
+func RotateRefreshToken(ctx context.Context, refresh string) (*TokenPair, error) {
+	if redisclient.Rdb == nil {
+		return nil, errors.New("Redis 客户端未初始化")
+	}
+	subject, err := lookupRefreshToken(ctx, refresh)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked, err := loginsession.IsRevoked(ctx, subject.JTI)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	// SETNX 保证同一个 refresh token 只能成功使用一次
+	hash := hashToken(refresh)
+	first, err := redisclient.Rdb.SetNX(ctx, refreshUsedKeyPrefix+hash, "1", refreshTTL()).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !first {
+		// 该登录最近签发的 access token 最晚在一个 access_ttl 后过期
+		if err := loginsession.RevokeJTI(ctx, subject.JTI, time.Now().Add(accessTTL())); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	return issueTokenPair(ctx, subject)
+}
+
+func lookupRefreshToken(ctx context.Context, refresh string) (*RefreshSubject, error) {
+	if redisclient.Rdb == nil {
+		return nil, errors.New("Redis 客户端未初始化")
+	}
+	if refresh == "" {
+		return nil, ErrRefreshTokenInvalid
+	}
+	vals, err := redisclient.Rdb.HGetAll(ctx, refreshKeyPrefix+hashToken(refresh)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(vals) == 0 {
+		return nil, ErrRefreshTokenInvalid
+	}
+	id, _ := strconv.ParseInt(vals["uid"], 10, 64)
+	return &RefreshSubject{
+		JTI:      vals["jti"],
+		Id:       id,
+		Username: vals["username"],
+		Role:     vals["role"],
+	}, nil
+}
@@ -0,0 +1,116 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"wsai/backend/config"
+	"wsai/backend/internal/common/mysql"
+	redisclient "wsai/backend/internal/common/redis"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	gormmysql "gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setup 用 miniredis 和 sqlmock 替换全局 Redis、MySQL，并使用 HS256 密钥签发 token
+func setup(t *testing.T, accessTTL string) (*miniredis.Miniredis, sqlmock.Sqlmock) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	db, err := gorm.Open(gormmysql.New(gormmysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}),
+		&gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("gorm.Open: %v", err)
+	}
+
+	oldRdb, oldDB, oldConf := redisclient.Rdb, mysql.DB, config.C
+	redisclient.Rdb = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	mysql.DB = db
+	config.C = new(config.Config)
+	config.C.JWTConfig.Secret = "test-secret-test-secret-test-secret"
+	config.C.JWTConfig.AccessTTL = accessTTL
+	config.C.JWTConfig.RefreshTTL = "30d"
+	if err := InitKeys(); err != nil {
+		t.Fatalf("InitKeys: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = redisclient.Rdb.Close()
+		_ = sqlDB.Close()
+		redisclient.Rdb, mysql.DB, config.C = oldRdb, oldDB, oldConf
+		keysMu.Lock()
+		current = nil
+		keysMu.Unlock()
+	})
+	return mr, mock
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	setup(t, "2h")
+	ctx := context.Background()
+
+	pair, err := IssueTokenPair(ctx, 1, "alice", "user")
+	if err != nil {
+		t.Fatalf("IssueTokenPair: %v", err)
+	}
+	next, err := RotateRefreshToken(ctx, pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if next.JTI != pair.JTI {
+		t.Fatalf("rotated jti = %q, want %q", next.JTI, pair.JTI)
+	}
+	if next.RefreshToken == pair.RefreshToken {
+		t.Fatal("rotation returned the same refresh token")
+	}
+	claims, ok := ParseTokenClaims(next.AccessToken)
+	if !ok || claims.ID != pair.JTI || claims.Username != "alice" || claims.Role != "user" {
+		t.Fatalf("rotated access token claims = %+v, ok=%v", claims, ok)
+	}
+
+	if _, err := RotateRefreshToken(ctx, "not-a-refresh-token"); !errors.Is(err, ErrRefreshTokenInvalid) {
+		t.Fatalf("unknown token: got %v, want ErrRefreshTokenInvalid", err)
+	}
+	if _, err := RotateRefreshToken(ctx, ""); !errors.Is(err, ErrRefreshTokenInvalid) {
+		t.Fatalf("empty token: got %v, want ErrRefreshTokenInvalid", err)
+	}
+}
+
+func TestRefreshTokenReuseRevokesLogin(t *testing.T) {
+	mr, mock := setup(t, "72h")
+	ctx := context.Background()
+
+	pair, err := IssueTokenPair(ctx, 1, "alice", "user")
+	if err != nil {
+		t.Fatalf("IssueTokenPair: %v", err)
+	}
+	next, err := RotateRefreshToken(ctx, pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+
+	// 没有登录记录时，吊销标记按 access_ttl 保留
+	mock.ExpectQuery("login_sessions").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	if _, err := RotateRefreshToken(ctx, pair.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("reuse: got %v, want ErrRefreshTokenReused", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+	ttl := mr.TTL("jwt:blacklist:" + pair.JTI)
+	if ttl < 71*time.Hour || ttl > 72*time.Hour {
+		t.Fatalf("blacklist ttl = %v, want about 72h", ttl)
+	}
+
+	// 整个登录已吊销，最新的 refresh token 也不能再用
+	if _, err := RotateRefreshToken(ctx, next.RefreshToken); !errors.Is(err, ErrRefreshTokenInvalid) {
+		t.Fatalf("rotate after reuse: got %v, want ErrRefreshTokenInvalid", err)
+	}
+}
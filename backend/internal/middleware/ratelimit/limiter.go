@@ -0,0 +1,136 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+	redisclient "wsai/backend/internal/common/redis"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const keyPrefix = "ratelimit:"
+
+// Result 一次限流判断的结果
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// ResetAfter 窗口内最早一次请求过期（即额度恢复）还需的时间
+	ResetAfter time.Duration
+}
+
+// slidingWindowScript 基于有序集合的滑动窗口，返回 {是否放行, 剩余次数, 重置毫秒数}
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call('ZREMRANGEBYSCORE', key, 0, now - window)
+local count = redis.call('ZCARD', key)
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', key, now, ARGV[4])
+	redis.call('PEXPIRE', key, window)
+	count = count + 1
+	allowed = 1
+end
+local reset = window
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = window - (now - tonumber(oldest[2]))
+end
+return {allowed, limit - count, reset}
+`)
+
+func allowRedis(ctx context.Context, key string, limit int, window time.Duration) (*Result, error) {
+	if redisclient.Rdb == nil {
+		return nil, errors.New("Redis 客户端未初始化")
+	}
+	now := time.Now().UnixMilli()
+	vals, err := slidingWindowScript.Run(ctx, redisclient.Rdb, []string{keyPrefix + key},
+		now, window.Milliseconds(), limit, uuid.New().String()).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+	if len(vals) != 3 {
+		return nil, errors.New("限流脚本返回值异常")
+	}
+	return &Result{
+		Allowed:    vals[0] == 1,
+		Limit:      limit,
+		Remaining:  int(max(vals[1], 0)),
+		ResetAfter: time.Duration(vals[2]) * time.Millisecond,
+	}, nil
+}
+
+// memoryLimiter Redis 不可用时的进程内滑动窗口，多实例部署下各实例独立计数。
+// 每条规则一个实例，清理时按该规则自己的窗口判断过期
+type memoryLimiter struct {
+	mu        sync.Mutex
+	window    time.Duration
+	hits      map[string][]time.Time
+	lastSweep time.Time
+}
+
+var (
+	fallbacks   = make(map[string]*memoryLimiter)
+	fallbacksMu sync.Mutex
+)
+
+// fallbackFor 返回规则 name 的内存限流器，同名规则挂在多个路由组上时共用计数
+func fallbackFor(name string, window time.Duration) *memoryLimiter {
+	fallbacksMu.Lock()
+	defer fallbacksMu.Unlock()
+	m, ok := fallbacks[name]
+	if !ok {
+		m = &memoryLimiter{window: window, hits: make(map[string][]time.Time)}
+		fallbacks[name] = m
+	}
+	return m
+}
+
+func (m *memoryLimiter) allow(key string, limit int) *Result {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	window := m.window
+	m.sweep(now)
+
+	hits := prune(m.hits[key], now.Add(-window))
+	res := &Result{Limit: limit}
+	if len(hits) < limit {
+		hits = append(hits, now)
+		res.Allowed = true
+	}
+	m.hits[key] = hits
+	res.Remaining = limit - len(hits)
+	res.ResetAfter = window - now.Sub(hits[0])
+	return res
+}
+
+// sweep 定期清理已过期的 key，避免内存无限增长
+func (m *memoryLimiter) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < m.window {
+		return
+	}
+	m.lastSweep = now
+	for k, hits := range m.hits {
+		if hits = prune(hits, now.Add(-m.window)); len(hits) == 0 {
+			delete(m.hits, k)
+		} else {
+			m.hits[k] = hits
+		}
+	}
+}
+
+func prune(hits []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(hits) && !hits[i].After(cutoff) {
+		i++
+	}
+	return hits[i:]
+}
@@ -0,0 +1,96 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+	"wsai/backend/config"
+	"wsai/backend/internal/common"
+	"wsai/backend/internal/common/code"
+	"wsai/backend/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// loadRule 读取名为 name 的规则，未启用、不存在或配置错误时返回 false
+func loadRule(name string) (config.RateLimitRule, time.Duration, bool) {
+	rule, ok := config.C.RateLimitConfig.Rules[name]
+	window, err := time.ParseDuration(rule.Window)
+	if !config.C.RateLimitConfig.Enabled || !ok || rule.Limit <= 0 || err != nil || window <= 0 {
+		if ok && err != nil {
+			logger.L().Warn("限流规则配置错误，已忽略",
+				zap.String("rule", name),
+				zap.String("window", rule.Window),
+				zap.Error(err))
+		}
+		return rule, 0, false
+	}
+	return rule, window, true
+}
+
+// take 对 identity 计数一次，Redis 不可用时退回进程内计数
+func take(name string, rule config.RateLimitRule, window time.Duration, identity string) *Result {
+	key := name + ":" + identity
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	result, err := allowRedis(ctx, key, rule.Limit, window)
+	if err != nil {
+		logger.L().Warn("Redis 限流失败，使用内存限流",
+			zap.String("rule", name),
+			zap.Error(err))
+		result = fallbackFor(name, window).allow(key, rule.Limit)
+	}
+	return result
+}
+
+// AllowUser 按规则 name 对用户计数一次，与 Middleware 共用同一计数，供 WebSocket 帧等不经过 HTTP 中间件的请求使用。
+// 规则未启用时总是放行
+func AllowUser(name string, userID int64) bool {
+	rule, window, ok := loadRule(name)
+	if !ok {
+		return true
+	}
+	return take(name, rule, window, userIdentity(userID)).Allowed
+}
+
+// Middleware 按配置中名为 name 的规则限流；已登录请求按用户 ID 计数，否则按客户端 IP
+func Middleware(name string) gin.HandlerFunc {
+	rule, window, ok := loadRule(name)
+	if !ok {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	return func(c *gin.Context) {
+		result := take(name, rule, window, identity(c))
+
+		resetSeconds := strconv.Itoa(int(math.Ceil(result.ResetAfter.Seconds())))
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", resetSeconds)
+
+		if !result.Allowed {
+			c.Header("Retry-After", resetSeconds)
+			res := new(common.Response)
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, res.CodeOf(code.CodeTooManyRequests))
+			return
+		}
+		c.Next()
+	}
+}
+
+func identity(c *gin.Context) string {
+	if id, ok := c.Get("userID"); ok {
+		return fmt.Sprintf("user:%v", id)
+	}
+	return "ip:" + c.ClientIP()
+}
+
+func userIdentity(userID int64) string {
+	return fmt.Sprintf("user:%v", userID)
+}
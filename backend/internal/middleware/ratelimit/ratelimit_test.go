@@ -0,0 +1,185 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"wsai/backend/config"
+	redisclient "wsai/backend/internal/common/redis"
+	"wsai/backend/internal/logger"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// setup 用 miniredis 替换全局 Redis，并启用名为 name 的规则
+func setup(t *testing.T, name string, limit int, window string) *miniredis.Miniredis {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	oldRdb, oldConf := redisclient.Rdb, config.C
+	redisclient.Rdb = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	config.C = new(config.Config)
+	config.C.RateLimitConfig.Enabled = true
+	config.C.RateLimitConfig.Rules = map[string]config.RateLimitRule{
+		name: {Limit: limit, Window: window},
+	}
+	t.Cleanup(func() {
+		_ = redisclient.Rdb.Close()
+		redisclient.Rdb, config.C = oldRdb, oldConf
+	})
+	return mr
+}
+
+func TestAllowRedisSlidingWindow(t *testing.T) {
+	setup(t, "chat", 2, "200ms")
+	ctx := context.Background()
+	window := 200 * time.Millisecond
+
+	for i := range 2 {
+		res, err := allowRedis(ctx, "chat:ip:1.1.1.1", 2, window)
+		if err != nil {
+			t.Fatalf("request %d: %v", i+1, err)
+		}
+		if !res.Allowed || res.Remaining != 1-i {
+			t.Fatalf("request %d = %+v", i+1, res)
+		}
+	}
+	res, err := allowRedis(ctx, "chat:ip:1.1.1.1", 2, window)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Allowed || res.Remaining != 0 || res.ResetAfter <= 0 || res.ResetAfter > window {
+		t.Fatalf("over limit = %+v", res)
+	}
+	// 其他 key 单独计数
+	if res, _ := allowRedis(ctx, "chat:ip:2.2.2.2", 2, window); !res.Allowed {
+		t.Fatal("other key was limited")
+	}
+
+	// 最早的请求滑出窗口后恢复额度
+	time.Sleep(window + 20*time.Millisecond)
+	if res, _ := allowRedis(ctx, "chat:ip:1.1.1.1", 2, window); !res.Allowed || res.Remaining != 1 {
+		t.Fatalf("after window = %+v", res)
+	}
+}
+
+func TestMemoryLimiter(t *testing.T) {
+	window := 100 * time.Millisecond
+	m := &memoryLimiter{window: window, hits: make(map[string][]time.Time)}
+
+	if res := m.allow("a", 1); !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("first = %+v", res)
+	}
+	if res := m.allow("a", 1); res.Allowed || res.ResetAfter <= 0 {
+		t.Fatalf("second = %+v", res)
+	}
+	if res := m.allow("b", 1); !res.Allowed {
+		t.Fatal("other key was limited")
+	}
+
+	time.Sleep(window + 20*time.Millisecond)
+	if res := m.allow("a", 1); !res.Allowed {
+		t.Fatalf("after window = %+v", res)
+	}
+	// 过期的 key 被清理
+	if _, ok := m.hits["b"]; ok {
+		t.Fatal("expired key was not swept")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setup(t, "captcha", 2, "1m")
+
+	r := gin.New()
+	r.GET("/", Middleware("captcha"), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	do := func(ip string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = ip + ":1234"
+		r.ServeHTTP(w, req)
+		return w
+	}
+	for i := range 2 {
+		w := do("1.1.1.1")
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("request %d: status %d", i+1, w.Code)
+		}
+		if w.Header().Get("X-RateLimit-Limit") != "2" {
+			t.Fatalf("request %d: limit header %q", i+1, w.Header().Get("X-RateLimit-Limit"))
+		}
+	}
+	w := do("1.1.1.1")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("over limit: status %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" || w.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Fatalf("over limit headers = %v", w.Header())
+	}
+	if w := do("2.2.2.2"); w.Code != http.StatusNoContent {
+		t.Fatalf("other ip: status %d", w.Code)
+	}
+}
+
+func TestAllowUserSharesCount(t *testing.T) {
+	setup(t, "chat", 2, "1m")
+
+	if !AllowUser("chat", 1) || !AllowUser("chat", 1) {
+		t.Fatal("requests within limit were rejected")
+	}
+	if AllowUser("chat", 1) {
+		t.Fatal("request over limit was allowed")
+	}
+	if !AllowUser("chat", 2) {
+		t.Fatal("other user was limited")
+	}
+	// 未配置的规则总是放行
+	if !AllowUser("image", 1) {
+		t.Fatal("unknown rule was limited")
+	}
+}
+
+func TestTakeFallsBackToMemory(t *testing.T) {
+	mr := setup(t, "chat", 1, "1m")
+	if err := logger.Init(true); err != nil {
+		t.Fatal(err)
+	}
+	mr.Close()
+
+	if !AllowUser("chat", 3) {
+		t.Fatal("first request was rejected")
+	}
+	if AllowUser("chat", 3) {
+		t.Fatal("memory fallback did not limit")
+	}
+}
+
+func TestLoadRule(t *testing.T) {
+	setup(t, "chat", 5, "1m")
+	rules := config.C.RateLimitConfig.Rules
+	rules["bad"] = config.RateLimitRule{Limit: 5, Window: "soon"}
+	rules["zero"] = config.RateLimitRule{Limit: 0, Window: "1m"}
+
+	if _, window, ok := loadRule("chat"); !ok || window != time.Minute {
+		t.Fatalf("chat: window %v, ok %v", window, ok)
+	}
+	for _, name := range []string{"missing", "zero"} {
+		if _, _, ok := loadRule(name); ok {
+			t.Fatalf("%s: rule enabled", name)
+		}
+	}
+	if err := logger.Init(true); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := loadRule("bad"); ok {
+		t.Fatal("bad: rule enabled")
+	}
+	config.C.RateLimitConfig.Enabled = false
+	if _, _, ok := loadRule("chat"); ok {
+		t.Fatal("disabled: rule enabled")
+	}
+}
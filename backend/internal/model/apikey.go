@@ -0,0 +1,48 @@
+package model
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKey 用户的长期 API Key，只保存哈希，明文仅在创建时返回一次
+type APIKey struct {
+	ID         int64          `gorm:"primary_key" json:"id"`
+	UserID     int64          `gorm:"index;not null" json:"-"`
+	UserName   string         `gorm:"type:varchar(50);index;not null" json:"-"`
+	Name       string         `gorm:"type:varchar(50)" json:"name"`
+	Prefix     string         `gorm:"type:varchar(16)" json:"prefix"`
+	KeyHash    string         `gorm:"type:char(64);uniqueIndex" json:"-"`
+	Scopes     string         `gorm:"type:varchar(100)" json:"-"` // 逗号分隔，见 APIKeyScopes
+	LastUsedAt *time.Time     `json:"last_used_at"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// API Key 权限范围
+const (
+	ScopeChat      = "chat"
+	ScopeImage     = "image"
+	ScopeUsage     = "usage"
+	ScopeKnowledge = "knowledge"
+)
+
+// APIKeyScopes 全部可授予的权限范围
+var APIKeyScopes = []string{ScopeChat, ScopeImage, ScopeUsage, ScopeKnowledge}
+
+// ScopeList 拆分存储的权限范围
+func (k *APIKey) ScopeList() []string {
+	if k.Scopes == "" {
+		return []string{}
+	}
+	return strings.Split(k.Scopes, ",")
+}
+
+// HasScope 是否拥有某项权限
+func (k *APIKey) HasScope(scope string) bool {
+	return slices.Contains(k.ScopeList(), scope)
+}
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// AuditLog 安全与数据相关操作的审计记录，只追加不修改
+type AuditLog struct {
+	ID         int64     `gorm:"primary_key" json:"id"`
+	Action     string    `gorm:"type:varchar(50);index;not null" json:"action"`
+	ActorID    int64     `gorm:"index" json:"actor_id"`               // 未登录的操作（如登录失败）为 0
+	ActorName  string    `gorm:"type:varchar(100)" json:"actor_name"` // 用户名，登录失败时为输入的用户名或邮箱
+	IP         string    `gorm:"type:varchar(64);index" json:"ip"`
+	UserAgent  string    `gorm:"type:varchar(255)" json:"user_agent"`
+	TargetType string    `gorm:"type:varchar(30);index:idx_audit_target" json:"target_type"`
+	TargetID   string    `gorm:"type:varchar(64);index:idx_audit_target" json:"target_id"`
+	Success    bool      `json:"success"`
+	Detail     string    `gorm:"type:varchar(500)" json:"detail"`
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`
+}
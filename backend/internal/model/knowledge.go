@@ -0,0 +1,52 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// KnowledgeBase 用户的知识库，会话绑定后回答时会检索其中的文档
+type KnowledgeBase struct {
+	ID            int64          `gorm:"primary_key" json:"id"`
+	UserID        int64          `gorm:"index;not null" json:"-"`
+	Name          string         `gorm:"type:varchar(100);not null" json:"name"`
+	Description   string         `gorm:"type:varchar(500)" json:"description"`
+	DocumentCount int64          `gorm:"-" json:"document_count"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// 文档处理状态
+const (
+	DocumentPending    = "pending"
+	DocumentProcessing = "processing"
+	DocumentReady      = "ready"
+	DocumentFailed     = "failed"
+)
+
+// Document 上传到知识库的文档，原文件保存在 rag.storage_dir 下，切块与向量化在后台完成
+type Document struct {
+	ID              int64     `gorm:"primary_key" json:"id"`
+	KnowledgeBaseID int64     `gorm:"index;not null" json:"knowledge_base_id"`
+	UserID          int64     `gorm:"index;not null" json:"-"`
+	Filename        string    `gorm:"type:varchar(255);not null" json:"filename"`
+	Size            int64     `json:"size"`
+	StoragePath     string    `gorm:"type:varchar(500)" json:"-"`
+	Status          string    `gorm:"type:varchar(20);index" json:"status"`
+	Error           string    `gorm:"type:varchar(500)" json:"error,omitempty"`
+	ChunkCount      int       `json:"chunk_count"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// DocumentChunk 文档切块及其向量；Embedding 为归一化后的 float32 小端序列
+type DocumentChunk struct {
+	ID              int64  `gorm:"primary_key"`
+	DocumentID      int64  `gorm:"index;not null"`
+	KnowledgeBaseID int64  `gorm:"index;not null"`
+	Seq             int    `gorm:"not null"`
+	Content         string `gorm:"type:text;not null"`
+	Embedding       []byte `gorm:"type:mediumblob"`
+}
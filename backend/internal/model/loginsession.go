@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// LoginSession 一次登录（设备），签发的 access/refresh token 通过 jti 关联到这里
+type LoginSession struct {
+	ID         int64      `gorm:"primary_key" json:"id"`
+	UserID     int64      `gorm:"index;not null" json:"-"`
+	UserName   string     `gorm:"type:varchar(50);index;not null" json:"-"`
+	JTI        string     `gorm:"column:jti;type:char(32);uniqueIndex" json:"-"`
+	DeviceName string     `gorm:"type:varchar(100)" json:"device_name"`
+	IP         string     `gorm:"type:varchar(64)" json:"ip"`
+	UserAgent  string     `gorm:"type:varchar(255)" json:"user_agent"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `gorm:"index" json:"expires_at"` // refresh token 到期时间，每次刷新顺延
+	RevokedAt  *time.Time `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
@@ -5,12 +5,17 @@ import (
 )
 
 type Message struct {
-	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
-	SessionID string    `gorm:"index;not null;type:varchar(36)" json:"session_id"`
-	UserName  string    `gorm:"type:varchar(20)" json:"username"`
-	Content   string    `gorm:"type:text" json:"content"`
-	IsUser    bool      `gorm:"not null;" json:"is_user"`
-	CreatedAt time.Time `json:"created_at"`
+	ID               uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	SessionID        string    `gorm:"index;not null;type:varchar(36)" json:"session_id"`
+	UserName         string    `gorm:"type:varchar(20)" json:"username"`
+	Content          string    `gorm:"type:text" json:"content"`
+	IsUser           bool      `gorm:"not null;" json:"is_user"`
+	ModelType        string    `gorm:"type:varchar(20)" json:"model_type,omitempty"`
+	PromptTokens     int       `gorm:"not null;default:0" json:"prompt_tokens"`
+	CompletionTokens int       `gorm:"not null;default:0" json:"completion_tokens"`
+	TotalTokens      int       `gorm:"not null;default:0" json:"total_tokens"`
+	Cost             float64   `gorm:"type:decimal(16,6);not null;default:0" json:"cost"`
+	CreatedAt        time.Time `json:"created_at"`
 }
 
 type History struct {
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+const DefaultPlan = "free"
+
+// Plan 套餐，各限额为 0 表示不限制
+type Plan struct {
+	ID              int64     `gorm:"primary_key" json:"id"`
+	Name            string    `gorm:"type:varchar(20);uniqueIndex" json:"name"`
+	DailyTokens     int64     `gorm:"not null;default:0" json:"daily_tokens"`
+	MonthlyTokens   int64     `gorm:"not null;default:0" json:"monthly_tokens"`
+	DailyRequests   int64     `gorm:"not null;default:0" json:"daily_requests"`
+	MonthlyRequests int64     `gorm:"not null;default:0" json:"monthly_requests"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
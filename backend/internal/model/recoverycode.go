@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// RecoveryCode 两步验证的恢复码，只保存哈希，每个只能使用一次
+type RecoveryCode struct {
+	ID        int64  `gorm:"primary_key"`
+	UserID    int64  `gorm:"index;not null"`
+	CodeHash  string `gorm:"type:char(64);not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
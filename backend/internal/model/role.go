@@ -0,0 +1,17 @@
+package model
+
+import "slices"
+
+// 用户角色
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Roles 全部角色
+var Roles = []string{RoleUser, RoleAdmin}
+
+// IsValidRole 角色是否存在
+func IsValidRole(role string) bool {
+	return slices.Contains(Roles, role)
+}
@@ -13,10 +13,14 @@ type Session struct {
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	// KnowledgeBaseID 绑定的知识库，为空表示回答时不检索
+	KnowledgeBaseID *int64 `gorm:"index" json:"knowledge_base_id,omitempty"`
 }
 
 type SessionInfo struct {
 	SessionID string    `json:"sessionId"`
 	Title     string    `json:"title"`
 	UpdatedAt time.Time `json:"updatedAt"`
+	// KnowledgeBaseID 绑定的知识库
+	KnowledgeBaseID *int64 `json:"knowledgeBaseId,omitempty"`
 }
@@ -4,12 +4,20 @@ import "time"
 import "gorm.io/gorm"
 
 type User struct {
-	ID        int64          `gorm:"primary_key" json:"id"`
-	Name      string         `gorm:"type:varchar(50)" json:"name"`
-	Email     string         `gorm:"type:varchar(100);uniqueIndex" json:"email"`
-	Username  string         `gorm:"type:varchar(50);uniqueIndex" json:"username"`
-	Password  string         `gorm:"type:varchar(255)" json:"-"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID           int64          `gorm:"primary_key" json:"id"`
+	Name         string         `gorm:"type:varchar(50)" json:"name"`
+	Avatar       string         `gorm:"type:varchar(255)" json:"avatar"`
+	Email        string         `gorm:"type:varchar(100);uniqueIndex" json:"email"`
+	Username     string         `gorm:"type:varchar(50);uniqueIndex" json:"username"`
+	Password     string         `gorm:"type:varchar(255)" json:"-"`
+	Plan         string         `gorm:"type:varchar(20);not null;default:'free'" json:"plan"`
+	TOTPSecret   string         `gorm:"column:totp_secret;type:varchar(64)" json:"-"` // 登记后需验证一次才会开启
+	TOTPEnabled  bool           `gorm:"column:totp_enabled;not null;default:false" json:"totp_enabled"`
+	Role         string         `gorm:"type:varchar(20);not null;default:'user'" json:"role"`
+	Disabled     bool           `gorm:"not null;default:false" json:"disabled"` // 禁用后无法登录，已有登录全部吊销
+	DefaultModel string         `gorm:"type:varchar(20)" json:"default_model"`  // 偏好：对话未指定模型时使用
+	Language     string         `gorm:"type:varchar(10)" json:"language"`       // 偏好：界面语言
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
 }
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// UserIdentity 外部身份提供方（OIDC）账号与本地用户的绑定
+type UserIdentity struct {
+	ID          int64     `gorm:"primary_key" json:"id"`
+	UserID      int64     `gorm:"index;not null" json:"-"`
+	Provider    string    `gorm:"type:varchar(50);uniqueIndex:idx_provider_subject;not null" json:"provider"`
+	Subject     string    `gorm:"type:varchar(255);uniqueIndex:idx_provider_subject;not null" json:"-"`
+	Email       string    `gorm:"type:varchar(100)" json:"email"`
+	LastLoginAt time.Time `json:"last_login_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
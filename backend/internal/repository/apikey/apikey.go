@@ -0,0 +1,49 @@
+package apikey
+
+import (
+	"time"
+	"wsai/backend/internal/common/mysql"
+	"wsai/backend/internal/model"
+)
+
+func CreateAPIKey(key *model.APIKey) (*model.APIKey, error) {
+	err := mysql.DB.Create(key).Error
+	return key, err
+}
+
+func GetAPIKeyByHash(keyHash string) (*model.APIKey, error) {
+	key := &model.APIKey{}
+	err := mysql.DB.Where("key_hash = ?", keyHash).First(key).Error
+	return key, err
+}
+
+func GetUserAPIKey(userID int64, id int64) (*model.APIKey, error) {
+	key := &model.APIKey{}
+	err := mysql.DB.Where("id = ? AND user_id = ?", id, userID).First(key).Error
+	return key, err
+}
+
+func FindUserAPIKeys(userID int64) ([]*model.APIKey, error) {
+	var keys []*model.APIKey
+	err := mysql.DB.Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&keys).Error
+	return keys, err
+}
+
+func UpdateAPIKey(key *model.APIKey, fields map[string]interface{}) error {
+	return mysql.DB.Model(key).Updates(fields).Error
+}
+
+func TouchAPIKey(id int64, at time.Time) error {
+	return mysql.DB.Model(&model.APIKey{}).
+		Where("id = ?", id).
+		UpdateColumn("last_used_at", at).
+		Error
+}
+
+// RevokeAPIKey 软删除，吊销后按哈希查不到
+func RevokeAPIKey(userID int64, id int64) (bool, error) {
+	result := mysql.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&model.APIKey{})
+	return result.RowsAffected > 0, result.Error
+}
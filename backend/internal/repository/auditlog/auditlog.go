@@ -0,0 +1,96 @@
+package auditlog
+
+import (
+	"time"
+	"wsai/backend/internal/common/mysql"
+	"wsai/backend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// Filter 审计日志查询条件，零值表示不限制
+type Filter struct {
+	Action     string
+	ActorID    int64
+	ActorName  string
+	IP         string
+	TargetType string
+	TargetID   string
+	Success    *bool
+	From       time.Time
+	To         time.Time
+}
+
+func CreateAuditLog(l *model.AuditLog) error {
+	return mysql.DB.Create(l).Error
+}
+
+func (f Filter) apply(db *gorm.DB) *gorm.DB {
+	if f.Action != "" {
+		db = db.Where("action = ?", f.Action)
+	}
+	if f.ActorID != 0 {
+		db = db.Where("actor_id = ?", f.ActorID)
+	}
+	if f.ActorName != "" {
+		db = db.Where("actor_name = ?", f.ActorName)
+	}
+	if f.IP != "" {
+		db = db.Where("ip = ?", f.IP)
+	}
+	if f.TargetType != "" {
+		db = db.Where("target_type = ?", f.TargetType)
+	}
+	if f.TargetID != "" {
+		db = db.Where("target_id = ?", f.TargetID)
+	}
+	if f.Success != nil {
+		db = db.Where("success = ?", *f.Success)
+	}
+	if !f.From.IsZero() {
+		db = db.Where("created_at >= ?", f.From)
+	}
+	if !f.To.IsZero() {
+		db = db.Where("created_at < ?", f.To)
+	}
+	return db
+}
+
+// FindAuditLogs 按时间倒序分页查询
+func FindAuditLogs(f Filter, offset, limit int) ([]*model.AuditLog, int64, error) {
+	var (
+		logs  []*model.AuditLog
+		total int64
+	)
+	db := f.apply(mysql.DB.Model(&model.AuditLog{}))
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := db.Order("id DESC").Offset(offset).Limit(limit).Find(&logs).Error
+	return logs, total, err
+}
+
+// EachAuditLog 按时间倒序逐批读取，最多 limit 条，用于导出
+func EachAuditLog(f Filter, limit int, fn func([]*model.AuditLog) error) error {
+	const batch = 500
+	lastID := int64(0)
+	for read := 0; read < limit; {
+		var logs []*model.AuditLog
+		db := f.apply(mysql.DB.Model(&model.AuditLog{}))
+		if lastID != 0 {
+			db = db.Where("id < ?", lastID)
+		}
+		if err := db.Order("id DESC").Limit(min(batch, limit-read)).Find(&logs).Error; err != nil {
+			return err
+		}
+		if len(logs) == 0 {
+			return nil
+		}
+		if err := fn(logs); err != nil {
+			return err
+		}
+		read += len(logs)
+		lastID = logs[len(logs)-1].ID
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package knowledge
+
+import (
+	"wsai/backend/internal/common/mysql"
+	"wsai/backend/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReplaceChunks 用新的切块替换文档原有的切块，并更新文档的切块数；
+// 文档已被删除时返回 gorm.ErrRecordNotFound，不留下孤立的切块
+func ReplaceChunks(documentID int64, chunks []*model.DocumentChunk) error {
+	return mysql.DB.Transaction(func(tx *gorm.DB) error {
+		// 锁住文档行，与并发的删除互斥
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").
+			First(&model.Document{}, documentID).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&model.Document{}).
+			Where("id = ?", documentID).
+			Update("chunk_count", len(chunks)).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("document_id = ?", documentID).Delete(&model.DocumentChunk{}).Error; err != nil {
+			return err
+		}
+		if len(chunks) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(chunks, 100).Error
+	})
+}
+
+// FindChunkVectors 知识库下已就绪文档的全部切块向量，不含正文
+func FindChunkVectors(knowledgeBaseID int64) ([]*model.DocumentChunk, error) {
+	var chunks []*model.DocumentChunk
+	err := mysql.DB.Select("document_chunks.id", "document_chunks.document_id", "document_chunks.embedding").
+		Joins("JOIN documents ON documents.id = document_chunks.document_id AND documents.status = ?", model.DocumentReady).
+		Where("document_chunks.knowledge_base_id = ?", knowledgeBaseID).
+		Find(&chunks).Error
+	return chunks, err
+}
+
+// GetChunks 按 ID 查询切块正文
+func GetChunks(ids []int64) ([]*model.DocumentChunk, error) {
+	var chunks []*model.DocumentChunk
+	err := mysql.DB.Select("id", "document_id", "seq", "content").
+		Where("id IN ?", ids).
+		Find(&chunks).Error
+	return chunks, err
+}
+
+// GetDocumentNames 文档 ID 到文件名的映射
+func GetDocumentNames(ids []int64) (map[int64]string, error) {
+	var docs []*model.Document
+	err := mysql.DB.Select("id", "filename").Where("id IN ?", ids).Find(&docs).Error
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[int64]string, len(docs))
+	for _, d := range docs {
+		names[d.ID] = d.Filename
+	}
+	return names, nil
+}
@@ -0,0 +1,56 @@
+package knowledge
+
+import (
+	"wsai/backend/internal/common/mysql"
+	"wsai/backend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+func CreateDocument(doc *model.Document) (*model.Document, error) {
+	err := mysql.DB.Create(doc).Error
+	return doc, err
+}
+
+func GetDocument(id int64) (*model.Document, error) {
+	doc := &model.Document{}
+	err := mysql.DB.Where("id = ?", id).First(doc).Error
+	return doc, err
+}
+
+func GetUserDocument(userID int64, id int64) (*model.Document, error) {
+	doc := &model.Document{}
+	err := mysql.DB.Where("id = ? AND user_id = ?", id, userID).First(doc).Error
+	return doc, err
+}
+
+func FindDocuments(knowledgeBaseID int64) ([]*model.Document, error) {
+	var docs []*model.Document
+	err := mysql.DB.Where("knowledge_base_id = ?", knowledgeBaseID).
+		Order("created_at DESC").
+		Find(&docs).Error
+	return docs, err
+}
+
+// FindDocumentPaths 知识库下全部文档的存储路径，删除知识库时清理文件
+func FindDocumentPaths(knowledgeBaseID int64) ([]string, error) {
+	var paths []string
+	err := mysql.DB.Model(&model.Document{}).
+		Where("knowledge_base_id = ?", knowledgeBaseID).
+		Pluck("storage_path", &paths).Error
+	return paths, err
+}
+
+func UpdateDocument(id int64, fields map[string]interface{}) error {
+	return mysql.DB.Model(&model.Document{}).Where("id = ?", id).Updates(fields).Error
+}
+
+// DeleteDocument 删除文档及其切块
+func DeleteDocument(id int64) error {
+	return mysql.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("document_id = ?", id).Delete(&model.DocumentChunk{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Document{}, id).Error
+	})
+}
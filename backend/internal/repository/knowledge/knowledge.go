@@ -0,0 +1,87 @@
+package knowledge
+
+import (
+	"wsai/backend/internal/common/mysql"
+	"wsai/backend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+func CreateKnowledgeBase(kb *model.KnowledgeBase) (*model.KnowledgeBase, error) {
+	err := mysql.DB.Create(kb).Error
+	return kb, err
+}
+
+func GetUserKnowledgeBase(userID int64, id int64) (*model.KnowledgeBase, error) {
+	kb := &model.KnowledgeBase{}
+	err := mysql.DB.Where("id = ? AND user_id = ?", id, userID).First(kb).Error
+	return kb, err
+}
+
+// FindUserKnowledgeBases 查询用户的知识库，并填充各自的文档数
+func FindUserKnowledgeBases(userID int64) ([]*model.KnowledgeBase, error) {
+	var bases []*model.KnowledgeBase
+	err := mysql.DB.Where("user_id = ?", userID).
+		Order("updated_at DESC").
+		Find(&bases).Error
+	if err != nil || len(bases) == 0 {
+		return bases, err
+	}
+
+	var counts []struct {
+		KnowledgeBaseID int64
+		N               int64
+	}
+	err = mysql.DB.Model(&model.Document{}).
+		Select("knowledge_base_id, COUNT(*) AS n").
+		Where("user_id = ?", userID).
+		Group("knowledge_base_id").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]int64, len(counts))
+	for _, c := range counts {
+		byID[c.KnowledgeBaseID] = c.N
+	}
+	for _, kb := range bases {
+		kb.DocumentCount = byID[kb.ID]
+	}
+	return bases, nil
+}
+
+func UpdateKnowledgeBase(kb *model.KnowledgeBase, fields map[string]interface{}) error {
+	return mysql.DB.Model(kb).Updates(fields).Error
+}
+
+// DeleteKnowledgeBase 删除知识库及其文档、切块，并解除会话绑定
+func DeleteKnowledgeBase(id int64) error {
+	return mysql.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("knowledge_base_id = ?", id).Delete(&model.DocumentChunk{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("knowledge_base_id = ?", id).Delete(&model.Document{}).Error; err != nil {
+			return err
+		}
+		err := tx.Model(&model.Session{}).
+			Where("knowledge_base_id = ?", id).
+			Update("knowledge_base_id", nil).Error
+		if err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&model.KnowledgeBase{}, id).Error
+	})
+}
+
+// DeleteUserKnowledge 删除用户的全部知识库数据，注销账号时使用
+func DeleteUserKnowledge(tx *gorm.DB, userID int64) error {
+	err := tx.Where("document_id IN (?)", tx.Model(&model.Document{}).Select("id").Where("user_id = ?", userID)).
+		Delete(&model.DocumentChunk{}).Error
+	if err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", userID).Delete(&model.Document{}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Where("user_id = ?", userID).Delete(&model.KnowledgeBase{}).Error
+}
@@ -0,0 +1,47 @@
+package loginsession
+
+import (
+	"time"
+	"wsai/backend/internal/common/mysql"
+	"wsai/backend/internal/model"
+)
+
+func CreateLoginSession(s *model.LoginSession) (*model.LoginSession, error) {
+	err := mysql.DB.Create(s).Error
+	return s, err
+}
+
+func GetLoginSessionByJTI(jti string) (*model.LoginSession, error) {
+	s := &model.LoginSession{}
+	err := mysql.DB.Where("jti = ?", jti).First(s).Error
+	return s, err
+}
+
+func GetUserLoginSession(userID int64, id int64) (*model.LoginSession, error) {
+	s := &model.LoginSession{}
+	err := mysql.DB.Where("id = ? AND user_id = ?", id, userID).First(s).Error
+	return s, err
+}
+
+// FindActiveLoginSessions 未吊销且未过期的登录
+func FindActiveLoginSessions(userID int64, now time.Time) ([]*model.LoginSession, error) {
+	var sessions []*model.LoginSession
+	err := mysql.DB.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+func TouchLoginSession(jti string, fields map[string]interface{}) error {
+	return mysql.DB.Model(&model.LoginSession{}).
+		Where("jti = ?", jti).
+		UpdateColumns(fields).
+		Error
+}
+
+func RevokeLoginSessions(ids []int64, at time.Time) error {
+	return mysql.DB.Model(&model.LoginSession{}).
+		Where("id IN ? AND revoked_at IS NULL", ids).
+		UpdateColumn("revoked_at", at).
+		Error
+}
@@ -0,0 +1,12 @@
+package plan
+
+import (
+	"wsai/backend/internal/common/mysql"
+	"wsai/backend/internal/model"
+)
+
+func GetPlanByName(name string) (*model.Plan, error) {
+	plan := &model.Plan{}
+	err := mysql.DB.Where("name = ?", name).First(plan).Error
+	return plan, err
+}
@@ -0,0 +1,43 @@
+package recoverycode
+
+import (
+	"time"
+	"wsai/backend/internal/common/mysql"
+	"wsai/backend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// ReplaceRecoveryCodes 删除用户旧的恢复码并写入新的一组
+func ReplaceRecoveryCodes(userID int64, hashes []string) error {
+	return mysql.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		codes := make([]*model.RecoveryCode, 0, len(hashes))
+		for _, hash := range hashes {
+			codes = append(codes, &model.RecoveryCode{UserID: userID, CodeHash: hash})
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// UseRecoveryCode 作废一个未使用的恢复码，返回是否命中
+func UseRecoveryCode(userID int64, hash string) (bool, error) {
+	result := mysql.DB.Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		UpdateColumn("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+func CountUnusedRecoveryCodes(userID int64) (int64, error) {
+	var n int64
+	err := mysql.DB.Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&n).Error
+	return n, err
+}
+
+func DeleteRecoveryCodes(userID int64) error {
+	return mysql.DB.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error
+}
@@ -33,7 +33,7 @@ func GetSessionByID(sessionID string) (*model.Session, error) {
 }
 func FindUserSessions(userID string) ([]*model.Session, error) {
 	var sessions []*model.Session
-	err := mysql.DB.Select("id", "title", "updated_at").
+	err := mysql.DB.Select("id", "title", "updated_at", "knowledge_base_id").
 		Where("user_name = ? AND deleted_at IS NULL", userID).
 		Order("updated_at DESC").
 		Find(&sessions).Error
@@ -46,3 +46,11 @@ func TouchSession(sessionID string) error {
 		Update("updated_at", time.Now()).
 		Error
 }
+
+// SetKnowledgeBase 绑定或解除（knowledgeBaseID 为 nil）会话的知识库
+func SetKnowledgeBase(sessionID string, knowledgeBaseID *int64) error {
+	return mysql.DB.Model(&model.Session{}).
+		Where("id = ? AND deleted_at IS NULL", sessionID).
+		Update("knowledge_base_id", knowledgeBaseID).
+		Error
+}
@@ -0,0 +1,70 @@
+package usage
+
+import (
+	"time"
+	"wsai/backend/internal/common/mysql"
+	"wsai/backend/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// Summary token 用量汇总
+type Summary struct {
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	Requests         int64   `json:"requests"`
+	Cost             float64 `json:"cost"`
+}
+
+// DailyUsage 按天汇总的用量
+type DailyUsage struct {
+	Date string `json:"date"`
+	Summary
+}
+
+// SessionUsage 按会话汇总的用量
+type SessionUsage struct {
+	SessionID string `json:"session_id"`
+	Summary
+}
+
+const summaryColumns = "COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens, " +
+	"COALESCE(SUM(completion_tokens), 0) AS completion_tokens, " +
+	"COALESCE(SUM(total_tokens), 0) AS total_tokens, " +
+	"COUNT(*) AS requests, " +
+	"COALESCE(SUM(cost), 0) AS cost"
+
+// 只统计 AI 回复，用量都记录在 AI 回复消息上
+func assistantMessages(username string, from, to time.Time) *gorm.DB {
+	return mysql.DB.Model(&model.Message{}).
+		Where("user_name = ? AND is_user = ? AND created_at >= ? AND created_at < ?", username, false, from, to)
+}
+
+func GetUserSummary(username string, from, to time.Time) (*Summary, error) {
+	summary := new(Summary)
+	err := assistantMessages(username, from, to).
+		Select(summaryColumns).
+		Scan(summary).Error
+	return summary, err
+}
+
+func GetUserDailyUsage(username string, from, to time.Time) ([]DailyUsage, error) {
+	var days []DailyUsage
+	err := assistantMessages(username, from, to).
+		Select("DATE_FORMAT(created_at, '%Y-%m-%d') AS date, " + summaryColumns).
+		Group("date").
+		Order("date ASC").
+		Scan(&days).Error
+	return days, err
+}
+
+func GetUserSessionUsage(username string, from, to time.Time) ([]SessionUsage, error) {
+	var sessions []SessionUsage
+	err := assistantMessages(username, from, to).
+		Select("session_id, " + summaryColumns).
+		Group("session_id").
+		Order("total_tokens DESC").
+		Scan(&sessions).Error
+	return sessions, err
+}
@@ -1,8 +1,12 @@
 package user
 
 import (
+	"fmt"
 	"wsai/backend/internal/common/mysql"
 	"wsai/backend/internal/model"
+	"wsai/backend/internal/repository/knowledge"
+
+	"gorm.io/gorm"
 )
 
 func InsertUser(user *model.User) (*model.User, error) {
@@ -15,8 +19,96 @@ func GetUserByUsername(username string) (*model.User, error) {
 	err := mysql.DB.Where("username = ?", username).First(user).Error
 	return user, err
 }
+func UpdatePassword(id int64, passwordHash string) error {
+	return mysql.DB.Model(&model.User{}).
+		Where("id = ?", id).
+		Update("password", passwordHash).
+		Error
+}
+
 func GetUserByEmail(email string) (*model.User, error) {
 	user := &model.User{}
 	err := mysql.DB.Where("email = ?", email).First(user).Error
 	return user, err
 }
+
+func GetUserByID(id int64) (*model.User, error) {
+	user := &model.User{}
+	err := mysql.DB.Where("id = ?", id).First(user).Error
+	return user, err
+}
+
+// UpdateTOTP 更新两步验证密钥与开启状态
+func UpdateTOTP(id int64, secret string, enabled bool) error {
+	return mysql.DB.Model(&model.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"totp_secret":  secret,
+			"totp_enabled": enabled,
+		}).
+		Error
+}
+
+// FindUsers 按用户名、邮箱或昵称模糊查询用户，返回当前页和总数
+func FindUsers(keyword string, offset, limit int) ([]*model.User, int64, error) {
+	query := mysql.DB.Model(&model.User{})
+	if keyword != "" {
+		like := "%" + keyword + "%"
+		query = query.Where("username LIKE ? OR email LIKE ? OR name LIKE ?", like, like, like)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var users []*model.User
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&users).Error
+	return users, total, err
+}
+
+func UpdateUser(id int64, fields map[string]interface{}) error {
+	return mysql.DB.Model(&model.User{}).
+		Where("id = ?", id).
+		Updates(fields).
+		Error
+}
+
+// DeleteAccount 注销账号：删除会话、消息、API Key、外部身份和恢复码，
+// 匿名化用户信息（释放用户名和邮箱）后软删除
+func DeleteAccount(u *model.User) error {
+	return mysql.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_name = ?", u.Username).Delete(&model.Message{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("user_name = ?", u.Username).Delete(&model.Session{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("user_id = ?", u.ID).Delete(&model.APIKey{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", u.ID).Delete(&model.UserIdentity{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", u.ID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if err := knowledge.DeleteUserKnowledge(tx, u.ID); err != nil {
+			return err
+		}
+		placeholder := fmt.Sprintf("deleted-%d", u.ID)
+		err := tx.Model(&model.User{}).Where("id = ?", u.ID).Updates(map[string]interface{}{
+			"name":          "已注销用户",
+			"avatar":        "",
+			"email":         placeholder + "@deleted.invalid",
+			"username":      placeholder,
+			"password":      "",
+			"totp_secret":   "",
+			"totp_enabled":  false,
+			"default_model": "",
+			"language":      "",
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Delete(&model.User{}, u.ID).Error
+	})
+}
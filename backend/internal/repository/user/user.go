@@ -3,7 +3,6 @@ package user
 import (
 	"context"
 	"wsai/backend/internal/model"
-	"wsai/backend/utils"
 
 	"gorm.io/gorm"
 )
@@ -30,12 +29,14 @@ func IsExistUserWithEmail(email string) (bool, *model.User) {
 	}
 	return true, user
 }
-func Register(username, email, password string) (*model.User, bool) {
+
+// Register 写入新用户，password 为已计算好的密码哈希
+func Register(username, email, passwordHash string) (*model.User, bool) {
 	if user, err := InsertUser(&model.User{
 		Email:    email,
 		Name:     username,
 		Username: username,
-		Password: utils.MD5(password),
+		Password: passwordHash,
 	}); err != nil {
 		return nil, false
 	} else {
@@ -0,0 +1,28 @@
+package useridentity
+
+import (
+	"time"
+	"wsai/backend/internal/common/mysql"
+	"wsai/backend/internal/model"
+)
+
+func GetIdentity(provider, subject string) (*model.UserIdentity, error) {
+	identity := &model.UserIdentity{}
+	err := mysql.DB.Where("provider = ? AND subject = ?", provider, subject).First(identity).Error
+	return identity, err
+}
+
+func CreateIdentity(identity *model.UserIdentity) (*model.UserIdentity, error) {
+	err := mysql.DB.Create(identity).Error
+	return identity, err
+}
+
+func TouchIdentity(id int64, email string, at time.Time) error {
+	return mysql.DB.Model(&model.UserIdentity{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"email":         email,
+			"last_login_at": at,
+		}).
+		Error
+}
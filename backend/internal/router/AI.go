@@ -2,11 +2,15 @@ package router
 
 import (
 	"wsai/backend/internal/handler/session"
+	"wsai/backend/internal/handler/ws"
 
 	"github.com/gin-gonic/gin"
 )
 
 func AIRouter(r *gin.RouterGroup) {
+	// 聊天 WebSocket：一个连接复用多个会话
+	r.GET("/ws", ws.Chat)
+
 	// 聊天会话资源集合：/chatMessage/sessions
 	sessions := r.Group("/chatMessage/sessions")
 	{
@@ -20,6 +24,10 @@ func AIRouter(r *gin.RouterGroup) {
 	{
 		sessionGroup.POST("/messages/stream", session.SendMessageStream)
 
+		sessionGroup.GET("/messages/stream/:generation_id", session.ResumeMessageStream)
+
 		sessionGroup.GET("/messages", session.GetMessageHistory)
+
+		sessionGroup.PUT("/knowledge-base", session.SetSessionKnowledgeBase)
 	}
 }
@@ -0,0 +1,18 @@
+package router
+
+import (
+	"wsai/backend/internal/handler/admin"
+
+	"github.com/gin-gonic/gin"
+)
+
+func AdminRouter(r *gin.RouterGroup) {
+	r.GET("/users", admin.ListUsers)
+	r.GET("/users/:id", admin.GetUser)
+	r.PATCH("/users/:id", admin.UpdateUser)
+	r.POST("/users/:id/quota/reset", admin.ResetQuota)
+	r.POST("/users/:id/unlock", admin.UnlockUser)
+	r.GET("/users/:id/usage", admin.GetUserUsage)
+	r.GET("/audit-logs", admin.ListAuditLogs)
+	r.GET("/audit-logs/export", admin.ExportAuditLogs)
+}
@@ -0,0 +1,18 @@
+package router
+
+import (
+	"wsai/backend/internal/handler/knowledge"
+	"wsai/backend/internal/middleware/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+func KnowledgeRouter(r *gin.RouterGroup) {
+	r.GET("", knowledge.ListKnowledgeBases)
+	r.POST("", knowledge.CreateKnowledgeBase)
+	r.DELETE("/documents/:id", knowledge.DeleteDocument)
+	r.PATCH("/:id", knowledge.UpdateKnowledgeBase)
+	r.DELETE("/:id", knowledge.DeleteKnowledgeBase)
+	r.GET("/:id/documents", knowledge.ListDocuments)
+	r.POST("/:id/documents", ratelimit.Middleware("document"), knowledge.UploadDocument)
+}
@@ -1,29 +1,60 @@
 package router
 
 import (
+	"wsai/backend/config"
+	"wsai/backend/internal/handler/openai"
+	"wsai/backend/internal/handler/wellknown"
+	"wsai/backend/internal/logger"
+	"wsai/backend/internal/middleware/apikey"
 	"wsai/backend/internal/middleware/cors"
 	"wsai/backend/internal/middleware/jwt"
+	"wsai/backend/internal/middleware/ratelimit"
+	"wsai/backend/internal/model"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 func InitRouter() *gin.Engine {
 	r := gin.Default()
+	// 只信任配置的代理转发的客户端 IP，限流、登录保护都依赖 ClientIP
+	if err := r.SetTrustedProxies(config.C.App.TrustedProxies); err != nil {
+		logger.L().Fatal("trusted_proxies 配置错误", zap.Error(err))
+	}
 	r.Use(cors.Middleware())
+	r.GET("/.well-known/jwks.json", wellknown.JWKS)
 	enterRouter := r.Group("api/v1")
 	{
 		RegisterUserRouter(enterRouter.Group("/user"))
 	}
 	{
 		AIGroup := enterRouter.Group("/AI")
-		AIGroup.Use(jwt.AuthMiddleware())
+		AIGroup.Use(jwt.AuthMiddleware(), jwt.RequireScope(model.ScopeChat), ratelimit.Middleware("chat"))
 		AIRouter(AIGroup)
 	}
 	{
 		ImageGroup := enterRouter.Group("/image")
-		ImageGroup.Use(jwt.AuthMiddleware())
+		ImageGroup.Use(jwt.AuthMiddleware(), jwt.RequireScope(model.ScopeImage), ratelimit.Middleware("image"))
 		ImageRouter(ImageGroup)
 	}
+	{
+		KnowledgeGroup := enterRouter.Group("/knowledge-bases")
+		KnowledgeGroup.Use(jwt.AuthMiddleware(), jwt.RequireScope(model.ScopeKnowledge))
+		KnowledgeRouter(KnowledgeGroup)
+	}
+	{
+		// 管理后台只允许管理员以登录态访问
+		AdminGroup := enterRouter.Group("/admin")
+		AdminGroup.Use(jwt.AuthMiddleware(), jwt.DenyAPIKey(), jwt.RequireRole(model.RoleAdmin))
+		AdminRouter(AdminGroup)
+	}
+	{
+		// OpenAI 兼容接口，使用 API Key 鉴权
+		OpenAIGroup := r.Group("/v1")
+		OpenAIGroup.Use(apikey.OpenAIMiddleware(), ratelimit.Middleware("chat"))
+		OpenAIGroup.GET("/models", openai.ListModels)
+		OpenAIGroup.POST("/chat/completions", openai.ChatCompletions)
+	}
 
 	return r
 }
@@ -1,8 +1,14 @@
 package router
 
 import (
+	"wsai/backend/internal/handler/apikey"
+	"wsai/backend/internal/handler/loginsession"
+	"wsai/backend/internal/handler/twofactor"
+	"wsai/backend/internal/handler/usage"
 	"wsai/backend/internal/handler/user"
 	jwtmiddleware "wsai/backend/internal/middleware/jwt"
+	"wsai/backend/internal/middleware/ratelimit"
+	"wsai/backend/internal/model"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,6 +17,52 @@ func RegisterUserRouter(r *gin.RouterGroup) {
 	r.POST("/users", user.Register)
 	r.POST("/login", user.Login)
 	r.POST("/email-login", user.LoginWithEmail)
-	r.POST("/captcha", user.HandleCaptcha)
+	r.POST("/login/code/send", ratelimit.Middleware("captcha"), user.SendLoginCode)
+	r.POST("/login/code", user.LoginWithCode)
+	r.POST("/login/2fa", ratelimit.Middleware("password"), user.LoginWithTwoFactor)
+	r.GET("/oidc/providers", user.ListOIDCProviders)
+	r.GET("/oidc/:provider/login", user.OIDCLogin)
+	r.GET("/oidc/:provider/callback", user.OIDCCallback)
+	r.POST("/token/refresh", user.RefreshToken)
+	r.GET("/captcha/image", ratelimit.Middleware("captcha_image"), user.GetImageCaptcha)
+	r.POST("/captcha", ratelimit.Middleware("captcha"), user.HandleCaptcha)
 	r.POST("/logout", jwtmiddleware.AuthMiddleware(), user.Logout)
+	r.POST("/password/forgot", ratelimit.Middleware("captcha"), user.ForgotPassword)
+	r.POST("/password/reset", ratelimit.Middleware("password"), user.ResetPassword)
+	r.PUT("/password", jwtmiddleware.AuthMiddleware(), jwtmiddleware.DenyAPIKey(), ratelimit.Middleware("password"), user.ChangePassword)
+	r.GET("/usage", jwtmiddleware.AuthMiddleware(), jwtmiddleware.RequireScope(model.ScopeUsage), usage.GetUserUsage)
+
+	// API Key 管理只允许登录态操作，不能用 API Key 管理 API Key
+	keys := r.Group("/api-keys", jwtmiddleware.AuthMiddleware(), jwtmiddleware.DenyAPIKey())
+	{
+		keys.GET("", apikey.ListAPIKeys)
+		keys.POST("", apikey.CreateAPIKey)
+		keys.PATCH("/:id", apikey.UpdateAPIKey)
+		keys.DELETE("/:id", apikey.RevokeAPIKey)
+	}
+
+	logins := r.Group("/logins", jwtmiddleware.AuthMiddleware(), jwtmiddleware.DenyAPIKey())
+	{
+		logins.GET("", loginsession.ListLogins)
+		logins.DELETE("", loginsession.RevokeAllLogins)
+		logins.DELETE("/:id", loginsession.RevokeLogin)
+	}
+
+	me := r.Group("/me", jwtmiddleware.AuthMiddleware(), jwtmiddleware.DenyAPIKey())
+	{
+		me.GET("", user.GetProfile)
+		me.PATCH("", user.UpdateProfile)
+		me.DELETE("", ratelimit.Middleware("password"), user.DeleteAccount)
+		me.POST("/email", ratelimit.Middleware("captcha"), user.ChangeEmail)
+		me.POST("/email/confirm", ratelimit.Middleware("password"), user.ConfirmEmail)
+	}
+
+	tfa := r.Group("/2fa", jwtmiddleware.AuthMiddleware(), jwtmiddleware.DenyAPIKey())
+	{
+		tfa.GET("", twofactor.GetStatus)
+		tfa.POST("/enroll", twofactor.Enroll)
+		tfa.POST("/activate", ratelimit.Middleware("password"), twofactor.Activate)
+		tfa.POST("/recovery-codes", ratelimit.Middleware("password"), twofactor.RegenerateRecoveryCodes)
+		tfa.DELETE("", ratelimit.Middleware("password"), twofactor.Disable)
+	}
 }
@@ -0,0 +1,186 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"time"
+	"wsai/backend/internal/common/code"
+	"wsai/backend/internal/logger"
+	"wsai/backend/internal/model"
+	"wsai/backend/internal/repository/plan"
+	"wsai/backend/internal/repository/user"
+	"wsai/backend/internal/service/loginguard"
+	"wsai/backend/internal/service/loginsession"
+	"wsai/backend/internal/service/quota"
+	"wsai/backend/internal/service/usage"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// UserPage 用户分页列表
+type UserPage struct {
+	Users    []*model.User `json:"users"`
+	Total    int64         `json:"total"`
+	Page     int           `json:"page"`
+	PageSize int           `json:"page_size"`
+}
+
+// UserDetail 用户详情，附带剩余额度和登录锁定状态
+type UserDetail struct {
+	*model.User
+	Quota       *quota.Remaining `json:"quota,omitempty"`
+	LockedUntil *time.Time       `json:"locked_until,omitempty"` // 密码错误过多被临时锁定时的解锁时间
+}
+
+// UserUpdate 管理员可修改的字段，nil 表示不修改
+type UserUpdate struct {
+	Role     *string
+	Plan     *string
+	Disabled *bool
+}
+
+// ListUsers 分页查询用户
+func ListUsers(keyword string, page, pageSize int) (*UserPage, code.Code) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	pageSize = min(pageSize, maxPageSize)
+
+	users, total, err := user.FindUsers(keyword, (page-1)*pageSize, pageSize)
+	if err != nil {
+		logger.L().Error("user.FindUsers error", zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+	return &UserPage{Users: users, Total: total, Page: page, PageSize: pageSize}, code.CodeSuccess
+}
+
+func getUser(id int64) (*model.User, code.Code) {
+	u, err := user.GetUserByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, code.CodeUserNotExist
+		}
+		logger.L().Error("user.GetUserByID error",
+			zap.Int64("user_id", id),
+			zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+	return u, code.CodeSuccess
+}
+
+// GetUser 查询用户详情和剩余额度
+func GetUser(ctx context.Context, id int64) (*UserDetail, code.Code) {
+	u, code_ := getUser(id)
+	if code_ != code.CodeSuccess {
+		return nil, code_
+	}
+	detail := &UserDetail{User: u}
+	// 额度查询失败不影响查看用户
+	if remaining, code_ := quota.Get(ctx, u.Username); code_ == code.CodeSuccess || code_ == code.CodeQuotaExceeded {
+		detail.Quota = remaining
+	}
+	if until, err := loginguard.LockedUntil(ctx, u.ID); err == nil {
+		detail.LockedUntil = until
+	}
+	return detail, code.CodeSuccess
+}
+
+// UpdateUser 修改用户角色、套餐或禁用状态；角色变更和禁用会吊销该用户的全部登录，
+// 使新的角色立即生效。operatorID 为当前管理员，不能禁用自己或取消自己的管理员角色
+func UpdateUser(ctx context.Context, operatorID int64, id int64, update UserUpdate) (*model.User, code.Code) {
+	u, code_ := getUser(id)
+	if code_ != code.CodeSuccess {
+		return nil, code_
+	}
+
+	fields := map[string]interface{}{}
+	revoke := false
+	if update.Role != nil && *update.Role != u.Role {
+		if !model.IsValidRole(*update.Role) || id == operatorID {
+			return nil, code.CodeInvalidParams
+		}
+		fields["role"] = *update.Role
+		revoke = true
+	}
+	if update.Plan != nil && *update.Plan != u.Plan {
+		if _, err := plan.GetPlanByName(*update.Plan); err != nil {
+			return nil, code.CodeInvalidParams
+		}
+		fields["plan"] = *update.Plan
+	}
+	if update.Disabled != nil && *update.Disabled != u.Disabled {
+		if *update.Disabled && id == operatorID {
+			return nil, code.CodeInvalidParams
+		}
+		fields["disabled"] = *update.Disabled
+		revoke = revoke || *update.Disabled
+	}
+	if len(fields) == 0 {
+		return u, code.CodeSuccess
+	}
+
+	if err := user.UpdateUser(id, fields); err != nil {
+		logger.L().Error("user.UpdateUser error",
+			zap.Int64("user_id", id),
+			zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+	if revoke {
+		if _, code_ := loginsession.RevokeAll(ctx, id, ""); code_ != code.CodeSuccess {
+			return nil, code_
+		}
+	}
+	logger.L().Info("管理员修改用户",
+		zap.Int64("operator_id", operatorID),
+		zap.Int64("user_id", id),
+		zap.Any("fields", fields))
+	return getUser(id)
+}
+
+// ResetQuota 清空用户当天与当月的额度计数
+func ResetQuota(ctx context.Context, id int64) code.Code {
+	u, code_ := getUser(id)
+	if code_ != code.CodeSuccess {
+		return code_
+	}
+	if err := quota.Reset(ctx, u.Username); err != nil {
+		logger.L().Error("quota.Reset error",
+			zap.Int64("user_id", id),
+			zap.Error(err))
+		return code.CodeServerBusy
+	}
+	return code.CodeSuccess
+}
+
+// UnlockUser 解除用户因密码错误过多造成的登录锁定
+func UnlockUser(ctx context.Context, id int64) code.Code {
+	u, code_ := getUser(id)
+	if code_ != code.CodeSuccess {
+		return code_
+	}
+	if err := loginguard.Unlock(ctx, u.ID); err != nil {
+		logger.L().Error("loginguard.Unlock error",
+			zap.Int64("user_id", id),
+			zap.Error(err))
+		return code.CodeServerBusy
+	}
+	return code.CodeSuccess
+}
+
+// GetUserUsage 查询用户最近 days 天的 token 用量
+func GetUserUsage(id int64, days int) (*usage.Report, code.Code) {
+	u, code_ := getUser(id)
+	if code_ != code.CodeSuccess {
+		return nil, code_
+	}
+	return usage.GetUserUsage(u.Username, days)
+}
@@ -0,0 +1,233 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+	"wsai/backend/internal/common/code"
+	"wsai/backend/internal/logger"
+	"wsai/backend/internal/model"
+	"wsai/backend/internal/repository/apikey"
+	"wsai/backend/internal/repository/user"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// KeyPrefix API Key 的固定前缀，便于与 JWT 区分
+	KeyPrefix = "wsai-"
+	// MaxKeysPerUser 每个用户最多持有的 API Key 数量
+	MaxKeysPerUser = 20
+	// lastUsedInterval 最近使用时间的最小更新间隔，避免每次请求都写库
+	lastUsedInterval = time.Minute
+)
+
+// KeyInfo 对外展示的 API Key 信息，不含哈希
+type KeyInfo struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func toKeyInfo(key *model.APIKey) KeyInfo {
+	return KeyInfo{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.ScopeList(),
+		LastUsedAt: key.LastUsedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
+
+// HashKey API Key 本身是高熵随机串，直接做 SHA-256 即可安全存储
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsAPIKey 判断凭证是否是 API Key 格式
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, KeyPrefix)
+}
+
+func generateKey() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return KeyPrefix + hex.EncodeToString(buf), nil
+}
+
+// normalizeScopes 校验并去重权限范围，为空时授予全部权限
+func normalizeScopes(scopes []string) (string, bool) {
+	if len(scopes) == 0 {
+		return strings.Join(model.APIKeyScopes, ","), true
+	}
+	out := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if !slices.Contains(model.APIKeyScopes, scope) {
+			return "", false
+		}
+		if !slices.Contains(out, scope) {
+			out = append(out, scope)
+		}
+	}
+	return strings.Join(out, ","), true
+}
+
+// Create 为用户创建 API Key，返回的明文只此一次
+func Create(userID int64, userName string, name string, scopes []string) (string, *KeyInfo, code.Code) {
+	scopeStr, ok := normalizeScopes(scopes)
+	if !ok {
+		return "", nil, code.CodeInvalidParams
+	}
+	existing, err := apikey.FindUserAPIKeys(userID)
+	if err != nil {
+		logger.L().Error("apikey.FindUserAPIKeys error",
+			zap.String("username", userName),
+			zap.Error(err))
+		return "", nil, code.CodeServerBusy
+	}
+	if len(existing) >= MaxKeysPerUser {
+		return "", nil, code.CodeTooManyAPIKeys
+	}
+
+	plain, err := generateKey()
+	if err != nil {
+		logger.L().Error("generate api key error", zap.Error(err))
+		return "", nil, code.CodeServerBusy
+	}
+	key, err := apikey.CreateAPIKey(&model.APIKey{
+		UserID:   userID,
+		UserName: userName,
+		Name:     strings.TrimSpace(name),
+		Prefix:   plain[:len(KeyPrefix)+6],
+		KeyHash:  HashKey(plain),
+		Scopes:   scopeStr,
+	})
+	if err != nil {
+		logger.L().Error("apikey.CreateAPIKey error",
+			zap.String("username", userName),
+			zap.Error(err))
+		return "", nil, code.CodeServerBusy
+	}
+	info := toKeyInfo(key)
+	return plain, &info, code.CodeSuccess
+}
+
+// List 列出用户未吊销的 API Key
+func List(userID int64) ([]KeyInfo, code.Code) {
+	keys, err := apikey.FindUserAPIKeys(userID)
+	if err != nil {
+		logger.L().Error("apikey.FindUserAPIKeys error",
+			zap.Int64("user_id", userID),
+			zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+	infos := make([]KeyInfo, 0, len(keys))
+	for _, key := range keys {
+		infos = append(infos, toKeyInfo(key))
+	}
+	return infos, code.CodeSuccess
+}
+
+// Update 修改 API Key 的名称或权限范围，nil 表示不修改
+func Update(userID int64, id int64, name *string, scopes []string) (*KeyInfo, code.Code) {
+	key, err := apikey.GetUserAPIKey(userID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, code.CodeRecordNotFound
+		}
+		return nil, code.CodeServerBusy
+	}
+
+	fields := map[string]interface{}{}
+	if name != nil {
+		fields["name"] = strings.TrimSpace(*name)
+	}
+	if scopes != nil {
+		scopeStr, ok := normalizeScopes(scopes)
+		if !ok {
+			return nil, code.CodeInvalidParams
+		}
+		fields["scopes"] = scopeStr
+	}
+	if len(fields) > 0 {
+		if err := apikey.UpdateAPIKey(key, fields); err != nil {
+			logger.L().Error("apikey.UpdateAPIKey error",
+				zap.Int64("api_key_id", id),
+				zap.Error(err))
+			return nil, code.CodeServerBusy
+		}
+	}
+	info := toKeyInfo(key)
+	return &info, code.CodeSuccess
+}
+
+// Revoke 吊销 API Key，立即失效
+func Revoke(userID int64, id int64) code.Code {
+	ok, err := apikey.RevokeAPIKey(userID, id)
+	if err != nil {
+		logger.L().Error("apikey.RevokeAPIKey error",
+			zap.Int64("api_key_id", id),
+			zap.Error(err))
+		return code.CodeServerBusy
+	}
+	if !ok {
+		return code.CodeRecordNotFound
+	}
+	return code.CodeSuccess
+}
+
+// Authenticate 校验 API Key 并更新最近使用时间，已吊销或不存在的返回 CodeInvalidToken，
+// 所属用户被禁用或已注销时返回 CodeUserDisabled
+func Authenticate(plain string) (*model.APIKey, code.Code) {
+	if !IsAPIKey(plain) {
+		return nil, code.CodeInvalidToken
+	}
+	key, err := apikey.GetAPIKeyByHash(HashKey(plain))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, code.CodeInvalidToken
+		}
+		logger.L().Error("apikey.GetAPIKeyByHash error", zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+	// 每次都查所属用户，禁用立即生效，重新启用后 Key 恢复可用
+	owner, err := user.GetUserByID(key.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, code.CodeUserDisabled
+		}
+		logger.L().Error("user.GetUserByID error",
+			zap.Int64("user_id", key.UserID),
+			zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+	if owner.Disabled {
+		return nil, code.CodeUserDisabled
+	}
+
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > lastUsedInterval {
+		key.LastUsedAt = &now
+		go func(id int64) {
+			if err := apikey.TouchAPIKey(id, now); err != nil {
+				logger.L().Warn("apikey.TouchAPIKey error",
+					zap.Int64("api_key_id", id),
+					zap.Error(err))
+			}
+		}(key.ID)
+	}
+	return key, code.CodeSuccess
+}
@@ -0,0 +1,124 @@
+package audit
+
+import (
+	"time"
+	"unicode/utf8"
+	"wsai/backend/internal/common/code"
+	"wsai/backend/internal/logger"
+	"wsai/backend/internal/model"
+	"wsai/backend/internal/repository/auditlog"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Action 审计动作
+type Action string
+
+const (
+	ActionLogin          Action = "login"
+	ActionLogout         Action = "logout"
+	ActionLoginRevoke    Action = "login.revoke"
+	ActionPasswordChange Action = "password.change"
+	ActionPasswordReset  Action = "password.reset"
+	ActionEmailChange    Action = "email.change"
+	ActionAccountDelete  Action = "account.delete"
+	ActionTwoFactorOn    Action = "2fa.enable"
+	ActionTwoFactorOff   Action = "2fa.disable"
+	ActionAPIKeyCreate   Action = "apikey.create"
+	ActionAPIKeyUpdate   Action = "apikey.update"
+	ActionAPIKeyRevoke   Action = "apikey.revoke"
+	ActionAdminUser      Action = "admin.user.update"
+	ActionAdminQuota     Action = "admin.quota.reset"
+	ActionAdminUnlock    Action = "admin.user.unlock"
+	ActionAuditExport    Action = "admin.audit.export"
+)
+
+// 审计对象类型
+const (
+	TargetUser   = "user"
+	TargetLogin  = "login"
+	TargetAPIKey = "api_key"
+)
+
+// Actor 操作者及请求来源
+type Actor struct {
+	UserID    int64
+	Username  string
+	IP        string
+	UserAgent string
+}
+
+// ActorOf 从请求中取当前登录用户与来源，未登录的接口只有 IP 和 User-Agent
+func ActorOf(c *gin.Context) Actor {
+	return Actor{
+		UserID:    c.GetInt64("userID"),
+		Username:  c.GetString("username"),
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
+
+// Entry 一条审计记录
+type Entry struct {
+	Actor
+	Action     Action
+	TargetType string
+	TargetID   string
+	Success    bool
+	Detail     string
+}
+
+// Record 异步写入审计日志，调用方不因审计失败而失败
+func Record(e Entry) {
+	l := &model.AuditLog{
+		Action:     string(e.Action),
+		ActorID:    e.UserID,
+		ActorName:  truncate(e.Username, 100),
+		IP:         truncate(e.IP, 64),
+		UserAgent:  truncate(e.UserAgent, 255),
+		TargetType: e.TargetType,
+		TargetID:   truncate(e.TargetID, 64),
+		Success:    e.Success,
+		Detail:     truncate(e.Detail, 500),
+		CreatedAt:  time.Now(),
+	}
+	if queueReady() {
+		err := enqueue(l)
+		if err == nil {
+			return
+		}
+		logger.L().Warn("审计日志入队失败，改为直接写库",
+			zap.String("action", l.Action),
+			zap.Error(err))
+	}
+	go func() {
+		if err := auditlog.CreateAuditLog(l); err != nil {
+			logger.L().Error("写入审计日志失败",
+				zap.String("action", l.Action),
+				zap.Int64("actor_id", l.ActorID),
+				zap.Error(err))
+		}
+	}()
+}
+
+// RecordResult 记录一次接口操作：操作者取自请求，成败由业务码决定，失败时在 Detail 后追加错误信息
+func RecordResult(c *gin.Context, e Entry, code_ code.Code) {
+	e.Actor = ActorOf(c)
+	e.Success = code_ == code.CodeSuccess
+	if !e.Success {
+		if e.Detail != "" {
+			e.Detail += "; "
+		}
+		e.Detail += code_.Msg()
+	}
+	Record(e)
+}
+
+// truncate 按字符截断，避免超出列宽导致写入失败
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
@@ -0,0 +1,99 @@
+package audit
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"wsai/backend/internal/common/code"
+	"wsai/backend/internal/logger"
+	"wsai/backend/internal/model"
+	"wsai/backend/internal/repository/auditlog"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+	// MaxExportRows 单次导出的最大行数，需要更多时请缩小时间范围分批导出
+	MaxExportRows = 100000
+)
+
+// Filter 查询条件
+type Filter = auditlog.Filter
+
+// Page 审计日志分页结果
+type Page struct {
+	Logs     []*model.AuditLog `json:"logs"`
+	Total    int64             `json:"total"`
+	Page     int               `json:"page"`
+	PageSize int               `json:"page_size"`
+}
+
+// List 按条件分页查询审计日志，最新的在前
+func List(f Filter, page, pageSize int) (*Page, code.Code) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	pageSize = min(pageSize, maxPageSize)
+
+	logs, total, err := auditlog.FindAuditLogs(f, (page-1)*pageSize, pageSize)
+	if err != nil {
+		logger.L().Error("auditlog.FindAuditLogs error", zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+	return &Page{Logs: logs, Total: total, Page: page, PageSize: pageSize}, code.CodeSuccess
+}
+
+var csvHeader = []string{"id", "created_at", "action", "success", "actor_id", "actor_name", "ip", "user_agent", "target_type", "target_id", "detail"}
+
+// csvSafe 以公式字符开头的字段前加单引号，防止在表格软件中被当作公式执行
+func csvSafe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// ExportCSV 按条件把审计日志以 CSV 写入 w，最多 MaxExportRows 行
+func ExportCSV(w io.Writer, f Filter) error {
+	// UTF-8 BOM，便于 Excel 正确识别中文
+	if _, err := io.WriteString(w, "\xEF\xBB\xBF"); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	err := auditlog.EachAuditLog(f, MaxExportRows, func(logs []*model.AuditLog) error {
+		for _, l := range logs {
+			if err := cw.Write([]string{
+				strconv.FormatInt(l.ID, 10),
+				l.CreatedAt.Format(time.RFC3339),
+				l.Action,
+				strconv.FormatBool(l.Success),
+				strconv.FormatInt(l.ActorID, 10),
+				csvSafe(l.ActorName),
+				l.IP,
+				csvSafe(l.UserAgent),
+				l.TargetType,
+				csvSafe(l.TargetID),
+				csvSafe(l.Detail),
+			}); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
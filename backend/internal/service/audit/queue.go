@@ -0,0 +1,112 @@
+package audit
+
+import (
+	"encoding/json"
+	"time"
+	"wsai/backend/config"
+	"wsai/backend/internal/common/rabbitmq"
+	"wsai/backend/internal/logger"
+	"wsai/backend/internal/model"
+	"wsai/backend/internal/repository/auditlog"
+
+	"github.com/streadway/amqp"
+	"go.uber.org/zap"
+)
+
+// 审计队列：Audit 为待写库队列；写库失败的记录带上重试次数投递到 Audit.retry，
+// 在其中等待 RetryDelay 后经死信回到 Audit；重试次数用完或消息无法解析时进入 Audit.dead 留待排查。
+const (
+	queueName      = "Audit"
+	retryQueueName = "Audit.retry"
+	deadQueueName  = "Audit.dead"
+)
+
+var (
+	auditQueue    *rabbitmq.RabbitMQ
+	retryQueue    *rabbitmq.RabbitMQ
+	deadQueue     *rabbitmq.RabbitMQ
+	auditConsumer *rabbitmq.RabbitMQ
+)
+
+// job 队列中的一条审计记录，Attempts 为已失败的写库次数
+type job struct {
+	model.AuditLog
+	Attempts int `json:"attempts,omitempty"`
+}
+
+// InitQueue 声明审计队列并启动消费者，需在 RabbitMQ 初始化之后调用
+func InitQueue() {
+	delay, err := time.ParseDuration(config.C.AuditConfig.RetryDelay)
+	if err != nil || delay <= 0 {
+		delay = 30 * time.Second
+	}
+
+	auditQueue = rabbitmq.NewWorkRabbitMQ(queueName)
+	retryQueue = rabbitmq.NewWorkRabbitMQWithArgs(retryQueueName, amqp.Table{
+		"x-message-ttl":             int32(delay.Milliseconds()),
+		"x-dead-letter-exchange":    "",
+		"x-dead-letter-routing-key": queueName,
+	})
+	deadQueue = rabbitmq.NewWorkRabbitMQ(deadQueueName)
+	auditConsumer = rabbitmq.NewWorkRabbitMQ(queueName)
+	go auditConsumer.ConsumeWork(processAuditDelivery)
+}
+
+// DestroyQueue 关闭审计队列使用的 channel
+func DestroyQueue() {
+	for _, q := range []*rabbitmq.RabbitMQ{auditQueue, retryQueue, deadQueue, auditConsumer} {
+		if q != nil {
+			q.Destroy()
+		}
+	}
+}
+
+func queueReady() bool {
+	return auditQueue != nil
+}
+
+func enqueue(l *model.AuditLog) error {
+	data, err := json.Marshal(job{AuditLog: *l})
+	if err != nil {
+		return err
+	}
+	return auditQueue.PublishWork(data)
+}
+
+// processAuditDelivery 写入一条队列中的审计记录；失败时转入重试或死信队列后确认原消息，
+// 只有转投也失败时才返回错误让消息重新入队
+func processAuditDelivery(msg *amqp.Delivery) error {
+	var j job
+	if err := json.Unmarshal(msg.Body, &j); err != nil {
+		logger.L().Error("审计消息解析失败，转入死信队列",
+			zap.Error(err),
+			zap.Uint64("delivery_tag", msg.DeliveryTag))
+		return deadQueue.PublishWork(msg.Body)
+	}
+
+	l := j.AuditLog
+	l.ID = 0
+	err := auditlog.CreateAuditLog(&l)
+	if err == nil {
+		return nil
+	}
+
+	j.Attempts++
+	data, marshalErr := json.Marshal(j)
+	if marshalErr != nil {
+		return marshalErr
+	}
+	if j.Attempts < config.C.AuditConfig.MaxAttempts {
+		logger.L().Warn("写入审计日志失败，稍后重试",
+			zap.String("action", j.Action),
+			zap.Int("attempts", j.Attempts),
+			zap.Error(err))
+		return retryQueue.PublishWork(data)
+	}
+	logger.L().Error("审计日志多次写入失败，转入死信队列",
+		zap.String("action", j.Action),
+		zap.Int64("actor_id", j.ActorID),
+		zap.Int("attempts", j.Attempts),
+		zap.Error(err))
+	return deadQueue.PublishWork(data)
+}
@@ -2,48 +2,212 @@ package captcha
 
 import (
 	"context"
-	"log"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
 	"strings"
 	"time"
+	"wsai/backend/config"
 	redis2 "wsai/backend/internal/common/redis"
 
 	"github.com/redis/go-redis/v9"
 )
 
-func CaptchaKey(email string) string {
-	const prefix = "captcha:"
-	return prefix + email
+// Purpose 验证码用途，不同用途的验证码互不通用
+type Purpose string
+
+const (
+	PurposeRegister    Purpose = "register"
+	PurposeLogin       Purpose = "login"
+	PurposeReset       Purpose = "reset"
+	PurposeEmailChange Purpose = "email-change"
+)
+
+// ttl 各用途验证码的有效期
+var ttl = map[Purpose]time.Duration{
+	PurposeRegister:    5 * time.Minute,
+	PurposeLogin:       5 * time.Minute,
+	PurposeReset:       10 * time.Minute,
+	PurposeEmailChange: 10 * time.Minute,
 }
-func SetCaptchaForEmail(ctx context.Context, email, captcha string) error {
+
+// TTL 返回该用途验证码的有效期
+func TTL(p Purpose) time.Duration {
+	return ttl[p]
+}
+
+var (
+	ErrCooldown   = errors.New("验证码发送过于频繁")
+	ErrDailyLimit = errors.New("验证码今日发送次数已达上限")
+)
+
+// Target 验证码的接收对象；Scope 进一步区分同一邮箱，如更换邮箱时绑定的用户 ID
+type Target struct {
+	Purpose Purpose
+	Email   string
+	Scope   string
+}
+
+func (t Target) key() string {
+	key := "captcha:" + string(t.Purpose) + ":"
+	if t.Scope != "" {
+		key += t.Scope + ":"
+	}
+	return key + strings.ToLower(strings.TrimSpace(t.Email))
+}
+
+type limits struct {
+	cooldown      time.Duration
+	emailDailyCap int
+	ipDailyCap    int
+	maxAttempts   int
+}
+
+func load() limits {
+	c := config.C.CaptchaConfig
+	l := limits{
+		cooldown:      time.Minute,
+		emailDailyCap: c.EmailDailyLimit,
+		ipDailyCap:    c.IPDailyLimit,
+		maxAttempts:   c.MaxAttempts,
+	}
+	if d, err := time.ParseDuration(c.Cooldown); err == nil && d >= 0 {
+		l.cooldown = d
+	}
+	if l.maxAttempts <= 0 {
+		l.maxAttempts = 5
+	}
+	return l
+}
+
+// issueScript 检查冷却时间和每日上限，通过后占用冷却并计数；上限为 0 表示不限制
+// KEYS: 冷却 key、邮箱当日计数、IP 当日计数
+// ARGV: 冷却毫秒数、邮箱上限、IP 上限
+var issueScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return -1
+end
+local emailCap = tonumber(ARGV[2])
+local ipCap = tonumber(ARGV[3])
+if emailCap > 0 and tonumber(redis.call("GET", KEYS[2]) or "0") >= emailCap then
+	return -2
+end
+if ipCap > 0 and tonumber(redis.call("GET", KEYS[3]) or "0") >= ipCap then
+	return -2
+end
+if tonumber(ARGV[1]) > 0 then
+	redis.call("SET", KEYS[1], 1, "PX", ARGV[1])
+end
+for i = 2, 3 do
+	if redis.call("INCR", KEYS[i]) == 1 then
+		redis.call("EXPIRE", KEYS[i], 86400)
+	end
+end
+return 0
+`)
+
+// failScript 记一次错误，达到次数上限后作废验证码
+var failScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+local n = redis.call("HINCRBY", KEYS[1], "attempts", 1)
+if n >= tonumber(ARGV[1]) then
+	redis.call("DEL", KEYS[1])
+end
+return n
+`)
+
+// consumeScript 比对成功才删除，保证验证码只能使用一次
+var consumeScript = redis.NewScript(`
+if redis.call("HGET", KEYS[1], "code") ~= ARGV[1] then
+	return 0
+end
+redis.call("DEL", KEYS[1])
+return 1
+`)
+
+// Issue 保存验证码，重复发送会覆盖旧的并重新计算错误次数。
+// 同一用途同一邮箱在冷却时间内不能重发，邮箱和 IP 各有每日发送上限
+func Issue(ctx context.Context, t Target, ip, code string) error {
 	if redis2.Rdb == nil {
 		return redis.Nil
 	}
-	key := CaptchaKey(email)
-	expire := 2 * time.Minute
-	if _, ok := ctx.Deadline(); !ok {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-	}
-	return redis2.Rdb.Set(ctx, key, captcha, expire).Err()
-}
-func CheckCaptchaForEmail(ctx context.Context, email, userInput string) (bool, error) {
-	key := CaptchaKey(email)
-	//从 Redis 获取存储的验证码
-	stored, err := redis2.Rdb.Get(ctx, key).Result()
-	if err == nil {
-		if err == redis.Nil {
-			return false, nil
-		}
-		return true, err
-	}
-	//比较用户输入和存储的验证码
-	if strings.TrimSpace(stored) != strings.TrimSpace(userInput) {
+	l := load()
+	email := strings.ToLower(strings.TrimSpace(t.Email))
+	day := time.Now().Format("20060102")
+	keys := []string{
+		"captcha:cooldown:" + string(t.Purpose) + ":" + email,
+		"captcha:daily:email:" + day + ":" + email,
+		"captcha:daily:ip:" + day + ":" + ip,
+	}
+	ipCap := l.ipDailyCap
+	if ip == "" {
+		ipCap = 0
+	}
+	n, err := issueScript.Run(ctx, redis2.Rdb, keys,
+		l.cooldown.Milliseconds(), l.emailDailyCap, ipCap).Int()
+	if err != nil {
+		return err
+	}
+	switch n {
+	case -1:
+		return ErrCooldown
+	case -2:
+		return ErrDailyLimit
+	}
+
+	key := t.key()
+	_, err = redis2.Rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		pipe.HSet(ctx, key, "code", code, "attempts", 0)
+		pipe.Expire(ctx, key, ttl[t.Purpose])
+		return nil
+	})
+	return err
+}
+
+// Verify 校验并作废验证码；错误次数达到上限后验证码失效，需重新发送
+func Verify(ctx context.Context, t Target, userInput string) (bool, error) {
+	if redis2.Rdb == nil {
+		return false, redis.Nil
+	}
+	input := strings.TrimSpace(userInput)
+	if input == "" {
 		return false, nil
 	}
-	//验证成功，删除 key
-	if err := redis2.Rdb.Del(ctx, key).Err(); err != nil {
-		log.Printf("删除验证码 key 失败, key=%s, err=%v", key, err)
+	key := t.key()
+	stored, err := redis2.Rdb.HGet(ctx, key, "code").Result()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(stored), []byte(input)) != 1 {
+		return false, failScript.Run(ctx, redis2.Rdb, []string{key}, load().maxAttempts).Err()
+	}
+	ok, err := consumeScript.Run(ctx, redis2.Rdb, []string{key}, stored).Int()
+	if err != nil {
+		return false, err
 	}
-	return true, nil
+	return ok == 1, nil
+}
+
+// NewCode 生成 6 位数字验证码；验证码可用于登录和重置密码，必须使用 crypto/rand
+func NewCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// ScopeUser 把验证码绑定到用户，换一个用户提交无效
+func ScopeUser(userID int64) string {
+	return strconv.FormatInt(userID, 10)
 }
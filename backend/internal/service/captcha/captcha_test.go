@@ -0,0 +1,176 @@
+package captcha
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"wsai/backend/config"
+	redis2 "wsai/backend/internal/common/redis"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// setup 用 miniredis 替换全局 Redis，并按用例设置验证码配置
+func setup(t *testing.T, cooldown string, emailCap, ipCap, maxAttempts int) *miniredis.Miniredis {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	oldRdb, oldConf := redis2.Rdb, config.C
+	redis2.Rdb = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	config.C = new(config.Config)
+	config.C.CaptchaConfig.Cooldown = cooldown
+	config.C.CaptchaConfig.EmailDailyLimit = emailCap
+	config.C.CaptchaConfig.IPDailyLimit = ipCap
+	config.C.CaptchaConfig.MaxAttempts = maxAttempts
+	t.Cleanup(func() {
+		_ = redis2.Rdb.Close()
+		redis2.Rdb, config.C = oldRdb, oldConf
+	})
+	return mr
+}
+
+func mustVerify(t *testing.T, target Target, input string, want bool) {
+	t.Helper()
+	ok, err := Verify(context.Background(), target, input)
+	if err != nil {
+		t.Fatalf("Verify(%q) error: %v", input, err)
+	}
+	if ok != want {
+		t.Fatalf("Verify(%q) = %v, want %v", input, ok, want)
+	}
+}
+
+func TestIssueCooldown(t *testing.T) {
+	mr := setup(t, "1m", 0, 0, 5)
+	ctx := context.Background()
+	target := Target{Purpose: PurposeRegister, Email: "a@example.com"}
+
+	if err := Issue(ctx, target, "1.1.1.1", "111111"); err != nil {
+		t.Fatalf("first issue: %v", err)
+	}
+	if err := Issue(ctx, target, "1.1.1.1", "222222"); !errors.Is(err, ErrCooldown) {
+		t.Fatalf("resend within cooldown: got %v, want ErrCooldown", err)
+	}
+	// 邮箱大小写和空格不能绕过冷却
+	if err := Issue(ctx, Target{Purpose: PurposeRegister, Email: " A@Example.com "}, "1.1.1.1", "222222"); !errors.Is(err, ErrCooldown) {
+		t.Fatalf("resend with different case: got %v, want ErrCooldown", err)
+	}
+	// 冷却按用途区分
+	if err := Issue(ctx, Target{Purpose: PurposeReset, Email: "a@example.com"}, "1.1.1.1", "333333"); err != nil {
+		t.Fatalf("issue for another purpose: %v", err)
+	}
+	// 被拒绝的重发不覆盖原验证码
+	mustVerify(t, target, "222222", false)
+
+	mr.FastForward(time.Minute)
+	if err := Issue(ctx, target, "1.1.1.1", "444444"); err != nil {
+		t.Fatalf("resend after cooldown: %v", err)
+	}
+	mustVerify(t, target, "111111", false)
+	mustVerify(t, target, "444444", true)
+}
+
+func TestIssueEmailDailyLimit(t *testing.T) {
+	setup(t, "0s", 2, 0, 5)
+	ctx := context.Background()
+	target := Target{Purpose: PurposeLogin, Email: "a@example.com"}
+
+	for i, ip := range []string{"1.1.1.1", "2.2.2.2"} {
+		if err := Issue(ctx, target, ip, "123456"); err != nil {
+			t.Fatalf("issue %d: %v", i+1, err)
+		}
+	}
+	// 换 IP、换用途都计入同一邮箱的每日次数
+	if err := Issue(ctx, Target{Purpose: PurposeReset, Email: "a@example.com"}, "3.3.3.3", "123456"); !errors.Is(err, ErrDailyLimit) {
+		t.Fatalf("over email cap: got %v, want ErrDailyLimit", err)
+	}
+	if err := Issue(ctx, Target{Purpose: PurposeLogin, Email: "b@example.com"}, "3.3.3.3", "123456"); err != nil {
+		t.Fatalf("other email: %v", err)
+	}
+}
+
+func TestIssueIPDailyLimit(t *testing.T) {
+	setup(t, "0s", 0, 2, 5)
+	ctx := context.Background()
+
+	for i, email := range []string{"a@example.com", "b@example.com"} {
+		if err := Issue(ctx, Target{Purpose: PurposeRegister, Email: email}, "1.1.1.1", "123456"); err != nil {
+			t.Fatalf("issue %d: %v", i+1, err)
+		}
+	}
+	if err := Issue(ctx, Target{Purpose: PurposeRegister, Email: "c@example.com"}, "1.1.1.1", "123456"); !errors.Is(err, ErrDailyLimit) {
+		t.Fatalf("over ip cap: got %v, want ErrDailyLimit", err)
+	}
+	if err := Issue(ctx, Target{Purpose: PurposeRegister, Email: "c@example.com"}, "2.2.2.2", "123456"); err != nil {
+		t.Fatalf("other ip: %v", err)
+	}
+	// 取不到 IP 时不按 IP 限制
+	if err := Issue(ctx, Target{Purpose: PurposeRegister, Email: "d@example.com"}, "", "123456"); err != nil {
+		t.Fatalf("empty ip: %v", err)
+	}
+}
+
+func TestVerifyMaxAttempts(t *testing.T) {
+	setup(t, "0s", 0, 0, 3)
+	ctx := context.Background()
+	target := Target{Purpose: PurposeLogin, Email: "a@example.com"}
+
+	if err := Issue(ctx, target, "1.1.1.1", "123456"); err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	mustVerify(t, target, "000000", false)
+	mustVerify(t, target, "000001", false)
+	mustVerify(t, target, "000002", false)
+	// 达到错误上限后，正确的验证码也已失效
+	mustVerify(t, target, "123456", false)
+
+	// 重新发送后错误次数重新计算
+	if err := Issue(ctx, target, "1.1.1.1", "654321"); err != nil {
+		t.Fatalf("reissue: %v", err)
+	}
+	mustVerify(t, target, "000000", false)
+	mustVerify(t, target, "000001", false)
+	mustVerify(t, target, "654321", true)
+}
+
+func TestVerifyPurposeScoped(t *testing.T) {
+	setup(t, "0s", 0, 0, 5)
+	ctx := context.Background()
+	register := Target{Purpose: PurposeRegister, Email: "a@example.com"}
+
+	if err := Issue(ctx, register, "1.1.1.1", "123456"); err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	mustVerify(t, Target{Purpose: PurposeReset, Email: "a@example.com"}, "123456", false)
+	mustVerify(t, Target{Purpose: PurposeLogin, Email: "a@example.com"}, "123456", false)
+	// 绑定用户的验证码换一个用户无效
+	mustVerify(t, Target{Purpose: PurposeRegister, Email: "a@example.com", Scope: ScopeUser(1)}, "123456", false)
+	mustVerify(t, register, "123456", true)
+}
+
+func TestVerifySingleUse(t *testing.T) {
+	setup(t, "0s", 0, 0, 5)
+	ctx := context.Background()
+	target := Target{Purpose: PurposeEmailChange, Email: "a@example.com", Scope: ScopeUser(7)}
+
+	if err := Issue(ctx, target, "1.1.1.1", "123456"); err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	mustVerify(t, Target{Purpose: PurposeEmailChange, Email: "a@example.com", Scope: ScopeUser(8)}, "123456", false)
+	mustVerify(t, target, "123456", true)
+	mustVerify(t, target, "123456", false)
+}
+
+func TestNewCode(t *testing.T) {
+	for range 100 {
+		c, err := NewCode()
+		if err != nil {
+			t.Fatalf("NewCode error: %v", err)
+		}
+		if len(c) != 6 || strings.Trim(c, "0123456789") != "" {
+			t.Fatalf("NewCode() = %q, want 6 digits", c)
+		}
+	}
+}
@@ -1,29 +1,63 @@
 package email
 
 import (
-	"fmt"
-	"wsai/backend/config"
+	"context"
+	"wsai/backend/internal/logger"
 
-	"gopkg.in/gomail.v2"
+	"go.uber.org/zap"
 )
 
+// Kind 邮件类型，对应 templates/<locale>/<kind>.html
+type Kind string
+
 const (
-	CodeMsg     = "wsai的验证码如下（为保障信息安全，请勿告诉他人）"
-	UserNameMsg = "wsai的账号如下，请保存好，以登陆账号使用"
+	KindRegisterCode    Kind = "register_code"
+	KindWelcome         Kind = "welcome"
+	KindLoginCode       Kind = "login_code"
+	KindResetCode       Kind = "reset_code"
+	KindEmailChangeCode Kind = "email_change_code"
+	KindEmailChanged    Kind = "email_changed"
+	KindAccountLocked   Kind = "account_locked"
 )
 
-func SendCaptcha(email, code, msg string) error {
-	m := gomail.NewMessage()
-	m.SetHeader("From", config.C.EmailConfig.Email)
-	m.SetHeader("To", email)
-	m.SetHeader("Subject", "来自wsai的信息")
-	m.SetBody("text/plain", msg+" "+code)
+// Data 模板参数，不同类型的邮件只用到其中一部分
+type Data struct {
+	Code     string `json:"code,omitempty"`
+	Minutes  int    `json:"minutes,omitempty"` // 验证码有效分钟数
+	Username string `json:"username,omitempty"`
+	NewEmail string `json:"new_email,omitempty"`
+	Until    string `json:"until,omitempty"`
+}
+
+// Job 一封待发送的邮件，也是投递到 RabbitMQ 的消息体
+type Job struct {
+	To       string `json:"to"`
+	Kind     Kind   `json:"kind"`
+	Locale   string `json:"locale,omitempty"`
+	Data     Data   `json:"data"`
+	Attempts int    `json:"attempts,omitempty"`
+}
+
+// Send 发送一封模板邮件。开启异步时投递到邮件队列立即返回，
+// 队列不可用时退回同步发送；返回的错误只表示这封邮件确定发不出去
+func Send(ctx context.Context, job Job) error {
+	if queueReady() {
+		err := enqueue(job)
+		if err == nil {
+			return nil
+		}
+		logger.L().Warn("邮件入队失败，改为同步发送",
+			zap.String("kind", string(job.Kind)),
+			zap.Error(err))
+	}
+	return deliver(ctx, job)
+}
 
-	d := gomail.NewDialer(config.C.EmailConfig.Host, 587, config.C.EmailConfig.Email, config.C.EmailConfig.Authcode)
-	if err := d.DialAndSend(m); err != nil {
-		fmt.Printf("DialAndSend err %v:\n", err)
+// deliver 渲染模板并交给当前的 Sender
+func deliver(ctx context.Context, job Job) error {
+	m, err := Render(job)
+	if err != nil {
 		return err
 	}
-	fmt.Println("Sending Email Success")
-	return nil
+	return getSender().Send(ctx, m)
 }
@@ -0,0 +1,112 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+	"wsai/backend/config"
+	"wsai/backend/internal/common/rabbitmq"
+	"wsai/backend/internal/logger"
+
+	"github.com/streadway/amqp"
+	"go.uber.org/zap"
+)
+
+// 邮件发件箱：Mail 为待发送队列；发送失败的邮件带上重试次数投递到 Mail.retry，
+// 在其中等待 RetryDelay 后经死信回到 Mail；重试次数用完或消息无法解析时进入 Mail.dead 留待排查。
+const (
+	queueName      = "Mail"
+	retryQueueName = "Mail.retry"
+	deadQueueName  = "Mail.dead"
+)
+
+var (
+	mailQueue    *rabbitmq.RabbitMQ
+	retryQueue   *rabbitmq.RabbitMQ
+	deadQueue    *rabbitmq.RabbitMQ
+	mailConsumer *rabbitmq.RabbitMQ
+)
+
+// InitQueue 声明邮件队列并启动消费者，需在 RabbitMQ 初始化之后调用；未开启异步时不做任何事
+func InitQueue() {
+	c := config.C.EmailConfig
+	if !c.Async {
+		return
+	}
+	delay, err := time.ParseDuration(c.RetryDelay)
+	if err != nil || delay <= 0 {
+		delay = 30 * time.Second
+	}
+
+	mailQueue = rabbitmq.NewWorkRabbitMQ(queueName)
+	retryQueue = rabbitmq.NewWorkRabbitMQWithArgs(retryQueueName, amqp.Table{
+		"x-message-ttl":             int32(delay.Milliseconds()),
+		"x-dead-letter-exchange":    "",
+		"x-dead-letter-routing-key": queueName,
+	})
+	deadQueue = rabbitmq.NewWorkRabbitMQ(deadQueueName)
+	mailConsumer = rabbitmq.NewWorkRabbitMQ(queueName)
+	go mailConsumer.ConsumeWork(processMailDelivery)
+}
+
+// DestroyQueue 关闭邮件队列使用的 channel
+func DestroyQueue() {
+	for _, q := range []*rabbitmq.RabbitMQ{mailQueue, retryQueue, deadQueue, mailConsumer} {
+		if q != nil {
+			q.Destroy()
+		}
+	}
+}
+
+func queueReady() bool {
+	return config.C.EmailConfig.Async && mailQueue != nil
+}
+
+func enqueue(job Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return mailQueue.PublishWork(data)
+}
+
+// processMailDelivery 发送一封队列中的邮件；失败时转入重试或死信队列后确认原消息，
+// 只有转投也失败时才返回错误让消息重新入队
+func processMailDelivery(msg *amqp.Delivery) error {
+	var job Job
+	if err := json.Unmarshal(msg.Body, &job); err != nil {
+		logger.L().Error("邮件消息解析失败，转入死信队列",
+			zap.Error(err),
+			zap.Uint64("delivery_tag", msg.DeliveryTag))
+		return deadQueue.PublishWork(msg.Body)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	err := deliver(ctx, job)
+	if err == nil {
+		logger.L().Debug("邮件发送成功",
+			zap.String("kind", string(job.Kind)),
+			zap.Int("attempts", job.Attempts+1))
+		return nil
+	}
+
+	job.Attempts++
+	data, marshalErr := json.Marshal(job)
+	if marshalErr != nil {
+		return marshalErr
+	}
+	maxAttempts := config.C.EmailConfig.MaxAttempts
+	if job.Attempts < maxAttempts {
+		logger.L().Warn("邮件发送失败，稍后重试",
+			zap.String("kind", string(job.Kind)),
+			zap.Int("attempts", job.Attempts),
+			zap.Error(err))
+		return retryQueue.PublishWork(data)
+	}
+	logger.L().Error("邮件多次发送失败，转入死信队列",
+		zap.String("kind", string(job.Kind)),
+		zap.Int("attempts", job.Attempts),
+		zap.Error(err))
+	return deadQueue.PublishWork(data)
+}
@@ -0,0 +1,120 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"wsai/backend/config"
+	"wsai/backend/internal/logger"
+
+	"go.uber.org/zap"
+	"gopkg.in/gomail.v2"
+)
+
+// Mail 渲染完成的邮件
+type Mail struct {
+	To      string
+	Subject string
+	HTML    string
+}
+
+// Sender 邮件发送方式，可通过 SetSender 替换
+type Sender interface {
+	Send(ctx context.Context, m *Mail) error
+}
+
+var (
+	senderMu sync.RWMutex
+	sender   Sender
+)
+
+// SetSender 替换发送方式，传 nil 恢复为按配置创建
+func SetSender(s Sender) {
+	senderMu.Lock()
+	defer senderMu.Unlock()
+	sender = s
+}
+
+func getSender() Sender {
+	senderMu.RLock()
+	s := sender
+	senderMu.RUnlock()
+	if s != nil {
+		return s
+	}
+
+	senderMu.Lock()
+	defer senderMu.Unlock()
+	if sender == nil {
+		c := config.C.EmailConfig
+		switch c.Driver {
+		case "file":
+			sender = &FileSender{Dir: c.OutboxDir}
+		default:
+			sender = NewSMTPSender()
+		}
+	}
+	return sender
+}
+
+// SMTPSender 通过 SMTP 发送，端口和 SSL 取自配置
+type SMTPSender struct {
+	dialer *gomail.Dialer
+	from   string
+	name   string
+}
+
+func NewSMTPSender() *SMTPSender {
+	c := config.C.EmailConfig
+	port := c.Port
+	if port == 0 {
+		port = 587
+		if c.IsSSL {
+			port = 465
+		}
+	}
+	d := gomail.NewDialer(c.Host, port, c.Email, c.Authcode)
+	// 465 为隐式 TLS，其他端口由 gomail 按服务器能力走 STARTTLS
+	d.SSL = c.IsSSL
+	return &SMTPSender{dialer: d, from: c.Email, name: c.FromName}
+}
+
+func (s *SMTPSender) Send(ctx context.Context, m *Mail) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	msg := gomail.NewMessage()
+	msg.SetAddressHeader("From", s.from, s.name)
+	msg.SetHeader("To", m.To)
+	msg.SetHeader("Subject", m.Subject)
+	msg.SetBody("text/html", m.HTML)
+	return s.dialer.DialAndSend(msg)
+}
+
+// FileSender 把邮件写成 HTML 文件，本地调试时代替真实发信
+type FileSender struct {
+	Dir string
+}
+
+func (s *FileSender) Send(ctx context.Context, m *Mail) error {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.html",
+		time.Now().Format("20060102-150405.000"),
+		strings.NewReplacer("@", "_at_", "/", "_", "\\", "_").Replace(m.To))
+	path := filepath.Join(s.Dir, name)
+	head := fmt.Sprintf("<!-- To: %s -->\n<!-- Subject: %s -->\n", m.To, m.Subject)
+	if err := os.WriteFile(path, []byte(head+m.HTML), 0o644); err != nil {
+		return err
+	}
+	logger.L().Info("邮件已写入文件",
+		zap.String("to", m.To),
+		zap.String("subject", m.Subject),
+		zap.String("path", path))
+	return nil
+}
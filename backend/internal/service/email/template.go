@@ -0,0 +1,80 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// DefaultLocale 用户未设置语言或语言不受支持时使用
+const DefaultLocale = "zh-CN"
+
+// mailTemplate 正文用 html/template 转义；主题是纯文本邮件头，用 text/template 渲染，避免 ' & 等被转成 HTML 实体
+type mailTemplate struct {
+	body    *template.Template
+	subject *texttemplate.Template
+}
+
+var (
+	templatesMu sync.Mutex
+	templates   = map[string]*mailTemplate{}
+)
+
+// normalizeLocale 把 en、en-GB 等归到已有模板的语言
+func normalizeLocale(locale string) string {
+	switch {
+	case strings.HasPrefix(strings.ToLower(locale), "en"):
+		return "en-US"
+	default:
+		return DefaultLocale
+	}
+}
+
+// lookup 每种语言和类型的模板 = 该语言的 layout.html + <kind>.html，首次使用时解析并缓存
+func lookup(locale string, kind Kind) (*mailTemplate, error) {
+	key := locale + "/" + string(kind)
+	templatesMu.Lock()
+	defer templatesMu.Unlock()
+	if t, ok := templates[key]; ok {
+		return t, nil
+	}
+	kindFile := "templates/" + locale + "/" + string(kind) + ".html"
+	body, err := template.ParseFS(templateFS, "templates/"+locale+"/layout.html", kindFile)
+	if err != nil {
+		return nil, err
+	}
+	subject, err := texttemplate.ParseFS(templateFS, kindFile)
+	if err != nil {
+		return nil, err
+	}
+	t := &mailTemplate{body: body, subject: subject}
+	templates[key] = t
+	return t, nil
+}
+
+// Render 按邮件类型和语言渲染主题与正文
+func Render(job Job) (*Mail, error) {
+	t, err := lookup(normalizeLocale(job.Locale), job.Kind)
+	if err != nil {
+		return nil, fmt.Errorf("加载邮件模板 %s 失败: %w", job.Kind, err)
+	}
+	var subject, body bytes.Buffer
+	if err := t.subject.ExecuteTemplate(&subject, "subject", job.Data); err != nil {
+		return nil, err
+	}
+	if err := t.body.ExecuteTemplate(&body, "layout", job.Data); err != nil {
+		return nil, err
+	}
+	return &Mail{
+		To:      job.To,
+		Subject: strings.TrimSpace(subject.String()),
+		HTML:    body.String(),
+	}, nil
+}
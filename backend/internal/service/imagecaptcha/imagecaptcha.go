@@ -0,0 +1,78 @@
+package imagecaptcha
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+	redisclient "wsai/backend/internal/common/redis"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 图形验证码：发送邮箱验证码和注册前需先识别图片中的字符，防止脚本批量触发发信。
+// 答案只保存在 Redis 中，校验一次后无论对错都作废
+const (
+	keyPrefix = "captcha:image:"
+	TTL       = 2 * time.Minute
+	length    = 5
+	// alphabet 去掉了 0/O、1/I/L 等容易混淆的字符
+	alphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+)
+
+// Challenge 返回给前端的图形验证码
+type Challenge struct {
+	ID    string `json:"captcha_id"`
+	Image string `json:"image"` // data:image/png;base64,...
+}
+
+// New 生成一个图形验证码
+func New(ctx context.Context) (*Challenge, error) {
+	if redisclient.Rdb == nil {
+		return nil, errors.New("Redis 客户端未初始化")
+	}
+	buf := make([]byte, 16+length)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	id := hex.EncodeToString(buf[:16])
+	answer := make([]byte, length)
+	for i, b := range buf[16:] {
+		answer[i] = alphabet[int(b)%len(alphabet)]
+	}
+
+	img, err := render(string(answer))
+	if err != nil {
+		return nil, err
+	}
+	if err := redisclient.Rdb.Set(ctx, keyPrefix+id, string(answer), TTL).Err(); err != nil {
+		return nil, err
+	}
+	return &Challenge{
+		ID:    id,
+		Image: "data:image/png;base64," + base64.StdEncoding.EncodeToString(img),
+	}, nil
+}
+
+// Verify 校验并作废图形验证码，不区分大小写
+func Verify(ctx context.Context, id, answer string) (bool, error) {
+	if redisclient.Rdb == nil {
+		return false, errors.New("Redis 客户端未初始化")
+	}
+	answer = strings.ToUpper(strings.TrimSpace(answer))
+	if id == "" || answer == "" {
+		return false, nil
+	}
+	stored, err := redisclient.Rdb.GetDel(ctx, keyPrefix+id).Result()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare([]byte(stored), []byte(answer)) == 1, nil
+}
@@ -0,0 +1,120 @@
+package imagecaptcha
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"math/rand/v2"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	width  = 150
+	height = 44
+)
+
+var (
+	fontOnce sync.Once
+	fontData *opentype.Font
+	fontErr  error
+)
+
+func loadFont() (*opentype.Font, error) {
+	fontOnce.Do(func() {
+		fontData, fontErr = opentype.Parse(gobold.TTF)
+	})
+	return fontData, fontErr
+}
+
+// render 把答案画成 PNG：字符随机大小、颜色和上下偏移，整体做正弦扭曲，再加干扰线和噪点
+func render(text string) ([]byte, error) {
+	f, err := loadFont()
+	if err != nil {
+		return nil, err
+	}
+
+	canvas := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(canvas, canvas.Bounds(), &image.Uniform{C: randColor(225, 250)}, image.Point{}, draw.Src)
+
+	// 每个字符占一格并在格内居中，避免宽字符互相重叠
+	step := (width - 20) / len(text)
+	for i, ch := range text {
+		face, err := opentype.NewFace(f, &opentype.FaceOptions{
+			Size:    float64(24 + rand.IntN(6)),
+			DPI:     72,
+			Hinting: font.HintingFull,
+		})
+		if err != nil {
+			return nil, err
+		}
+		d := &font.Drawer{
+			Dst:  canvas,
+			Src:  &image.Uniform{C: randColor(20, 120)},
+			Face: face,
+		}
+		w := d.MeasureString(string(ch)).Ceil()
+		d.Dot = fixed.P(10+i*step+(step-w)/2+rand.IntN(5)-2, 31+rand.IntN(7)-3)
+		d.DrawString(string(ch))
+		_ = face.Close()
+	}
+
+	out := warp(canvas)
+	for range 3 {
+		line(out, randColor(60, 160))
+	}
+	for range width * height / 25 {
+		out.Set(rand.IntN(width), rand.IntN(height), randColor(60, 200))
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, out); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// warp 按正弦波横纵错位像素，避免字符被直接切分识别
+func warp(src *image.RGBA) *image.RGBA {
+	dst := image.NewRGBA(src.Bounds())
+	ax, ay := 1+rand.Float64()*1.5, 2+rand.Float64()*2
+	px, py := 40+rand.Float64()*20, 50+rand.Float64()*30
+	phase := rand.Float64() * 2 * math.Pi
+	bg := src.At(0, 0)
+	for y := range height {
+		for x := range width {
+			sx := x + int(ax*math.Sin(float64(y)/px*2*math.Pi+phase))
+			sy := y + int(ay*math.Sin(float64(x)/py*2*math.Pi+phase))
+			if sx < 0 || sx >= width || sy < 0 || sy >= height {
+				dst.Set(x, y, bg)
+				continue
+			}
+			dst.Set(x, y, src.At(sx, sy))
+		}
+	}
+	return dst
+}
+
+// line 画一条横穿图片的干扰曲线
+func line(img *image.RGBA, c color.Color) {
+	y0 := float64(rand.IntN(height))
+	amp := 4 + rand.Float64()*6
+	period := 40 + rand.Float64()*60
+	for x := range width {
+		y := int(y0 + amp*math.Sin(float64(x)/period*2*math.Pi))
+		img.Set(x, y, c)
+		img.Set(x, y+1, c)
+	}
+}
+
+func randColor(lo, hi int) color.RGBA {
+	n := func() uint8 { return uint8(lo + rand.IntN(hi-lo)) }
+	return color.RGBA{R: n(), G: n(), B: n(), A: 255}
+}
@@ -0,0 +1,191 @@
+package loginguard
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+	"wsai/backend/config"
+	redisclient "wsai/backend/internal/common/redis"
+	"wsai/backend/internal/logger"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// 密码登录的防暴力破解：按账号和 IP 分别统计失败次数，
+// 账号失败较多时每次重试需等待递增的时间，达到阈值后临时锁定；IP 失败过多时直接拒绝。
+const (
+	accountFailPrefix = "login:fail:uid:"
+	ipFailPrefix      = "login:fail:ip:"
+	lockPrefix        = "login:lock:"
+	delayPrefix       = "login:delay:"
+)
+
+var (
+	ErrAccountLocked = errors.New("账号已临时锁定")
+	ErrThrottled     = errors.New("登录尝试过于频繁")
+	ErrIPBlocked     = errors.New("IP 登录失败次数过多")
+)
+
+// incrScript 计数加一，首次计数时设置过期时间
+var incrScript = redis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+if n == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return n
+`)
+
+type settings struct {
+	window             time.Duration
+	maxAccountFailures int64
+	lockDuration       time.Duration
+	maxIPFailures      int64
+	delayAfter         int64
+	maxDelay           time.Duration
+}
+
+func parseDuration(raw string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(raw); err == nil && d > 0 {
+		return d
+	}
+	return def
+}
+
+func load() (settings, bool) {
+	c := config.C.LoginGuardConfig
+	if !c.Enabled || redisclient.Rdb == nil {
+		return settings{}, false
+	}
+	return settings{
+		window:             parseDuration(c.Window, 15*time.Minute),
+		maxAccountFailures: int64(c.MaxAccountFailures),
+		lockDuration:       parseDuration(c.LockDuration, 15*time.Minute),
+		maxIPFailures:      int64(c.MaxIPFailures),
+		delayAfter:         int64(c.DelayAfter),
+		maxDelay:           parseDuration(c.MaxDelay, 30*time.Second),
+	}, true
+}
+
+func uid(userID int64) string {
+	return strconv.FormatInt(userID, 10)
+}
+
+// Check 校验密码前调用；userID 为 0 表示账号不存在，只检查 IP。
+// Redis 不可用时放行，避免影响正常登录
+func Check(ctx context.Context, userID int64, ip string) error {
+	s, ok := load()
+	if !ok {
+		return nil
+	}
+	keys := []string{ipFailPrefix + ip}
+	if userID != 0 {
+		keys = append(keys, lockPrefix+uid(userID), delayPrefix+uid(userID))
+	}
+	pipe := redisclient.Rdb.Pipeline()
+	ipFails := pipe.Get(ctx, keys[0])
+	var locked, delayed *redis.IntCmd
+	if userID != 0 {
+		locked = pipe.Exists(ctx, keys[1])
+		delayed = pipe.Exists(ctx, keys[2])
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		logger.L().Warn("登录防护检查失败，已放行", zap.Error(err))
+		return nil
+	}
+
+	if n, _ := ipFails.Int64(); s.maxIPFailures > 0 && n >= s.maxIPFailures {
+		return ErrIPBlocked
+	}
+	if userID == 0 {
+		return nil
+	}
+	if locked.Val() > 0 {
+		return ErrAccountLocked
+	}
+	if delayed.Val() > 0 {
+		return ErrThrottled
+	}
+	return nil
+}
+
+// Fail 记录一次密码错误，返回本次是否触发了账号锁定
+func Fail(ctx context.Context, userID int64, ip string) (bool, error) {
+	s, ok := load()
+	if !ok {
+		return false, nil
+	}
+	window := strconv.FormatInt(s.window.Milliseconds(), 10)
+	if err := incrScript.Run(ctx, redisclient.Rdb, []string{ipFailPrefix + ip}, window).Err(); err != nil {
+		return false, err
+	}
+	if userID == 0 {
+		return false, nil
+	}
+
+	n, err := incrScript.Run(ctx, redisclient.Rdb, []string{accountFailPrefix + uid(userID)}, window).Int64()
+	if err != nil {
+		return false, err
+	}
+	if s.maxAccountFailures > 0 && n >= s.maxAccountFailures {
+		// 锁定后重新计数，解锁后仍需再失败同样次数才会再次锁定
+		_, err := redisclient.Rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, lockPrefix+uid(userID), time.Now().Add(s.lockDuration).Unix(), s.lockDuration)
+			pipe.Del(ctx, accountFailPrefix+uid(userID), delayPrefix+uid(userID))
+			return nil
+		})
+		return err == nil, err
+	}
+	if s.delayAfter > 0 && n >= s.delayAfter {
+		return false, redisclient.Rdb.Set(ctx, delayPrefix+uid(userID), 1, delay(n-s.delayAfter, s.maxDelay)).Err()
+	}
+	return false, nil
+}
+
+// delay 第 k 次（从 0 开始）超出免等待次数后的等待时间：1s、2s、4s...，最长 max
+func delay(k int64, max time.Duration) time.Duration {
+	if k >= 30 {
+		return max
+	}
+	return min(time.Duration(1<<k)*time.Second, max)
+}
+
+// Succeed 登录凭证（含两步验证）全部通过后清空账号的失败计数
+func Succeed(ctx context.Context, userID int64) {
+	if _, ok := load(); !ok {
+		return
+	}
+	if err := redisclient.Rdb.Del(ctx, accountFailPrefix+uid(userID), delayPrefix+uid(userID)).Err(); err != nil {
+		logger.L().Warn("清空登录失败计数失败",
+			zap.Int64("user_id", userID),
+			zap.Error(err))
+	}
+}
+
+// LockedUntil 返回账号锁定的解除时间，未锁定返回 nil
+func LockedUntil(ctx context.Context, userID int64) (*time.Time, error) {
+	if redisclient.Rdb == nil {
+		return nil, errors.New("Redis 客户端未初始化")
+	}
+	unix, err := redisclient.Rdb.Get(ctx, lockPrefix+uid(userID)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	t := time.Unix(unix, 0)
+	return &t, nil
+}
+
+// Unlock 解除账号锁定并清空失败计数
+func Unlock(ctx context.Context, userID int64) error {
+	if redisclient.Rdb == nil {
+		return errors.New("Redis 客户端未初始化")
+	}
+	return redisclient.Rdb.Del(ctx,
+		lockPrefix+uid(userID),
+		accountFailPrefix+uid(userID),
+		delayPrefix+uid(userID)).Err()
+}
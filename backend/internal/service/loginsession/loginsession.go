@@ -0,0 +1,233 @@
+package loginsession
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+	"wsai/backend/internal/common/code"
+	redisclient "wsai/backend/internal/common/redis"
+	"wsai/backend/internal/logger"
+	"wsai/backend/internal/model"
+	"wsai/backend/internal/repository/loginsession"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// revokedKeyPrefix 已吊销登录的 jti，JWT 中间件据此拒绝该登录签发的全部 token
+	revokedKeyPrefix = "jwt:blacklist:"
+	// seenKeyPrefix 最近活跃时间的写库节流标记
+	seenKeyPrefix = "jwt:seen:"
+	// lastSeenInterval 最近活跃时间的最小更新间隔，避免每次请求都写库
+	lastSeenInterval = time.Minute
+)
+
+// Client 登录时的客户端信息
+type Client struct {
+	DeviceName string
+	IP         string
+	UserAgent  string
+}
+
+// Info 对外展示的登录信息
+type Info struct {
+	ID         int64     `json:"id"`
+	DeviceName string    `json:"device_name"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	Current    bool      `json:"current"` // 是否是发起本次请求的登录
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// NewJTI 生成登录的唯一标识
+func NewJTI() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+// Start 记录一次新的登录，expiresAt 为 refresh token 的到期时间
+func Start(userID int64, userName string, jti string, client Client, expiresAt time.Time) error {
+	name := strings.TrimSpace(client.DeviceName)
+	if name == "" {
+		name = DescribeUserAgent(client.UserAgent)
+	}
+	now := time.Now()
+	_, err := loginsession.CreateLoginSession(&model.LoginSession{
+		UserID:     userID,
+		UserName:   userName,
+		JTI:        jti,
+		DeviceName: truncate(name, 100),
+		IP:         truncate(client.IP, 64),
+		UserAgent:  truncate(client.UserAgent, 255),
+		LastSeenAt: now,
+		ExpiresAt:  expiresAt,
+	})
+	return err
+}
+
+// Touch 更新登录的最近活跃时间，同一登录每分钟最多写一次库
+func Touch(ctx context.Context, jti string) {
+	if redisclient.Rdb != nil {
+		first, err := redisclient.Rdb.SetNX(ctx, seenKeyPrefix+jti, "1", lastSeenInterval).Result()
+		if err != nil || !first {
+			return
+		}
+	}
+	go func() {
+		if err := loginsession.TouchLoginSession(jti, map[string]interface{}{"last_seen_at": time.Now()}); err != nil {
+			logger.L().Warn("loginsession.TouchLoginSession error",
+				zap.String("jti", jti),
+				zap.Error(err))
+		}
+	}()
+}
+
+// Extend refresh token 轮换后顺延登录的到期时间
+func Extend(jti string, expiresAt time.Time) {
+	err := loginsession.TouchLoginSession(jti, map[string]interface{}{
+		"last_seen_at": time.Now(),
+		"expires_at":   expiresAt,
+	})
+	if err != nil {
+		logger.L().Warn("loginsession.TouchLoginSession error",
+			zap.String("jti", jti),
+			zap.Error(err))
+	}
+}
+
+// IsRevoked 登录是否已被吊销
+func IsRevoked(ctx context.Context, jti string) (bool, error) {
+	if redisclient.Rdb == nil {
+		return false, nil
+	}
+	exists, err := redisclient.Rdb.Exists(ctx, revokedKeyPrefix+jti).Result()
+	if err != nil {
+		return false, err
+	}
+	return exists > 0, nil
+}
+
+// List 列出用户当前有效的登录，currentJTI 用于标记发起请求的登录
+func List(userID int64, currentJTI string) ([]Info, code.Code) {
+	sessions, err := loginsession.FindActiveLoginSessions(userID, time.Now())
+	if err != nil {
+		logger.L().Error("loginsession.FindActiveLoginSessions error",
+			zap.Int64("user_id", userID),
+			zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+	infos := make([]Info, 0, len(sessions))
+	for _, s := range sessions {
+		infos = append(infos, Info{
+			ID:         s.ID,
+			DeviceName: s.DeviceName,
+			IP:         s.IP,
+			UserAgent:  s.UserAgent,
+			Current:    s.JTI == currentJTI,
+			LastSeenAt: s.LastSeenAt,
+			ExpiresAt:  s.ExpiresAt,
+			CreatedAt:  s.CreatedAt,
+		})
+	}
+	return infos, code.CodeSuccess
+}
+
+// Revoke 吊销用户的某个登录，该登录的 access/refresh token 立即失效
+func Revoke(ctx context.Context, userID int64, id int64) code.Code {
+	s, err := loginsession.GetUserLoginSession(userID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return code.CodeRecordNotFound
+		}
+		return code.CodeServerBusy
+	}
+	if err := revoke(ctx, []*model.LoginSession{s}); err != nil {
+		logger.L().Error("吊销登录失败",
+			zap.Int64("login_session_id", id),
+			zap.Error(err))
+		return code.CodeServerBusy
+	}
+	return code.CodeSuccess
+}
+
+// RevokeAll 吊销用户除 exceptJTI 外的全部登录，返回吊销数量
+func RevokeAll(ctx context.Context, userID int64, exceptJTI string) (int, code.Code) {
+	sessions, err := loginsession.FindActiveLoginSessions(userID, time.Now())
+	if err != nil {
+		logger.L().Error("loginsession.FindActiveLoginSessions error",
+			zap.Int64("user_id", userID),
+			zap.Error(err))
+		return 0, code.CodeServerBusy
+	}
+	targets := make([]*model.LoginSession, 0, len(sessions))
+	for _, s := range sessions {
+		if s.JTI != exceptJTI {
+			targets = append(targets, s)
+		}
+	}
+	if err := revoke(ctx, targets); err != nil {
+		logger.L().Error("吊销登录失败",
+			zap.Int64("user_id", userID),
+			zap.Error(err))
+		return 0, code.CodeServerBusy
+	}
+	return len(targets), code.CodeSuccess
+}
+
+// RevokeJTI 按 jti 吊销登录，用于退出登录和 refresh token 重放；
+// tokenExpiresAt 为该登录已签发 access token 的最晚过期时间，没有登录记录时吊销标记保留到这个时间
+func RevokeJTI(ctx context.Context, jti string, tokenExpiresAt time.Time) error {
+	s, err := loginsession.GetLoginSessionByJTI(jti)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		// 没有登录记录时仍写入吊销标记，保证 token 失效
+		s = &model.LoginSession{JTI: jti, ExpiresAt: tokenExpiresAt}
+	}
+	return revoke(ctx, []*model.LoginSession{s})
+}
+
+// revoke 先写 Redis 吊销标记（中间件据此拦截），再更新数据库记录
+func revoke(ctx context.Context, sessions []*model.LoginSession) error {
+	if len(sessions) == 0 {
+		return nil
+	}
+	if redisclient.Rdb == nil {
+		return errors.New("Redis 客户端未初始化")
+	}
+	ids := make([]int64, 0, len(sessions))
+	for _, s := range sessions {
+		// 标记保留到该登录签发的 token 全部过期
+		ttl := time.Until(s.ExpiresAt)
+		if ttl < time.Hour {
+			ttl = time.Hour
+		}
+		if err := redisclient.Rdb.Set(ctx, revokedKeyPrefix+s.JTI, "1", ttl).Err(); err != nil {
+			return err
+		}
+		if s.ID != 0 {
+			ids = append(ids, s.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return loginsession.RevokeLoginSessions(ids, time.Now())
+}
@@ -0,0 +1,47 @@
+package loginsession
+
+import "strings"
+
+// DescribeUserAgent 从 User-Agent 粗略推断设备名称，如 "Chrome on Windows"
+func DescribeUserAgent(ua string) string {
+	if ua == "" {
+		return "未知设备"
+	}
+	browser := match(ua, [][2]string{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"curl/", "curl"},
+		{"python-requests", "Python"},
+		{"okhttp", "OkHttp"},
+	})
+	platform := match(ua, [][2]string{
+		{"Windows", "Windows"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Android", "Android"},
+		{"Mac OS X", "macOS"},
+		{"Linux", "Linux"},
+	})
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case browser != "":
+		return browser
+	case platform != "":
+		return platform
+	}
+	return "未知设备"
+}
+
+// match 按顺序返回第一个命中的名称，顺序决定优先级（Edge 的 UA 里也含 Chrome）
+func match(ua string, rules [][2]string) string {
+	for _, rule := range rules {
+		if strings.Contains(ua, rule[0]) {
+			return rule[1]
+		}
+	}
+	return ""
+}
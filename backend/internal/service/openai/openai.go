@@ -0,0 +1,192 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"wsai/backend/internal/ai"
+	"wsai/backend/internal/common/code"
+	"wsai/backend/internal/logger"
+	sessionrepo "wsai/backend/internal/repository/session"
+	"wsai/backend/internal/service/quota"
+	"wsai/backend/internal/service/session"
+
+	"github.com/cloudwego/eino/schema"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type (
+	ChatMessage struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}
+	StreamOptions struct {
+		IncludeUsage bool `json:"include_usage"`
+	}
+	// ChatCompletionRequest OpenAI chat completions 请求，wsai_ 前缀字段为 wsAI 扩展
+	ChatCompletionRequest struct {
+		Model         string         `json:"model" binding:"required"`
+		Messages      []ChatMessage  `json:"messages" binding:"required,min=1"`
+		Stream        bool           `json:"stream"`
+		StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+		User          string         `json:"user,omitempty"`
+		// Persist 为 true 时把本次对话保存为新的 wsAI 会话
+		Persist bool `json:"wsai_persist,omitempty"`
+		// SessionID 续写已有 wsAI 会话，只取 messages 中最后一条用户消息，历史以会话为准
+		SessionID string `json:"wsai_session_id,omitempty"`
+	}
+	// Result 一次补全的结果
+	Result struct {
+		Content   string
+		SessionID string
+		Usage     schema.TokenUsage
+	}
+)
+
+// ListModels 可用的模型，即工厂中注册的模型类型
+func ListModels() []string {
+	return ai.GetGlobalFactory().ModelTypes()
+}
+
+// Complete 执行一次 chat completion，cb 为 nil 时不流式回调。
+// 额度由调用方在写响应前预占，这里负责按实际消耗结算，失败时退回
+func Complete(ctx context.Context, userName string, reservation *quota.Reservation, req *ChatCompletionRequest, cb ai.StreamCallback) (*Result, code.Code) {
+	// 客户端断开后仍要结算预占的额度
+	settleCtx := context.WithoutCancel(ctx)
+	if !slices.Contains(ListModels(), req.Model) {
+		releaseQuota(settleCtx, reservation)
+		return nil, code.AIModelNotFind
+	}
+	if cb == nil {
+		cb = func(string) {}
+	}
+
+	var (
+		result *Result
+		code_  code.Code
+	)
+	if req.Persist || req.SessionID != "" {
+		result, code_ = completeWithSession(ctx, userName, req, cb)
+	} else {
+		result, code_ = completeStateless(ctx, req, cb)
+	}
+	if code_ != code.CodeSuccess {
+		releaseQuota(settleCtx, reservation)
+		return nil, code_
+	}
+
+	if err := quota.Record(settleCtx, reservation, int64(result.Usage.TotalTokens)); err != nil {
+		logger.L().Warn("quota.Record error",
+			zap.String("username", userName),
+			zap.Error(err))
+	}
+	return result, code.CodeSuccess
+}
+
+func releaseQuota(ctx context.Context, reservation *quota.Reservation) {
+	if err := quota.Release(ctx, reservation); err != nil {
+		logger.L().Warn("quota.Release error",
+			zap.String("username", reservation.Username),
+			zap.Error(err))
+	}
+}
+
+// completeStateless 不落库，直接把完整的 messages 交给模型
+func completeStateless(ctx context.Context, req *ChatCompletionRequest, cb ai.StreamCallback) (*Result, code.Code) {
+	messages := make([]*schema.Message, 0, len(req.Messages))
+	for _, m := range req.Messages {
+		role := schema.RoleType(strings.ToLower(m.Role))
+		switch role {
+		case schema.System, schema.User, schema.Assistant:
+		default:
+			return nil, code.CodeInvalidParams
+		}
+		messages = append(messages, &schema.Message{Role: role, Content: m.Content})
+	}
+
+	llm, err := ai.GetGlobalFactory().CreateAIModel(ctx, req.Model, map[string]interface{}{})
+	if err != nil {
+		logger.L().Error("CreateAIModel error",
+			zap.String("model", req.Model),
+			zap.Error(err))
+		return nil, code.AIModelCannotOpen
+	}
+	content, usage, err := llm.StreamResponse(ctx, messages, cb)
+	if err != nil {
+		logger.L().Error("openai facade StreamResponse error",
+			zap.String("model", req.Model),
+			zap.Error(err))
+		return nil, code.AIModelFail
+	}
+	result := &Result{Content: content}
+	if usage != nil {
+		result.Usage = *usage
+	}
+	return result, code.CodeSuccess
+}
+
+// completeWithSession 通过 AIHelper 生成，消息与用量和普通会话一样入库
+func completeWithSession(ctx context.Context, userName string, req *ChatCompletionRequest, cb ai.StreamCallback) (*Result, code.Code) {
+	last := req.Messages[len(req.Messages)-1]
+	if strings.ToLower(last.Role) != string(schema.User) || strings.TrimSpace(last.Content) == "" {
+		return nil, code.CodeInvalidParams
+	}
+
+	sessionID := req.SessionID
+	isNew := sessionID == ""
+	if isNew {
+		newSession, code_ := session.CreateStreamSessionOnly(userName, last.Content)
+		if code_ != code.CodeSuccess {
+			return nil, code_
+		}
+		sessionID = newSession.ID
+	} else {
+		sess, err := sessionrepo.GetSessionByID(sessionID)
+		if err != nil || sess.UserName != userName {
+			if err == nil || errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, code.CodeRecordNotFound
+			}
+			return nil, code.CodeServerBusy
+		}
+		if err := sessionrepo.TouchSession(sessionID); err != nil {
+			logger.L().Warn("session.TouchSession error",
+				zap.String("sessionId", sessionID),
+				zap.Error(err))
+		}
+	}
+
+	helper, err := ai.GetGlobalManager().GetOrCreateAIHelper(userName, sessionID, req.Model, map[string]interface{}{})
+	if err != nil {
+		logger.L().Error("GetOrCreateAIHelper error",
+			zap.String("sessionId", sessionID),
+			zap.String("model", req.Model),
+			zap.Error(err))
+		return nil, code.AIModelCannotOpen
+	}
+	// 新会话：把请求里携带的历史一并保存，保证会话内容与调用方一致
+	if isNew {
+		for _, m := range req.Messages[:len(req.Messages)-1] {
+			role := strings.ToLower(m.Role)
+			if role != string(schema.User) && role != string(schema.Assistant) {
+				continue
+			}
+			helper.AddMessage(m.Content, userName, role == string(schema.User), true)
+		}
+	}
+
+	aiMsg, err := helper.StreamResponse(userName, ctx, cb, last.Content)
+	if err != nil {
+		return nil, code.AIModelFail
+	}
+	return &Result{
+		Content:   aiMsg.Content,
+		SessionID: sessionID,
+		Usage: schema.TokenUsage{
+			PromptTokens:     aiMsg.PromptTokens,
+			CompletionTokens: aiMsg.CompletionTokens,
+			TotalTokens:      aiMsg.TotalTokens,
+		},
+	}, code.CodeSuccess
+}
@@ -0,0 +1,265 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"wsai/backend/internal/common/code"
+	redisclient "wsai/backend/internal/common/redis"
+	"wsai/backend/internal/logger"
+	"wsai/backend/internal/model"
+	"wsai/backend/internal/repository/plan"
+	"wsai/backend/internal/repository/user"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	keyPrefix = "quota:"
+
+	fieldTokens   = "tokens"
+	fieldRequests = "requests"
+
+	// Unlimited 表示该项没有限额
+	Unlimited int64 = -1
+
+	// reservedTokens 预占时按这个数估算本次消耗，Record 时再按实际用量多退少补
+	reservedTokens int64 = 1000
+
+	dailyTTL   = 48 * time.Hour
+	monthlyTTL = 32 * 24 * time.Hour
+)
+
+// Remaining 剩余额度，Unlimited 表示不限制
+type Remaining struct {
+	DailyTokens     int64 `json:"daily_tokens"`
+	MonthlyTokens   int64 `json:"monthly_tokens"`
+	DailyRequests   int64 `json:"daily_requests"`
+	MonthlyRequests int64 `json:"monthly_requests"`
+}
+
+// Exhausted 任意一项额度耗尽即视为超额
+func (r *Remaining) Exhausted() bool {
+	return r.DailyTokens == 0 || r.MonthlyTokens == 0 ||
+		r.DailyRequests == 0 || r.MonthlyRequests == 0
+}
+
+// WriteHeaders 把剩余额度写入响应头，不限制的项不输出
+func (r *Remaining) WriteHeaders(h http.Header) {
+	set := func(name string, v int64) {
+		if v != Unlimited {
+			h.Set(name, strconv.FormatInt(v, 10))
+		}
+	}
+	set("X-Quota-Remaining-Daily-Tokens", r.DailyTokens)
+	set("X-Quota-Remaining-Monthly-Tokens", r.MonthlyTokens)
+	set("X-Quota-Remaining-Daily-Requests", r.DailyRequests)
+	set("X-Quota-Remaining-Monthly-Requests", r.MonthlyRequests)
+}
+
+func dailyKey(username string, t time.Time) string {
+	return keyPrefix + username + ":day:" + t.Format("20060102")
+}
+
+func monthlyKey(username string, t time.Time) string {
+	return keyPrefix + username + ":month:" + t.Format("200601")
+}
+
+// GetUserPlan 获取用户所属套餐，套餐不存在时退回默认套餐
+func GetUserPlan(username string) (*model.Plan, error) {
+	planName := model.DefaultPlan
+	if ok, u := user.IsExistUser(username); ok && u.Plan != "" {
+		planName = u.Plan
+	}
+	p, err := plan.GetPlanByName(planName)
+	if errors.Is(err, gorm.ErrRecordNotFound) && planName != model.DefaultPlan {
+		p, err = plan.GetPlanByName(model.DefaultPlan)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &model.Plan{Name: model.DefaultPlan}, nil
+	}
+	return p, err
+}
+
+// reserveScript 检查额度并预占一次请求和 reservedTokens，保证并发请求不会同时通过检查；
+// 任意一项已用完时不预占。KEYS: 当日、当月计数；ARGV: 当日/当月 token 上限、当日/当月请求上限、预占 token 数、当日/当月过期秒数。
+// 返回 {是否通过, 当日 token, 当月 token, 当日请求, 当月请求}；字段名与 fieldTokens、fieldRequests 一致
+var reserveScript = redis.NewScript(`
+local function used(key, field)
+	return tonumber(redis.call("HGET", key, field) or "0")
+end
+local function full(limit, n)
+	limit = tonumber(limit)
+	return limit > 0 and n >= limit
+end
+local dt, mt = used(KEYS[1], "tokens"), used(KEYS[2], "tokens")
+local dr, mr = used(KEYS[1], "requests"), used(KEYS[2], "requests")
+if full(ARGV[1], dt) or full(ARGV[2], mt) or full(ARGV[3], dr) or full(ARGV[4], mr) then
+	return {0, dt, mt, dr, mr}
+end
+dt = redis.call("HINCRBY", KEYS[1], "tokens", ARGV[5])
+dr = redis.call("HINCRBY", KEYS[1], "requests", 1)
+mt = redis.call("HINCRBY", KEYS[2], "tokens", ARGV[5])
+mr = redis.call("HINCRBY", KEYS[2], "requests", 1)
+redis.call("EXPIRE", KEYS[1], ARGV[6])
+redis.call("EXPIRE", KEYS[2], ARGV[7])
+return {1, dt, mt, dr, mr}
+`)
+
+// adjustScript 按差值修正计数，结果不小于 0。
+// KEYS: 当日、当月计数；ARGV: token 差值、请求差值、当日/当月过期秒数
+var adjustScript = redis.NewScript(`
+for i = 1, 2 do
+	for field, delta in pairs({tokens = ARGV[1], requests = ARGV[2]}) do
+		if tonumber(delta) ~= 0 and redis.call("HINCRBY", KEYS[i], field, delta) < 0 then
+			redis.call("HSET", KEYS[i], field, 0)
+		end
+	end
+	redis.call("EXPIRE", KEYS[i], ARGV[2 + i])
+end
+return 0
+`)
+
+// Reservation 一次预占；结算按预占时所在的日、月记账，跨零点或跨月的生成不会记到下一个周期
+type Reservation struct {
+	Username string
+	At       time.Time
+	// reserved Redis 不可用时放行但没有预占，结算时按实际用量全额记录
+	reserved bool
+}
+
+// Reserve 在调用模型前检查额度并预占本次请求，返回预占记录和预占后的剩余额度，Redis 不可用时放行。
+// 通过后必须以 Record 结算或以 Release 退回
+func Reserve(ctx context.Context, username string) (*Reservation, *Remaining, code.Code) {
+	p, err := GetUserPlan(username)
+	if err != nil {
+		logger.L().Error("quota.GetUserPlan error",
+			zap.String("username", username),
+			zap.Error(err))
+		return nil, nil, code.CodeServerBusy
+	}
+	return reserve(ctx, p, username, time.Now())
+}
+
+func reserve(ctx context.Context, p *model.Plan, username string, at time.Time) (*Reservation, *Remaining, code.Code) {
+	r := &Reservation{Username: username, At: at}
+	if redisclient.Rdb == nil {
+		return r, newRemaining(p, nil, nil), code.CodeSuccess
+	}
+
+	vals, err := reserveScript.Run(ctx, redisclient.Rdb,
+		[]string{dailyKey(username, r.At), monthlyKey(username, r.At)},
+		p.DailyTokens, p.MonthlyTokens, p.DailyRequests, p.MonthlyRequests,
+		reservedTokens, int64(dailyTTL.Seconds()), int64(monthlyTTL.Seconds())).Int64Slice()
+	if err != nil || len(vals) != 5 {
+		logger.L().Warn("预占用户额度失败，本次放行",
+			zap.String("username", username),
+			zap.Error(err))
+		return r, newRemaining(p, nil, nil), code.CodeSuccess
+	}
+
+	remaining := &Remaining{
+		DailyTokens:     remain(p.DailyTokens, vals[1]),
+		MonthlyTokens:   remain(p.MonthlyTokens, vals[2]),
+		DailyRequests:   remain(p.DailyRequests, vals[3]),
+		MonthlyRequests: remain(p.MonthlyRequests, vals[4]),
+	}
+	if vals[0] == 0 {
+		return nil, remaining, code.CodeQuotaExceeded
+	}
+	r.reserved = true
+	return r, remaining, code.CodeSuccess
+}
+
+// Get 查询剩余额度，不占用额度
+func Get(ctx context.Context, username string) (*Remaining, code.Code) {
+	p, err := GetUserPlan(username)
+	if err != nil {
+		logger.L().Error("quota.GetUserPlan error",
+			zap.String("username", username),
+			zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+
+	var day, month map[string]string
+	if redisclient.Rdb != nil {
+		now := time.Now()
+		pipe := redisclient.Rdb.Pipeline()
+		dayCmd := pipe.HGetAll(ctx, dailyKey(username, now))
+		monthCmd := pipe.HGetAll(ctx, monthlyKey(username, now))
+		if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+			logger.L().Warn("读取用户额度失败",
+				zap.String("username", username),
+				zap.Error(err))
+		} else {
+			day, month = dayCmd.Val(), monthCmd.Val()
+		}
+	}
+
+	remaining := newRemaining(p, day, month)
+	if remaining.Exhausted() {
+		return remaining, code.CodeQuotaExceeded
+	}
+	return remaining, code.CodeSuccess
+}
+
+func newRemaining(p *model.Plan, day, month map[string]string) *Remaining {
+	used := func(m map[string]string, field string) int64 {
+		n, _ := strconv.ParseInt(m[field], 10, 64)
+		return n
+	}
+	return &Remaining{
+		DailyTokens:     remain(p.DailyTokens, used(day, fieldTokens)),
+		MonthlyTokens:   remain(p.MonthlyTokens, used(month, fieldTokens)),
+		DailyRequests:   remain(p.DailyRequests, used(day, fieldRequests)),
+		MonthlyRequests: remain(p.MonthlyRequests, used(month, fieldRequests)),
+	}
+}
+
+func remain(limit int64, used int64) int64 {
+	if limit <= 0 {
+		return Unlimited
+	}
+	return max(limit-used, 0)
+}
+
+// Record 按实际消耗的 token 数结算 Reserve 预占的额度
+func Record(ctx context.Context, r *Reservation, tokens int64) error {
+	if r == nil {
+		return nil
+	}
+	if !r.reserved {
+		return adjust(ctx, r, tokens, 1)
+	}
+	return adjust(ctx, r, tokens-reservedTokens, 0)
+}
+
+// Release 模型调用失败时退回 Reserve 预占的额度
+func Release(ctx context.Context, r *Reservation) error {
+	if r == nil || !r.reserved {
+		return nil
+	}
+	return adjust(ctx, r, -reservedTokens, -1)
+}
+
+func adjust(ctx context.Context, r *Reservation, tokens, requests int64) error {
+	if redisclient.Rdb == nil {
+		return nil
+	}
+	return adjustScript.Run(ctx, redisclient.Rdb,
+		[]string{dailyKey(r.Username, r.At), monthlyKey(r.Username, r.At)},
+		tokens, requests, int64(dailyTTL.Seconds()), int64(monthlyTTL.Seconds())).Err()
+}
+
+// Reset 清空用户当天与当月的额度计数
+func Reset(ctx context.Context, username string) error {
+	if redisclient.Rdb == nil {
+		return errors.New("Redis 客户端未初始化")
+	}
+	now := time.Now()
+	return redisclient.Rdb.Del(ctx, dailyKey(username, now), monthlyKey(username, now)).Err()
+}
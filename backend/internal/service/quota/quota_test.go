@@ -0,0 +1,185 @@
+package quota
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+	"wsai/backend/internal/common/code"
+	redisclient "wsai/backend/internal/common/redis"
+	"wsai/backend/internal/model"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// setup 用 miniredis 替换全局 Redis
+func setup(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	old := redisclient.Rdb
+	redisclient.Rdb = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		_ = redisclient.Rdb.Close()
+		redisclient.Rdb = old
+	})
+	return mr
+}
+
+func used(t *testing.T, mr *miniredis.Miniredis, key, field string) string {
+	t.Helper()
+	if !mr.Exists(key) {
+		return "0"
+	}
+	return mr.HGet(key, field)
+}
+
+func TestReserveConcurrent(t *testing.T) {
+	mr := setup(t)
+	p := &model.Plan{DailyRequests: 3}
+	now := time.Now()
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		passed int
+	)
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, code_ := reserve(context.Background(), p, "alice", now); code_ == code.CodeSuccess {
+				mu.Lock()
+				passed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if passed != 3 {
+		t.Fatalf("passed = %d, want 3", passed)
+	}
+	if got := used(t, mr, dailyKey("alice", now), fieldRequests); got != "3" {
+		t.Fatalf("daily requests = %s, want 3", got)
+	}
+}
+
+func TestReserveExceeded(t *testing.T) {
+	setup(t)
+	p := &model.Plan{DailyTokens: 1500, MonthlyRequests: 10}
+	ctx := context.Background()
+	now := time.Now()
+
+	r, remaining, code_ := reserve(ctx, p, "alice", now)
+	if code_ != code.CodeSuccess {
+		t.Fatalf("first reserve: %v", code_)
+	}
+	if remaining.DailyTokens != 500 || remaining.MonthlyRequests != 9 || remaining.DailyRequests != Unlimited {
+		t.Fatalf("remaining = %+v", remaining)
+	}
+	// 预占的 token 尚有剩余时仍可通过
+	if _, _, code_ := reserve(ctx, p, "alice", now); code_ != code.CodeSuccess {
+		t.Fatalf("second reserve: %v", code_)
+	}
+	if _, remaining, code_ := reserve(ctx, p, "alice", now); code_ != code.CodeQuotaExceeded || remaining.DailyTokens != 0 {
+		t.Fatalf("third reserve: code %v, remaining %+v", code_, remaining)
+	}
+	// 结算后按实际用量退回多预占的部分
+	if err := Record(ctx, r, 100); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if _, _, code_ := reserve(ctx, p, "alice", now); code_ != code.CodeSuccess {
+		t.Fatalf("reserve after record: %v", code_)
+	}
+}
+
+func TestRecordAndRelease(t *testing.T) {
+	mr := setup(t)
+	p := &model.Plan{DailyTokens: 100000}
+	ctx := context.Background()
+	now := time.Now()
+	dk := dailyKey("alice", now)
+
+	r1, _, _ := reserve(ctx, p, "alice", now)
+	r2, _, _ := reserve(ctx, p, "alice", now)
+	if err := Record(ctx, r1, 1234); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if err := Release(ctx, r2); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if got := used(t, mr, dk, fieldTokens); got != "1234" {
+		t.Fatalf("daily tokens = %s, want 1234", got)
+	}
+	if got := used(t, mr, dk, fieldRequests); got != "1" {
+		t.Fatalf("daily requests = %s, want 1", got)
+	}
+	if got := used(t, mr, monthlyKey("alice", now), fieldTokens); got != "1234" {
+		t.Fatalf("monthly tokens = %s, want 1234", got)
+	}
+
+	// 计数被重置后再退回，不会变成负数
+	mr.Del(dk)
+	r3, _, _ := reserve(ctx, p, "alice", now)
+	mr.Del(dk)
+	if err := Release(ctx, r3); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if got := used(t, mr, dk, fieldTokens); got != "0" {
+		t.Fatalf("daily tokens after reset = %s, want 0", got)
+	}
+}
+
+func TestSettleInReservedPeriod(t *testing.T) {
+	mr := setup(t)
+	p := &model.Plan{DailyTokens: 100000}
+	ctx := context.Background()
+	// 生成跨过零点和月初，结算仍记在预占时的日、月
+	at := time.Date(2026, 1, 31, 23, 59, 0, 0, time.Local)
+
+	r, _, code_ := reserve(ctx, p, "alice", at)
+	if code_ != code.CodeSuccess {
+		t.Fatalf("reserve: %v", code_)
+	}
+	if err := Record(ctx, r, 10); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if got := used(t, mr, dailyKey("alice", at), fieldTokens); got != "10" {
+		t.Fatalf("reserved day tokens = %s, want 10", got)
+	}
+	if got := used(t, mr, monthlyKey("alice", at), fieldTokens); got != "10" {
+		t.Fatalf("reserved month tokens = %s, want 10", got)
+	}
+	now := time.Now()
+	if mr.Exists(dailyKey("alice", now)) || mr.Exists(monthlyKey("alice", now)) {
+		t.Fatal("settlement touched the current period")
+	}
+
+	released, _, _ := reserve(ctx, p, "alice", at)
+	if err := Release(ctx, released); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if got := used(t, mr, dailyKey("alice", at), fieldRequests); got != "1" {
+		t.Fatalf("reserved day requests = %s, want 1", got)
+	}
+}
+
+func TestRecordWithoutReservation(t *testing.T) {
+	mr := setup(t)
+	ctx := context.Background()
+	// Redis 不可用时放行的请求没有预占，结算时全额记录
+	r := &Reservation{Username: "alice", At: time.Now()}
+	if err := Record(ctx, r, 42); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if err := Release(ctx, r); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	dk := dailyKey("alice", r.At)
+	if got := used(t, mr, dk, fieldTokens); got != "42" {
+		t.Fatalf("daily tokens = %s, want 42", got)
+	}
+	if got := used(t, mr, dk, fieldRequests); got != "1" {
+		t.Fatalf("daily requests = %s, want 1", got)
+	}
+}
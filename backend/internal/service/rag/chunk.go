@@ -0,0 +1,94 @@
+package rag
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var blankLines = regexp.MustCompile(`\n{3,}`)
+
+// splitText 把正文切成长度约为 size 个字符的块，相邻块重叠约 overlap 个字符。
+// 优先在段落边界切分，超长段落再按句子切分，仍然过长的句子按字符硬切
+func splitText(text string, size, overlap int) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = blankLines.ReplaceAllString(text, "\n\n")
+	overlap = min(overlap, size/2)
+
+	var units []string
+	for _, para := range strings.Split(text, "\n\n") {
+		para = strings.TrimSpace(para)
+		if para == "" {
+			continue
+		}
+		if utf8.RuneCountInString(para) <= size {
+			units = append(units, para)
+			continue
+		}
+		for _, sentence := range splitSentences(para) {
+			units = append(units, hardSplit(sentence, size)...)
+		}
+	}
+
+	var chunks []string
+	var current []string
+	length := 0
+	for _, unit := range units {
+		n := utf8.RuneCountInString(unit) + 1 // 含拼接时的换行
+		if length > 0 && length+n > size {
+			chunks = append(chunks, strings.Join(current, "\n"))
+			// 新块以上一块末尾的若干单元开头，保留上下文
+			var carry []string
+			carried := 0
+			for i := len(current) - 1; i >= 0; i-- {
+				m := utf8.RuneCountInString(current[i]) + 1
+				if carried+m > overlap || carried+m+n > size {
+					break
+				}
+				carry = append([]string{current[i]}, carry...)
+				carried += m
+			}
+			current, length = carry, carried
+		}
+		current = append(current, unit)
+		length += n
+	}
+	if length > 0 {
+		chunks = append(chunks, strings.Join(current, "\n"))
+	}
+	return chunks
+}
+
+// splitSentences 按中英文句末标点和换行切分，标点保留在句子末尾
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	for i, r := range text {
+		switch r {
+		case '。', '！', '？', '；', '.', '!', '?', ';', '\n':
+			end := i + utf8.RuneLen(r)
+			if s := strings.TrimSpace(text[start:end]); s != "" {
+				sentences = append(sentences, s)
+			}
+			start = end
+		}
+	}
+	if s := strings.TrimSpace(text[start:]); s != "" {
+		sentences = append(sentences, s)
+	}
+	return sentences
+}
+
+func hardSplit(text string, size int) []string {
+	runes := []rune(text)
+	if len(runes) <= size {
+		return []string{text}
+	}
+	var parts []string
+	for len(runes) > 0 {
+		n := min(size, len(runes))
+		parts = append(parts, string(runes[:n]))
+		runes = runes[n:]
+	}
+	return parts
+}
@@ -0,0 +1,154 @@
+package rag
+
+import (
+	"errors"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"wsai/backend/config"
+	"wsai/backend/internal/common/code"
+	"wsai/backend/internal/logger"
+	"wsai/backend/internal/model"
+	"wsai/backend/internal/repository/knowledge"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// MaxFileSize 单个文档的大小上限（字节）
+func MaxFileSize() int64 {
+	mb := config.C.RAGConfig.MaxFileSizeMB
+	if mb <= 0 {
+		mb = 20
+	}
+	return int64(mb) << 20
+}
+
+// userDir 用户上传文档的保存目录
+func userDir(userID int64) string {
+	return filepath.Join(config.C.RAGConfig.StorageDir, strconv.FormatInt(userID, 10))
+}
+
+// ListDocuments 列出知识库中的文档及处理状态
+func ListDocuments(userID int64, knowledgeBaseID int64) ([]*model.Document, code.Code) {
+	if _, code_ := GetKnowledgeBase(userID, knowledgeBaseID); code_ != code.CodeSuccess {
+		return nil, code_
+	}
+	docs, err := knowledge.FindDocuments(knowledgeBaseID)
+	if err != nil {
+		logger.L().Error("knowledge.FindDocuments error",
+			zap.Int64("knowledge_base_id", knowledgeBaseID),
+			zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+	if docs == nil {
+		docs = []*model.Document{}
+	}
+	return docs, code.CodeSuccess
+}
+
+// UploadDocument 保存上传的文档并投递到后台切块、向量化，返回时文档处于 pending 状态
+func UploadDocument(userID int64, knowledgeBaseID int64, file *multipart.FileHeader) (*model.Document, code.Code) {
+	if _, code_ := GetKnowledgeBase(userID, knowledgeBaseID); code_ != code.CodeSuccess {
+		return nil, code_
+	}
+	filename := truncate(filepath.Base(strings.ReplaceAll(file.Filename, "\\", "/")), 255)
+	ext := strings.ToLower(filepath.Ext(filename))
+	if !supportedExts[ext] {
+		return nil, code.CodeDocumentType
+	}
+	if file.Size > MaxFileSize() {
+		return nil, code.CodeDocumentTooLarge
+	}
+
+	dir := userDir(userID)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		logger.L().Error("创建文档目录失败", zap.String("dir", dir), zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+	path := filepath.Join(dir, uuid.New().String()+ext)
+	if err := saveUpload(file, path); err != nil {
+		removeFile(path)
+		logger.L().Error("保存上传文档失败", zap.String("path", path), zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+
+	doc, err := knowledge.CreateDocument(&model.Document{
+		KnowledgeBaseID: knowledgeBaseID,
+		UserID:          userID,
+		Filename:        filename,
+		Size:            file.Size,
+		StoragePath:     path,
+		Status:          model.DocumentPending,
+	})
+	if err != nil {
+		removeFile(path)
+		logger.L().Error("knowledge.CreateDocument error",
+			zap.Int64("knowledge_base_id", knowledgeBaseID),
+			zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+	enqueue(doc.ID)
+	return doc, code.CodeSuccess
+}
+
+// DeleteDocument 删除文档、切块及原文件
+func DeleteDocument(userID int64, documentID int64) code.Code {
+	doc, err := knowledge.GetUserDocument(userID, documentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return code.CodeRecordNotFound
+		}
+		logger.L().Error("knowledge.GetUserDocument error",
+			zap.Int64("document_id", documentID),
+			zap.Error(err))
+		return code.CodeServerBusy
+	}
+	if err := knowledge.DeleteDocument(doc.ID); err != nil {
+		logger.L().Error("knowledge.DeleteDocument error",
+			zap.Int64("document_id", documentID),
+			zap.Error(err))
+		return code.CodeServerBusy
+	}
+	invalidateIndex(doc.KnowledgeBaseID)
+	removeFile(doc.StoragePath)
+	return code.CodeSuccess
+}
+
+// RemoveUserFiles 删除用户上传的全部原文件，注销账号时使用
+func RemoveUserFiles(userID int64) {
+	dir := userDir(userID)
+	if err := os.RemoveAll(dir); err != nil {
+		logger.L().Warn("删除用户文档目录失败", zap.String("dir", dir), zap.Error(err))
+	}
+}
+
+func saveUpload(file *multipart.FileHeader, path string) error {
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o640)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+func removeFile(path string) {
+	if path == "" {
+		return
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		logger.L().Warn("删除文档文件失败", zap.String("path", path), zap.Error(err))
+	}
+}
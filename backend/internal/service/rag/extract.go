@@ -0,0 +1,163 @@
+package rag
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// maxExtractedSize 解析后正文的上限，防止压缩炸弹或超大 PDF 占满内存
+const maxExtractedSize = 50 << 20
+
+var errEmptyDocument = errors.New("文档中没有可识别的文字")
+
+// supportedExts 支持上传的扩展名
+var supportedExts = map[string]bool{
+	".txt":      true,
+	".md":       true,
+	".markdown": true,
+	".pdf":      true,
+	".docx":     true,
+}
+
+// extractText 按扩展名提取文档正文
+func extractText(path, ext string) (string, error) {
+	var text string
+	var err error
+	switch ext {
+	case ".txt", ".md", ".markdown":
+		text, err = extractPlain(path)
+	case ".pdf":
+		text, err = extractPDF(path)
+	case ".docx":
+		text, err = extractDOCX(path)
+	default:
+		return "", fmt.Errorf("不支持的文档格式 %s", ext)
+	}
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(text) == "" {
+		return "", errEmptyDocument
+	}
+	return text, nil
+}
+
+func extractPlain(path string) (string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	raw = bytes.TrimPrefix(raw, []byte("\xef\xbb\xbf"))
+	if !utf8.Valid(raw) {
+		return "", errors.New("文本文档需为 UTF-8 编码")
+	}
+	return string(raw), nil
+}
+
+// extractPDF 提取 PDF 的文字层；扫描件没有文字层，会得到空文本
+func extractPDF(path string) (text string, err error) {
+	// 解析库遇到损坏的文件可能 panic
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("PDF 解析失败: %v", r)
+		}
+	}()
+	f, r, err := pdf.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var b strings.Builder
+	for i := 1; i <= r.NumPage(); i++ {
+		page := r.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		rows, err := page.GetTextByRow()
+		if err != nil {
+			return "", err
+		}
+		for _, row := range rows {
+			for _, word := range row.Content {
+				b.WriteString(word.S)
+			}
+			b.WriteByte('\n')
+		}
+		b.WriteString("\n")
+		if b.Len() > maxExtractedSize {
+			return "", errors.New("文档内容过大")
+		}
+	}
+	return b.String(), nil
+}
+
+// extractDOCX 读取 word/document.xml 中的段落文字
+func extractDOCX(path string) (string, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return "", err
+	}
+	defer zr.Close()
+
+	var doc *zip.File
+	for _, f := range zr.File {
+		if f.Name == "word/document.xml" {
+			doc = f
+			break
+		}
+	}
+	if doc == nil {
+		return "", errors.New("不是有效的 docx 文档")
+	}
+	rc, err := doc.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	var b strings.Builder
+	dec := xml.NewDecoder(io.LimitReader(rc, maxExtractedSize))
+	inText := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				b.WriteByte('\t')
+			case "br", "cr":
+				b.WriteByte('\n')
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				b.WriteString("\n\n")
+			}
+		case xml.CharData:
+			if inText {
+				b.Write(t)
+			}
+		}
+	}
+	return b.String(), nil
+}
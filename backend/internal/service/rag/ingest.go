@@ -0,0 +1,99 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+	"wsai/backend/config"
+	"wsai/backend/internal/ai"
+	"wsai/backend/internal/logger"
+	"wsai/backend/internal/model"
+	"wsai/backend/internal/repository/knowledge"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ingest 解析文档、切块并向量化后写入切块表；文档已删除或已处理过时直接返回
+func ingest(ctx context.Context, documentID int64) error {
+	doc, err := knowledge.GetDocument(documentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if doc.Status == model.DocumentReady {
+		return nil
+	}
+	if err := knowledge.UpdateDocument(doc.ID, map[string]interface{}{
+		"status": model.DocumentProcessing,
+		"error":  "",
+	}); err != nil {
+		return err
+	}
+
+	chunks, err := buildChunks(ctx, doc)
+	if err != nil {
+		logger.L().Warn("文档处理失败",
+			zap.Int64("document_id", doc.ID),
+			zap.String("filename", doc.Filename),
+			zap.Error(err))
+		return knowledge.UpdateDocument(doc.ID, map[string]interface{}{
+			"status": model.DocumentFailed,
+			"error":  truncate(err.Error(), 500),
+		})
+	}
+	if err := knowledge.ReplaceChunks(doc.ID, chunks); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if err := knowledge.UpdateDocument(doc.ID, map[string]interface{}{"status": model.DocumentReady}); err != nil {
+		return err
+	}
+	invalidateIndex(doc.KnowledgeBaseID)
+	logger.L().Info("文档处理完成",
+		zap.Int64("document_id", doc.ID),
+		zap.Int("chunks", len(chunks)))
+	return nil
+}
+
+func buildChunks(ctx context.Context, doc *model.Document) ([]*model.DocumentChunk, error) {
+	text, err := extractText(doc.StoragePath, strings.ToLower(filepath.Ext(doc.Filename)))
+	if err != nil {
+		return nil, err
+	}
+	c := config.C.RAGConfig
+	parts := splitText(text, max(c.ChunkSize, 100), max(c.ChunkOverlap, 0))
+	if len(parts) == 0 {
+		return nil, errEmptyDocument
+	}
+	vectors, err := ai.Embed(ctx, parts)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make([]*model.DocumentChunk, len(parts))
+	for i, part := range parts {
+		chunks[i] = &model.DocumentChunk{
+			DocumentID:      doc.ID,
+			KnowledgeBaseID: doc.KnowledgeBaseID,
+			Seq:             i,
+			Content:         part,
+			Embedding:       encodeVector(vectors[i]),
+		}
+	}
+	return chunks, nil
+}
+
+// truncate 按字符截断，避免超出列宽
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
@@ -0,0 +1,81 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+	"wsai/backend/internal/common/rabbitmq"
+	"wsai/backend/internal/logger"
+
+	"github.com/streadway/amqp"
+	"go.uber.org/zap"
+)
+
+const queueName = "Document"
+
+// ingestTimeout 单个文档解析、向量化的最长时间
+const ingestTimeout = 10 * time.Minute
+
+var (
+	documentQueue    *rabbitmq.RabbitMQ
+	documentConsumer *rabbitmq.RabbitMQ
+)
+
+// documentJob 待处理的文档
+type documentJob struct {
+	DocumentID int64 `json:"document_id"`
+}
+
+// InitQueue 声明文档处理队列并启动消费者，需在 RabbitMQ 初始化之后调用
+func InitQueue() {
+	documentQueue = rabbitmq.NewWorkRabbitMQ(queueName)
+	documentConsumer = rabbitmq.NewWorkRabbitMQ(queueName)
+	go documentConsumer.ConsumeWork(processDocumentDelivery)
+}
+
+// DestroyQueue 关闭文档队列使用的 channel
+func DestroyQueue() {
+	for _, q := range []*rabbitmq.RabbitMQ{documentQueue, documentConsumer} {
+		if q != nil {
+			q.Destroy()
+		}
+	}
+}
+
+// enqueue 投递文档处理任务；队列不可用时在后台直接处理
+func enqueue(documentID int64) {
+	if documentQueue != nil {
+		data, err := json.Marshal(documentJob{DocumentID: documentID})
+		if err == nil {
+			if err = documentQueue.PublishWork(data); err == nil {
+				return
+			}
+		}
+		logger.L().Warn("文档任务入队失败，改为直接处理",
+			zap.Int64("document_id", documentID),
+			zap.Error(err))
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), ingestTimeout)
+		defer cancel()
+		if err := ingest(ctx, documentID); err != nil {
+			logger.L().Error("处理文档失败",
+				zap.Int64("document_id", documentID),
+				zap.Error(err))
+		}
+	}()
+}
+
+// processDocumentDelivery 只有数据库错误会返回 error 让消息重新入队，文档本身的问题记录为 failed
+func processDocumentDelivery(msg *amqp.Delivery) error {
+	var job documentJob
+	if err := json.Unmarshal(msg.Body, &job); err != nil {
+		logger.L().Error("文档消息解析失败，已丢弃",
+			zap.Error(err),
+			zap.ByteString("body", msg.Body))
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ingestTimeout)
+	defer cancel()
+	return ingest(ctx, job.DocumentID)
+}
@@ -0,0 +1,137 @@
+package rag
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+	"wsai/backend/internal/common/code"
+	"wsai/backend/internal/logger"
+	"wsai/backend/internal/model"
+	"wsai/backend/internal/repository/knowledge"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	maxNameLength        = 100
+	maxDescriptionLength = 500
+)
+
+// KnowledgeBaseUpdate 可修改的字段，nil 表示不修改
+type KnowledgeBaseUpdate struct {
+	Name        *string
+	Description *string
+}
+
+func validName(name string) bool {
+	n := utf8.RuneCountInString(name)
+	return n > 0 && n <= maxNameLength
+}
+
+// CreateKnowledgeBase 新建知识库
+func CreateKnowledgeBase(userID int64, name, description string) (*model.KnowledgeBase, code.Code) {
+	name = strings.TrimSpace(name)
+	if !validName(name) || utf8.RuneCountInString(description) > maxDescriptionLength {
+		return nil, code.CodeInvalidParams
+	}
+	kb, err := knowledge.CreateKnowledgeBase(&model.KnowledgeBase{
+		UserID:      userID,
+		Name:        name,
+		Description: description,
+	})
+	if err != nil {
+		logger.L().Error("knowledge.CreateKnowledgeBase error",
+			zap.Int64("user_id", userID),
+			zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+	return kb, code.CodeSuccess
+}
+
+// ListKnowledgeBases 列出用户的知识库
+func ListKnowledgeBases(userID int64) ([]*model.KnowledgeBase, code.Code) {
+	bases, err := knowledge.FindUserKnowledgeBases(userID)
+	if err != nil {
+		logger.L().Error("knowledge.FindUserKnowledgeBases error",
+			zap.Int64("user_id", userID),
+			zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+	if bases == nil {
+		bases = []*model.KnowledgeBase{}
+	}
+	return bases, code.CodeSuccess
+}
+
+// GetKnowledgeBase 查询用户自己的知识库，不存在或不属于该用户时返回 CodeRecordNotFound
+func GetKnowledgeBase(userID int64, id int64) (*model.KnowledgeBase, code.Code) {
+	kb, err := knowledge.GetUserKnowledgeBase(userID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, code.CodeRecordNotFound
+		}
+		logger.L().Error("knowledge.GetUserKnowledgeBase error",
+			zap.Int64("knowledge_base_id", id),
+			zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+	return kb, code.CodeSuccess
+}
+
+// UpdateKnowledgeBase 修改知识库名称或描述
+func UpdateKnowledgeBase(userID int64, id int64, update KnowledgeBaseUpdate) (*model.KnowledgeBase, code.Code) {
+	kb, code_ := GetKnowledgeBase(userID, id)
+	if code_ != code.CodeSuccess {
+		return nil, code_
+	}
+	fields := map[string]interface{}{}
+	if update.Name != nil {
+		name := strings.TrimSpace(*update.Name)
+		if !validName(name) {
+			return nil, code.CodeInvalidParams
+		}
+		fields["name"] = name
+	}
+	if update.Description != nil {
+		if utf8.RuneCountInString(*update.Description) > maxDescriptionLength {
+			return nil, code.CodeInvalidParams
+		}
+		fields["description"] = *update.Description
+	}
+	if len(fields) == 0 {
+		return kb, code.CodeSuccess
+	}
+	if err := knowledge.UpdateKnowledgeBase(kb, fields); err != nil {
+		logger.L().Error("knowledge.UpdateKnowledgeBase error",
+			zap.Int64("knowledge_base_id", id),
+			zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+	return GetKnowledgeBase(userID, id)
+}
+
+// DeleteKnowledgeBase 删除知识库及其全部文档，已绑定的会话自动解除绑定
+func DeleteKnowledgeBase(userID int64, id int64) code.Code {
+	if _, code_ := GetKnowledgeBase(userID, id); code_ != code.CodeSuccess {
+		return code_
+	}
+	paths, err := knowledge.FindDocumentPaths(id)
+	if err != nil {
+		logger.L().Error("knowledge.FindDocumentPaths error",
+			zap.Int64("knowledge_base_id", id),
+			zap.Error(err))
+		return code.CodeServerBusy
+	}
+	if err := knowledge.DeleteKnowledgeBase(id); err != nil {
+		logger.L().Error("knowledge.DeleteKnowledgeBase error",
+			zap.Int64("knowledge_base_id", id),
+			zap.Error(err))
+		return code.CodeServerBusy
+	}
+	invalidateIndex(id)
+	for _, p := range paths {
+		removeFile(p)
+	}
+	return code.CodeSuccess
+}
@@ -0,0 +1,139 @@
+package rag
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+	"wsai/backend/config"
+	"wsai/backend/internal/ai"
+	"wsai/backend/internal/repository/knowledge"
+)
+
+// indexTTL 内存中向量索引的有效期；本实例内的文档变更会立即失效，
+// 其他实例的变更最迟在 TTL 后生效
+const indexTTL = 5 * time.Minute
+
+type chunkVector struct {
+	id         int64
+	documentID int64
+	vector     []float32
+}
+
+type vectorIndex struct {
+	chunks   []chunkVector
+	loadedAt time.Time
+}
+
+var (
+	indexes   = make(map[int64]*vectorIndex)
+	indexesMu sync.Mutex
+)
+
+func loadIndex(knowledgeBaseID int64) (*vectorIndex, error) {
+	indexesMu.Lock()
+	idx, ok := indexes[knowledgeBaseID]
+	indexesMu.Unlock()
+	if ok && time.Since(idx.loadedAt) < indexTTL {
+		return idx, nil
+	}
+
+	rows, err := knowledge.FindChunkVectors(knowledgeBaseID)
+	if err != nil {
+		return nil, err
+	}
+	idx = &vectorIndex{chunks: make([]chunkVector, len(rows)), loadedAt: time.Now()}
+	for i, row := range rows {
+		idx.chunks[i] = chunkVector{id: row.ID, documentID: row.DocumentID, vector: decodeVector(row.Embedding)}
+	}
+	indexesMu.Lock()
+	indexes[knowledgeBaseID] = idx
+	indexesMu.Unlock()
+	return idx, nil
+}
+
+// invalidateIndex 知识库中的文档变化后丢弃缓存的索引
+func invalidateIndex(knowledgeBaseID int64) {
+	indexesMu.Lock()
+	delete(indexes, knowledgeBaseID)
+	indexesMu.Unlock()
+}
+
+// Retriever 在一个知识库中按向量相似度检索，实现 ai.Retriever
+type Retriever struct {
+	knowledgeBaseID int64
+}
+
+func NewRetriever(knowledgeBaseID int64) *Retriever {
+	return &Retriever{knowledgeBaseID: knowledgeBaseID}
+}
+
+type scored struct {
+	chunk *chunkVector
+	score float64
+}
+
+// Retrieve 返回与 query 最相似的至多 top_k 个切块，相似度低于 min_score 的不返回
+func (r *Retriever) Retrieve(ctx context.Context, query string) ([]ai.Reference, error) {
+	idx, err := loadIndex(r.knowledgeBaseID)
+	if err != nil || len(idx.chunks) == 0 {
+		return nil, err
+	}
+	vectors, err := ai.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+	q := decodeVector(encodeVector(vectors[0]))
+
+	c := config.C.RAGConfig
+	topK := c.TopK
+	if topK <= 0 {
+		topK = 5
+	}
+	var hits []scored
+	for i := range idx.chunks {
+		if s := dot(q, idx.chunks[i].vector); s >= c.MinScore {
+			hits = append(hits, scored{chunk: &idx.chunks[i], score: s})
+		}
+	}
+	if len(hits) == 0 {
+		return nil, nil
+	}
+	sort.Slice(hits, func(i, j int) bool { return hits[i].score > hits[j].score })
+	hits = hits[:min(topK, len(hits))]
+
+	ids := make([]int64, len(hits))
+	docIDs := make([]int64, len(hits))
+	for i, h := range hits {
+		ids[i], docIDs[i] = h.chunk.id, h.chunk.documentID
+	}
+	chunks, err := knowledge.GetChunks(ids)
+	if err != nil {
+		return nil, err
+	}
+	contents := make(map[int64]string, len(chunks))
+	for _, ch := range chunks {
+		contents[ch.ID] = ch.Content
+	}
+	names, err := knowledge.GetDocumentNames(docIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make([]ai.Reference, 0, len(hits))
+	for _, h := range hits {
+		content, ok := contents[h.chunk.id]
+		if !ok {
+			continue // 文档刚被删除
+		}
+		refs = append(refs, ai.Reference{
+			Index:      len(refs) + 1,
+			DocumentID: h.chunk.documentID,
+			ChunkID:    h.chunk.id,
+			Source:     names[h.chunk.documentID],
+			Content:    content,
+			Score:      h.score,
+		})
+	}
+	return refs, nil
+}
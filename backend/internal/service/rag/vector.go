@@ -0,0 +1,43 @@
+package rag
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// encodeVector 归一化后按 float32 小端序编码，归一化后余弦相似度即为点积
+func encodeVector(v []float64) []byte {
+	var norm float64
+	for _, x := range v {
+		norm += x * x
+	}
+	norm = math.Sqrt(norm)
+	if norm == 0 {
+		norm = 1
+	}
+	buf := make([]byte, 4*len(v))
+	for i, x := range v {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(float32(x/norm)))
+	}
+	return buf
+}
+
+func decodeVector(buf []byte) []float32 {
+	v := make([]float32, len(buf)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return v
+}
+
+// dot 两个归一化向量的点积；维度不一致（更换过向量模型）时返回 -1
+func dot(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return -1
+	}
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return float64(sum)
+}
@@ -0,0 +1,164 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+	"wsai/backend/internal/logger"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// generationRetention 生成结束后缓冲区的保留时间，供断线客户端续传
+const generationRetention = 5 * time.Minute
+
+// bufferedEvent 缓冲的事件，ID 在单次生成内从 1 开始递增
+type bufferedEvent struct {
+	ID   int64
+	Type string
+	Data []byte
+}
+
+// Generation 一次进行中的 AI 回复，事件缓冲在内存中，与 HTTP 连接解耦：
+// 客户端断开后生成继续进行，重连时可从 Last-Event-ID 之后回放并继续接收
+type Generation struct {
+	ID        string
+	SessionID string
+	UserName  string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	events []bufferedEvent
+	done   bool
+	// notify 每次有新事件或结束时关闭并替换，用于唤醒等待中的订阅者
+	notify chan struct{}
+}
+
+var (
+	generations   = make(map[string]*Generation)
+	generationsMu sync.RWMutex
+)
+
+// NewGeneration 创建并登记一次生成
+func NewGeneration(userName, sessionID string) *Generation {
+	genCtx, cancel := context.WithCancel(context.Background())
+	g := &Generation{
+		ID:        uuid.New().String(),
+		SessionID: sessionID,
+		UserName:  userName,
+		ctx:       genCtx,
+		cancel:    cancel,
+		notify:    make(chan struct{}),
+	}
+	generationsMu.Lock()
+	generations[g.ID] = g
+	generationsMu.Unlock()
+
+	g.Publish(EventGeneration, GenerationEvent{GenerationID: g.ID, SessionID: sessionID})
+	return g
+}
+
+// GetGeneration 按 ID 查找仍在保留期内的生成
+func GetGeneration(id string) (*Generation, bool) {
+	generationsMu.RLock()
+	defer generationsMu.RUnlock()
+	g, ok := generations[id]
+	return g, ok
+}
+
+// Context 生成使用的上下文，Cancel 后模型调用会被中断
+func (g *Generation) Context() context.Context {
+	return g.ctx
+}
+
+// Cancel 取消生成
+func (g *Generation) Cancel() {
+	g.cancel()
+}
+
+// Canceled 生成是否已被取消
+func (g *Generation) Canceled() bool {
+	return g.ctx.Err() != nil
+}
+
+// Publish 追加一个事件到缓冲区并唤醒订阅者
+func (g *Generation) Publish(event string, data any) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		logger.L().Warn("generation event marshal error",
+			zap.String("generation_id", g.ID),
+			zap.String("event", event),
+			zap.Error(err))
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.done {
+		return
+	}
+	g.events = append(g.events, bufferedEvent{
+		ID:   int64(len(g.events)) + 1,
+		Type: event,
+		Data: payload,
+	})
+	close(g.notify)
+	g.notify = make(chan struct{})
+}
+
+// Finish 标记生成结束，保留期过后从登记表中移除
+func (g *Generation) Finish() {
+	g.mu.Lock()
+	if g.done {
+		g.mu.Unlock()
+		return
+	}
+	g.done = true
+	close(g.notify)
+	g.mu.Unlock()
+	g.cancel()
+
+	time.AfterFunc(generationRetention, func() {
+		generationsMu.Lock()
+		delete(generations, g.ID)
+		generationsMu.Unlock()
+	})
+}
+
+// EventSink 生成事件的接收方，SSE 连接与 WebSocket 连接各自实现
+type EventSink interface {
+	SendEvent(id int64, event string, payload []byte) error
+}
+
+// Stream 先回放 lastEventID 之后的事件，再持续推送新事件，直到生成结束或 ctx 取消
+func (g *Generation) Stream(ctx context.Context, sink EventSink, lastEventID int64) error {
+	for {
+		g.mu.Lock()
+		var pending []bufferedEvent
+		if lastEventID < int64(len(g.events)) {
+			pending = g.events[max(lastEventID, 0):]
+		}
+		done, wait := g.done, g.notify
+		g.mu.Unlock()
+
+		for _, e := range pending {
+			if err := sink.SendEvent(e.ID, e.Type, e.Data); err != nil {
+				return err
+			}
+			lastEventID = e.ID
+		}
+		if done {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-wait:
+		}
+	}
+}
@@ -3,18 +3,23 @@ package session
 import (
 	"context"
 	"errors"
-	"net/http"
 	"strings"
 	"wsai/backend/internal/ai"
 	"wsai/backend/internal/common/code"
 	"wsai/backend/internal/logger"
 	"wsai/backend/internal/model"
 	"wsai/backend/internal/repository/session"
+	"wsai/backend/internal/service/quota"
+	"wsai/backend/internal/service/rag"
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
+// citationSnippetLength 引用片段返回给前端的最大字符数
+const citationSnippetLength = 200
+
 var ctx = context.Background()
 
 //	func GetUserSessionByUsername(username string) ([]model.SessionInfo, error) {
@@ -62,14 +67,15 @@ func GetUserSessionsByUsername(username string) ([]model.SessionInfo, error) {
 	for _, sess := range sessions {
 		title := sess.Title
 		infos = append(infos, model.SessionInfo{
-			SessionID: sess.ID,
-			Title:     title,
-			UpdatedAt: sess.UpdatedAt,
+			SessionID:       sess.ID,
+			Title:           title,
+			UpdatedAt:       sess.UpdatedAt,
+			KnowledgeBaseID: sess.KnowledgeBaseID,
 		})
 	}
 	return infos, nil
 }
-func CreateStreamSessionOnly(username string, userQuestion string) (string, code.Code) {
+func CreateStreamSessionOnly(username string, userQuestion string) (*model.Session, code.Code) {
 	question := strings.TrimSpace(userQuestion)
 	if question == "" {
 		question = "新会话"
@@ -88,26 +94,22 @@ func CreateStreamSessionOnly(username string, userQuestion string) (string, code
 			zap.String("username", username),
 			zap.String("question_preview", question[:min(50, len(question))]),
 			zap.Error(err))
-		return "", code.CodeServerBusy
+		return nil, code.CodeServerBusy
 
 	}
-	return createdSession.ID, code.CodeSuccess
+	return createdSession, code.CodeSuccess
 }
 
-func StreamMessageToExistingSession(userName string, sessionID string, userQuestion string, modelType string, writer http.ResponseWriter) code.Code {
-	//确保writer 支持flush
-	flusher, ok := writer.(http.Flusher)
-	if !ok {
-		logger.L().Warn("streamMessageToExistingSession http.Flusher error")
-		return code.CodeServerBusy
-	}
-
+// StreamMessageToExistingSession 调用模型生成回复，增量、用量等事件都发布到 gen 中，sess 需已校验属于 userName
+func StreamMessageToExistingSession(userName string, sess *model.Session, userQuestion string, modelType string, gen *Generation, reservation *quota.Reservation) code.Code {
+	sessionID := sess.ID
 	if err := session.TouchSession(sessionID); err != nil {
 		logger.L().Warn("session.TouchSession error",
 			zap.String("username", userName),
 			zap.String("sessionId", sessionID),
 			zap.Error(err))
 	}
+	opts := retrievalOptions(sess, gen)
 
 	manager := ai.GetGlobalManager()
 	config := map[string]interface{}{
@@ -127,28 +129,11 @@ func StreamMessageToExistingSession(userName string, sessionID string, userQuest
 		zap.L().Debug("sending SSE chunk",
 			zap.Int("length", len(msg)),
 		)
-		lines := strings.Split(msg, "\n")
-		for _, line := range lines {
-			_, werr := writer.Write([]byte("data: " + line + "\n"))
-			if werr != nil {
-				logger.L().Warn("SSE write error",
-					zap.Error(werr))
-				return
-			}
-		}
-		_, werr := writer.Write([]byte("\n"))
-		if werr != nil {
-			logger.L().Warn("SSE write error",
-				zap.Error(werr))
-			return
-		}
-		flusher.Flush()
-
+		gen.Publish(EventDelta, DeltaEvent{Content: msg})
 	}
-	flusher.Flush()
 	zap.L().Debug("SSE message to existing session")
 
-	_, err_ := helper.StreamResponse(userName, ctx, cb, userQuestion)
+	aiMsg, err_ := helper.StreamResponse(userName, gen.Context(), cb, userQuestion, opts...)
 	if err_ != nil {
 		zap.L().Error("StreamMessageToExistingSession StreamResponse error",
 			zap.String("username", userName),
@@ -158,21 +143,151 @@ func StreamMessageToExistingSession(userName string, sessionID string, userQuest
 		return code.AIModelFail
 	}
 
-	_, err = writer.Write([]byte("data: [DONE]\n\n"))
-	if err != nil {
-		logger.L().Warn("StreamMessageToExistingSession write DONE error",
+	if err := quota.Record(ctx, reservation, int64(aiMsg.TotalTokens)); err != nil {
+		logger.L().Warn("quota.Record error",
+			zap.String("username", userName),
+			zap.String("sessionId", sessionID),
 			zap.Error(err))
-		return code.AIModelFail
 	}
 
-	flusher.Flush()
+	gen.Publish(EventUsage, UsageEvent{
+		PromptTokens:     aiMsg.PromptTokens,
+		CompletionTokens: aiMsg.CompletionTokens,
+		TotalTokens:      aiMsg.TotalTokens,
+		Cost:             aiMsg.Cost,
+	})
+	gen.Publish(EventDone, DoneEvent{SessionID: sessionID})
+
+	return code.CodeSuccess
+
+}
+
+// retrievalOptions 会话绑定了知识库时先检索相关片段，并把引用来源作为 citations 事件推给前端
+func retrievalOptions(sess *model.Session, gen *Generation) []ai.StreamOption {
+	if sess.KnowledgeBaseID == nil {
+		return nil
+	}
+	onRetrieve := func(refs []ai.Reference) {
+		citations := make([]Citation, len(refs))
+		for i, ref := range refs {
+			snippet := []rune(ref.Content)
+			if len(snippet) > citationSnippetLength {
+				snippet = append(snippet[:citationSnippetLength], '…')
+			}
+			citations[i] = Citation{
+				Index:      ref.Index,
+				DocumentID: ref.DocumentID,
+				Source:     ref.Source,
+				Snippet:    string(snippet),
+				Score:      ref.Score,
+			}
+		}
+		gen.Publish(EventCitations, CitationsEvent{Citations: citations})
+	}
+	return []ai.StreamOption{ai.WithRetriever(rag.NewRetriever(*sess.KnowledgeBaseID), onRetrieve)}
+}
+
+// getUserSession 查询属于 userName 的会话，不存在或属于其他用户时返回 CodeRecordNotFound
+func getUserSession(userName string, sessionID string) (*model.Session, code.Code) {
+	sess, err := session.GetSessionByID(sessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, code.CodeRecordNotFound
+		}
+		logger.L().Error("session.GetSessionByID error",
+			zap.String("sessionId", sessionID),
+			zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+	if sess.UserName != userName {
+		return nil, code.CodeRecordNotFound
+	}
+	return sess, code.CodeSuccess
+}
 
+// SetKnowledgeBase 为会话绑定知识库，knowledgeBaseID 为 nil 时解除绑定
+func SetKnowledgeBase(userName string, userID int64, sessionID string, knowledgeBaseID *int64) code.Code {
+	if _, code_ := getUserSession(userName, sessionID); code_ != code.CodeSuccess {
+		return code_
+	}
+	if knowledgeBaseID != nil {
+		if _, code_ := rag.GetKnowledgeBase(userID, *knowledgeBaseID); code_ != code.CodeSuccess {
+			return code_
+		}
+	}
+	if err := session.SetKnowledgeBase(sessionID, knowledgeBaseID); err != nil {
+		logger.L().Error("session.SetKnowledgeBase error",
+			zap.String("sessionId", sessionID),
+			zap.Error(err))
+		return code.CodeServerBusy
+	}
 	return code.CodeSuccess
+}
 
+// CreateStreamSession 创建新会话并在后台开始生成第一条回复；调用方需已通过 quota.Reserve 预占额度，这里负责结算或退回
+func CreateStreamSession(userName string, userQuestion string, modelType string, reservation *quota.Reservation) (*Generation, code.Code) {
+	newSession, code_ := CreateStreamSessionOnly(userName, userQuestion)
+	if code_ != code.CodeSuccess {
+		releaseQuota(reservation)
+		return nil, code_
+	}
+	gen := NewGeneration(userName, newSession.ID)
+	gen.Publish(EventSession, SessionEvent{SessionID: newSession.ID})
+	gen.Publish(EventTitle, TitleEvent{SessionID: newSession.ID, Title: newSession.Title})
+	go runGeneration(gen, newSession, userQuestion, modelType, reservation)
+	return gen, code.CodeSuccess
 }
 
-func ChatStreamSend(userName string, sessionID string, userQuestion string, modelType string, writer http.ResponseWriter) code.Code {
-	return StreamMessageToExistingSession(userName, sessionID, userQuestion, modelType, writer)
+// ChatStreamSend 向已有会话发送消息，回复在后台生成，与请求连接的生命周期无关；额度约定同 CreateStreamSession
+func ChatStreamSend(userName string, sessionID string, userQuestion string, modelType string, reservation *quota.Reservation) (*Generation, code.Code) {
+	sess, code_ := getUserSession(userName, sessionID)
+	if code_ != code.CodeSuccess {
+		releaseQuota(reservation)
+		return nil, code_
+	}
+	gen := NewGeneration(userName, sessionID)
+	go runGeneration(gen, sess, userQuestion, modelType, reservation)
+	return gen, code.CodeSuccess
+}
+
+func runGeneration(gen *Generation, sess *model.Session, userQuestion string, modelType string, reservation *quota.Reservation) {
+	defer gen.Finish()
+	code_ := StreamMessageToExistingSession(gen.UserName, sess, userQuestion, modelType, gen, reservation)
+	if code_ != code.CodeSuccess && gen.Canceled() {
+		code_ = code.AIModelCanceled
+	}
+	if code_ != code.CodeSuccess {
+		releaseQuota(reservation)
+		gen.Publish(EventError, ErrorEvent{Code: code_, Message: code_.Msg()})
+	}
+}
+
+// releaseQuota 生成失败时退回调用方预占的额度
+func releaseQuota(reservation *quota.Reservation) {
+	if err := quota.Release(ctx, reservation); err != nil {
+		logger.L().Warn("quota.Release error",
+			zap.String("username", reservation.Username),
+			zap.Error(err))
+	}
+}
+
+// ResumeGeneration 查找可续传的生成，只允许所属用户在对应会话下续传
+func ResumeGeneration(userName string, sessionID string, generationID string) (*Generation, code.Code) {
+	gen, ok := GetGeneration(generationID)
+	if !ok || gen.UserName != userName || gen.SessionID != sessionID {
+		return nil, code.CodeRecordNotFound
+	}
+	return gen, code.CodeSuccess
+}
+
+// CancelGeneration 取消用户自己的进行中生成
+func CancelGeneration(userName string, generationID string) code.Code {
+	gen, ok := GetGeneration(generationID)
+	if !ok || gen.UserName != userName {
+		return code.CodeRecordNotFound
+	}
+	gen.Cancel()
+	return code.CodeSuccess
 }
 
 func GetChatHistory(userName string, sessionID string) ([]model.History, code.Code) {
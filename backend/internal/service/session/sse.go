@@ -0,0 +1,160 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+	"wsai/backend/internal/common/code"
+)
+
+// SSE 事件类型
+const (
+	EventSession    = "session"    // 新会话创建完成：{"sessionId"}
+	EventGeneration = "generation" // 本次生成的 ID，断线后凭它续传：{"generationId","sessionId"}
+	EventDelta      = "delta"      // AI 回复增量：{"content"}
+	EventUsage      = "usage"      // 本次调用的 token 用量
+	EventTitle      = "title"      // 会话标题：{"sessionId","title"}
+	EventError      = "error"      // 出错：{"code","message"}
+	EventDone       = "done"       // 本次回复结束
+	EventCitations  = "citations"  // 会话绑定了知识库时，本次回答引用的资料：{"citations"}
+)
+
+const heartbeatInterval = 15 * time.Second
+
+type (
+	SessionEvent struct {
+		SessionID string `json:"sessionId"`
+	}
+	GenerationEvent struct {
+		GenerationID string `json:"generationId"`
+		SessionID    string `json:"sessionId"`
+	}
+	DeltaEvent struct {
+		Content string `json:"content"`
+	}
+	UsageEvent struct {
+		PromptTokens     int     `json:"prompt_tokens"`
+		CompletionTokens int     `json:"completion_tokens"`
+		TotalTokens      int     `json:"total_tokens"`
+		Cost             float64 `json:"cost"`
+	}
+	TitleEvent struct {
+		SessionID string `json:"sessionId"`
+		Title     string `json:"title"`
+	}
+	ErrorEvent struct {
+		Code    code.Code `json:"code"`
+		Message string    `json:"message"`
+	}
+	DoneEvent struct {
+		SessionID string `json:"sessionId"`
+	}
+	// Citation 一条引用，index 对应回答中的 [n]
+	Citation struct {
+		Index      int     `json:"index"`
+		DocumentID int64   `json:"documentId"`
+		Source     string  `json:"source"`
+		Snippet    string  `json:"snippet"`
+		Score      float64 `json:"score"`
+	}
+	CitationsEvent struct {
+		Citations []Citation `json:"citations"`
+	}
+)
+
+// SSEWriter 按 SSE 协议输出带类型和 id 的事件，并定期发送注释心跳防止代理超时断开
+type SSEWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	mu      sync.Mutex
+	lastID  int64
+	closed  bool
+	stop    chan struct{}
+}
+
+// NewSSEWriter 创建 SSE 输出器并启动心跳，使用完毕需调用 Close
+func NewSSEWriter(w http.ResponseWriter) (*SSEWriter, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, errors.New("response writer does not support flushing")
+	}
+	s := &SSEWriter{
+		w:       w,
+		flusher: flusher,
+		stop:    make(chan struct{}),
+	}
+	go s.heartbeat()
+	return s, nil
+}
+
+// Send 写出一个事件，id 自动递增；data 以 JSON 编码，JSON 中的换行已被转义，可安全放在单行 data 中
+func (s *SSEWriter) Send(event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastID++
+	return s.write(s.lastID, event, payload)
+}
+
+// SendEvent 按给定 id 写出已编码好的事件，用于回放生成缓冲区中的事件
+func (s *SSEWriter) SendEvent(id int64, event string, payload []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastID = max(s.lastID, id)
+	return s.write(id, event, payload)
+}
+
+func (s *SSEWriter) write(id int64, event string, payload []byte) error {
+	if s.closed {
+		return errors.New("sse writer closed")
+	}
+	if _, err := fmt.Fprintf(s.w, "id: %s\nevent: %s\ndata: %s\n\n",
+		strconv.FormatInt(id, 10), event, payload); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+// SendError 输出 error 事件
+func (s *SSEWriter) SendError(code_ code.Code) error {
+	return s.Send(EventError, ErrorEvent{Code: code_, Message: code_.Msg()})
+}
+
+// Close 停止心跳，之后的写入都会失败
+func (s *SSEWriter) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	close(s.stop)
+}
+
+func (s *SSEWriter) heartbeat() {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			if !s.closed {
+				if _, err := s.w.Write([]byte(": ping\n\n")); err == nil {
+					s.flusher.Flush()
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"wsai/backend/config"
+	"wsai/backend/internal/common/code"
+	redisclient "wsai/backend/internal/common/redis"
+	"wsai/backend/internal/logger"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+)
+
+// OpenID Connect 授权码流程（PKCE）：
+// 1. AuthCodeURL 生成 state、nonce 和 code_verifier 存入 Redis，state 的哈希写入发起登录的浏览器 cookie，跳转到 IdP；
+// 2. IdP 回调后 Exchange 校验 state 及其与 cookie 的绑定，用 code + code_verifier 换取 id_token 并校验签名和 nonce。
+const (
+	stateKeyPrefix = "oidc:state:"
+	stateTTL       = 10 * time.Minute
+	httpTimeout    = 10 * time.Second
+)
+
+// StateTTL 登录流程的有效期，state 绑定 cookie 的有效期与之相同
+const StateTTL = stateTTL
+
+// Identity 从 id_token 中取出的用户身份
+type Identity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// ProviderInfo 对外展示的身份提供方
+type ProviderInfo struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+type provider struct {
+	name     string
+	cfg      config.OIDCProvider
+	oauth    *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// pendingLogin 跳转到 IdP 前保存在 Redis 中的状态
+type pendingLogin struct {
+	Provider string `json:"provider"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+}
+
+var (
+	mu        sync.Mutex
+	providers = map[string]*provider{}
+	client    = &http.Client{Timeout: httpTimeout}
+)
+
+// Providers 列出已配置的身份提供方
+func Providers() []ProviderInfo {
+	infos := make([]ProviderInfo, 0, len(config.C.OIDCConfig.Providers))
+	for name, cfg := range config.C.OIDCConfig.Providers {
+		display := cfg.DisplayName
+		if display == "" {
+			display = name
+		}
+		infos = append(infos, ProviderInfo{Name: name, DisplayName: display})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// getProvider 首次使用时才做 OIDC discovery，IdP 不可用不影响服务启动，失败后下次重试
+func getProvider(ctx context.Context, name string) (*provider, code.Code) {
+	cfg, ok := config.C.OIDCConfig.Providers[name]
+	if !ok {
+		return nil, code.CodeRecordNotFound
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if p, ok := providers[name]; ok {
+		return p, code.CodeSuccess
+	}
+
+	discovered, err := oidc.NewProvider(oidc.ClientContext(ctx, client), cfg.Issuer)
+	if err != nil {
+		logger.L().Error("OIDC discovery 失败",
+			zap.String("provider", name),
+			zap.String("issuer", cfg.Issuer),
+			zap.Error(err))
+		return nil, code.CodeOIDCFailed
+	}
+	scopes := cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{oidc.ScopeOpenID, "profile", "email"}
+	}
+	p := &provider{
+		name: name,
+		cfg:  cfg,
+		oauth: &oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint:     discovered.Endpoint(),
+			Scopes:       scopes,
+		},
+		verifier: discovered.Verifier(&oidc.Config{ClientID: cfg.ClientID}),
+	}
+	providers[name] = p
+	return p, code.CodeSuccess
+}
+
+// AutoProvision 该提供方是否允许首次登录时自动创建账号
+func AutoProvision(name string) bool {
+	return config.C.OIDCConfig.Providers[name].AutoProvision
+}
+
+func randomString() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// StateBinding state 的哈希，写入发起登录的浏览器 cookie；回调时要求一致，
+// 防止攻击者把自己发起的登录回调链接交给受害者，让受害者登录进攻击者的账号
+func StateBinding(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:])
+}
+
+// AuthCodeURL 生成跳转到 IdP 的授权地址，同时返回需要绑定到浏览器的 state
+func AuthCodeURL(ctx context.Context, name string) (string, string, code.Code) {
+	if redisclient.Rdb == nil {
+		return "", "", code.CodeServerBusy
+	}
+	p, code_ := getProvider(ctx, name)
+	if code_ != code.CodeSuccess {
+		return "", "", code_
+	}
+
+	state, err := randomString()
+	if err != nil {
+		return "", "", code.CodeServerBusy
+	}
+	nonce, err := randomString()
+	if err != nil {
+		return "", "", code.CodeServerBusy
+	}
+	pending := pendingLogin{Provider: name, Nonce: nonce, Verifier: oauth2.GenerateVerifier()}
+	payload, _ := json.Marshal(pending)
+	if err := redisclient.Rdb.Set(ctx, stateKeyPrefix+state, payload, stateTTL).Err(); err != nil {
+		logger.L().Error("保存 OIDC state 失败", zap.Error(err))
+		return "", "", code.CodeServerBusy
+	}
+	return p.oauth.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(pending.Verifier)), state, code.CodeSuccess
+}
+
+// Exchange 处理 IdP 回调：校验 state 及其与浏览器 cookie（binding）的绑定，换取并校验 id_token，返回用户身份
+func Exchange(ctx context.Context, name, authCode, state, binding string) (*Identity, code.Code) {
+	if redisclient.Rdb == nil {
+		return nil, code.CodeServerBusy
+	}
+	if authCode == "" || state == "" {
+		return nil, code.CodeInvalidParams
+	}
+	if subtle.ConstantTimeCompare([]byte(StateBinding(state)), []byte(binding)) != 1 {
+		logger.L().Warn("OIDC state 与发起登录的浏览器不一致", zap.String("provider", name))
+		return nil, code.CodeOIDCFailed
+	}
+	// state 只能使用一次
+	raw, err := redisclient.Rdb.GetDel(ctx, stateKeyPrefix+state).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, code.CodeOIDCFailed
+	}
+	if err != nil {
+		logger.L().Error("读取 OIDC state 失败", zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+	var pending pendingLogin
+	if err := json.Unmarshal(raw, &pending); err != nil || pending.Provider != name {
+		return nil, code.CodeOIDCFailed
+	}
+
+	p, code_ := getProvider(ctx, name)
+	if code_ != code.CodeSuccess {
+		return nil, code_
+	}
+	ctx = oidc.ClientContext(ctx, client)
+	token, err := p.oauth.Exchange(ctx, authCode, oauth2.VerifierOption(pending.Verifier))
+	if err != nil {
+		logger.L().Warn("OIDC 授权码换取 token 失败",
+			zap.String("provider", name),
+			zap.Error(err))
+		return nil, code.CodeOIDCFailed
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, code.CodeOIDCFailed
+	}
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil || idToken.Nonce != pending.Nonce {
+		logger.L().Warn("OIDC id_token 校验失败",
+			zap.String("provider", name),
+			zap.Error(err))
+		return nil, code.CodeOIDCFailed
+	}
+
+	var claims struct {
+		Email         string `json:"email"`
+		EmailVerified *bool  `json:"email_verified"`
+		Name          string `json:"name"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, code.CodeOIDCFailed
+	}
+	identity := &Identity{
+		Provider: name,
+		Subject:  idToken.Subject,
+		Email:    strings.ToLower(strings.TrimSpace(claims.Email)),
+		// 未声明 email_verified 时视为未验证，除非该提供方配置了 trust_email
+		EmailVerified: emailVerified(claims.EmailVerified, p.cfg.TrustEmail),
+		Name:          claims.Name,
+	}
+	if !p.allowed(identity.Email) {
+		return nil, code.CodeOIDCDomain
+	}
+	return identity, code.CodeSuccess
+}
+
+func emailVerified(claim *bool, trustEmail bool) bool {
+	if claim == nil {
+		return trustEmail
+	}
+	return *claim
+}
+
+// allowed 邮箱域名是否在允许列表中
+func (p *provider) allowed(email string) bool {
+	if len(p.cfg.AllowedDomains) == 0 {
+		return true
+	}
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	return slices.ContainsFunc(p.cfg.AllowedDomains, func(domain string) bool {
+		return strings.EqualFold(strings.TrimPrefix(domain, "@"), email[at+1:])
+	})
+}
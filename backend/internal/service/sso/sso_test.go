@@ -0,0 +1,291 @@
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+	"wsai/backend/config"
+	"wsai/backend/internal/common/code"
+	redisclient "wsai/backend/internal/common/redis"
+	"wsai/backend/internal/logger"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	testClientID = "wsai"
+	testCode     = "auth-code"
+	testKeyID    = "test-key"
+)
+
+// fakeIdP 最小的 OpenID Connect 提供方：discovery、JWKS 和 token 端点，
+// 授权码只有 testCode 有效，并按 PKCE 校验 code_verifier
+type fakeIdP struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	mu        sync.Mutex
+	nonce     string
+	challenge string
+	// emailVerified 为 nil 时 id_token 不带 email_verified 声明
+	emailVerified *bool
+	exchanges     int
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey: %v", err)
+	}
+	idp := &fakeIdP{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", idp.discovery)
+	mux.HandleFunc("/jwks", idp.jwks)
+	mux.HandleFunc("/token", idp.token)
+	idp.Server = httptest.NewServer(mux)
+	t.Cleanup(idp.Close)
+	return idp
+}
+
+func (idp *fakeIdP) exchangeCount() int {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	return idp.exchanges
+}
+
+func (idp *fakeIdP) discovery(w http.ResponseWriter, _ *http.Request) {
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"issuer":                                idp.URL,
+		"authorization_endpoint":                idp.URL + "/authorize",
+		"token_endpoint":                        idp.URL + "/token",
+		"jwks_uri":                              idp.URL + "/jwks",
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (idp *fakeIdP) jwks(w http.ResponseWriter, _ *http.Request) {
+	pub := idp.key.PublicKey
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": testKeyID,
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func (idp *fakeIdP) token(w http.ResponseWriter, r *http.Request) {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.exchanges++
+
+	sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if r.PostFormValue("code") != testCode || base64.RawURLEncoding.EncodeToString(sum[:]) != idp.challenge {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":   idp.URL,
+		"sub":   "user-1",
+		"aud":   testClientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+		"nonce": idp.nonce,
+		"email": " Alice@Example.com ",
+		"name":  "Alice",
+	}
+	if idp.emailVerified != nil {
+		claims["email_verified"] = *idp.emailVerified
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = testKeyID
+	idToken, err := token.SignedString(idp.key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+// authorize 模拟浏览器跳转到 IdP：记下授权地址中的 nonce 和 code_challenge
+func (idp *fakeIdP) authorize(t *testing.T, authURL string) {
+	t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("parse auth url: %v", err)
+	}
+	q := u.Query()
+	if q.Get("client_id") != testClientID || q.Get("code_challenge_method") != "S256" {
+		t.Fatalf("unexpected auth url %s", authURL)
+	}
+	idp.mu.Lock()
+	idp.nonce = q.Get("nonce")
+	idp.challenge = q.Get("code_challenge")
+	idp.mu.Unlock()
+}
+
+// setup 用 miniredis 替换全局 Redis，并把 fakeIdP 配置为名为 corp 的提供方
+func setup(t *testing.T, trustEmail bool) *fakeIdP {
+	t.Helper()
+	if err := logger.Init(true); err != nil {
+		t.Fatal(err)
+	}
+	mr := miniredis.RunT(t)
+	idp := newFakeIdP(t)
+
+	oldRdb, oldConf := redisclient.Rdb, config.C
+	redisclient.Rdb = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	config.C = new(config.Config)
+	config.C.OIDCConfig.Providers = map[string]config.OIDCProvider{
+		"corp": {
+			Issuer:       idp.URL,
+			ClientID:     testClientID,
+			ClientSecret: "secret",
+			RedirectURL:  "http://localhost/api/v1/user/oidc/corp/callback",
+			TrustEmail:   trustEmail,
+		},
+	}
+	t.Cleanup(func() {
+		_ = redisclient.Rdb.Close()
+		redisclient.Rdb, config.C = oldRdb, oldConf
+		mu.Lock()
+		providers = map[string]*provider{}
+		mu.Unlock()
+	})
+	return idp
+}
+
+// begin 发起一次登录，返回 state
+func begin(t *testing.T, idp *fakeIdP) string {
+	t.Helper()
+	authURL, state, code_ := AuthCodeURL(context.Background(), "corp")
+	if code_ != code.CodeSuccess {
+		t.Fatalf("AuthCodeURL: %v", code_)
+	}
+	idp.authorize(t, authURL)
+	return state
+}
+
+func TestExchange(t *testing.T) {
+	idp := setup(t, false)
+	verified := true
+	idp.emailVerified = &verified
+	state := begin(t, idp)
+
+	identity, code_ := Exchange(context.Background(), "corp", testCode, state, StateBinding(state))
+	if code_ != code.CodeSuccess {
+		t.Fatalf("Exchange: %v", code_)
+	}
+	want := Identity{Provider: "corp", Subject: "user-1", Email: "alice@example.com", EmailVerified: true, Name: "Alice"}
+	if *identity != want {
+		t.Fatalf("identity = %+v, want %+v", *identity, want)
+	}
+}
+
+func TestExchangeStateMismatch(t *testing.T) {
+	idp := setup(t, false)
+	ctx := context.Background()
+	state := begin(t, idp)
+	other := begin(t, idp)
+
+	// 攻击者把自己发起的登录回调交给受害者，受害者浏览器中的 cookie 绑定的是另一个 state
+	if _, code_ := Exchange(ctx, "corp", testCode, state, StateBinding(other)); code_ != code.CodeOIDCFailed {
+		t.Fatalf("mismatched binding: got %v, want CodeOIDCFailed", code_)
+	}
+	// 伪造的 state 即使 cookie 与之一致也无效
+	if _, code_ := Exchange(ctx, "corp", testCode, "forged", StateBinding("forged")); code_ != code.CodeOIDCFailed {
+		t.Fatalf("unknown state: got %v, want CodeOIDCFailed", code_)
+	}
+	// state 属于另一个提供方
+	if _, code_ := Exchange(ctx, "other", testCode, other, StateBinding(other)); code_ != code.CodeOIDCFailed {
+		t.Fatalf("other provider: got %v, want CodeOIDCFailed", code_)
+	}
+	if n := idp.exchangeCount(); n != 0 {
+		t.Fatalf("token endpoint called %d times, want 0", n)
+	}
+}
+
+func TestExchangeMissingCookie(t *testing.T) {
+	idp := setup(t, false)
+	ctx := context.Background()
+	state := begin(t, idp)
+
+	if _, code_ := Exchange(ctx, "corp", testCode, state, ""); code_ != code.CodeOIDCFailed {
+		t.Fatalf("missing cookie: got %v, want CodeOIDCFailed", code_)
+	}
+	// 没带 cookie 的回调不消耗 state，发起登录的浏览器仍可完成登录
+	if _, code_ := Exchange(ctx, "corp", testCode, state, StateBinding(state)); code_ != code.CodeSuccess {
+		t.Fatalf("original browser: %v", code_)
+	}
+}
+
+func TestExchangeReplayedState(t *testing.T) {
+	idp := setup(t, false)
+	ctx := context.Background()
+	state := begin(t, idp)
+
+	if _, code_ := Exchange(ctx, "corp", testCode, state, StateBinding(state)); code_ != code.CodeSuccess {
+		t.Fatalf("first callback: %v", code_)
+	}
+	if _, code_ := Exchange(ctx, "corp", testCode, state, StateBinding(state)); code_ != code.CodeOIDCFailed {
+		t.Fatalf("replayed callback: got %v, want CodeOIDCFailed", code_)
+	}
+	if n := idp.exchangeCount(); n != 1 {
+		t.Fatalf("token endpoint called %d times, want 1", n)
+	}
+}
+
+func TestExchangeEmailVerified(t *testing.T) {
+	verified, unverified := true, false
+	for _, tc := range []struct {
+		name       string
+		claim      *bool
+		trustEmail bool
+		want       bool
+	}{
+		{"missing claim", nil, false, false},
+		{"missing claim with trust_email", nil, true, true},
+		{"unverified with trust_email", &unverified, true, false},
+		{"verified", &verified, false, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			idp := setup(t, tc.trustEmail)
+			idp.emailVerified = tc.claim
+			state := begin(t, idp)
+
+			identity, code_ := Exchange(context.Background(), "corp", testCode, state, StateBinding(state))
+			if code_ != code.CodeSuccess {
+				t.Fatalf("Exchange: %v", code_)
+			}
+			if identity.EmailVerified != tc.want {
+				t.Fatalf("EmailVerified = %v, want %v", identity.EmailVerified, tc.want)
+			}
+		})
+	}
+}
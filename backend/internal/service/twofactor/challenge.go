@@ -0,0 +1,91 @@
+package twofactor
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+	redisclient "wsai/backend/internal/common/redis"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 开启两步验证的用户密码校验通过后，先拿到一个短期的挑战 token，
+// 再凭挑战 token 和动态验证码换取正式的登录凭证。
+const (
+	challengeKeyPrefix  = "2fa:challenge:"
+	ChallengeTTL        = 5 * time.Minute
+	maxChallengeAttempt = 5
+)
+
+var ErrChallengeInvalid = errors.New("两步验证挑战无效或已过期")
+
+func challengeKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return challengeKeyPrefix + hex.EncodeToString(sum[:])
+}
+
+// NewChallenge 为通过密码校验的用户创建挑战 token
+func NewChallenge(ctx context.Context, userID int64) (string, error) {
+	if redisclient.Rdb == nil {
+		return "", errors.New("Redis 客户端未初始化")
+	}
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+	key := challengeKey(token)
+	_, err := redisclient.Rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, "uid", userID, "attempts", 0)
+		pipe.Expire(ctx, key, ChallengeTTL)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ChallengeUser 返回挑战对应的用户 ID，并计入一次尝试；超过次数后挑战作废
+func ChallengeUser(ctx context.Context, token string) (int64, error) {
+	if redisclient.Rdb == nil {
+		return 0, errors.New("Redis 客户端未初始化")
+	}
+	if token == "" {
+		return 0, ErrChallengeInvalid
+	}
+	key := challengeKey(token)
+	uid, err := redisclient.Rdb.HGet(ctx, key, "uid").Result()
+	if errors.Is(err, redis.Nil) {
+		return 0, ErrChallengeInvalid
+	}
+	if err != nil {
+		return 0, err
+	}
+	attempts, err := redisclient.Rdb.HIncrBy(ctx, key, "attempts", 1).Result()
+	if err != nil {
+		return 0, err
+	}
+	if attempts > maxChallengeAttempt {
+		redisclient.Rdb.Del(ctx, key)
+		return 0, ErrChallengeInvalid
+	}
+	id, err := strconv.ParseInt(uid, 10, 64)
+	if err != nil {
+		return 0, ErrChallengeInvalid
+	}
+	return id, nil
+}
+
+// CompleteChallenge 验证成功后作废挑战 token，返回 false 表示已被并发使用
+func CompleteChallenge(ctx context.Context, token string) (bool, error) {
+	if redisclient.Rdb == nil {
+		return false, errors.New("Redis 客户端未初始化")
+	}
+	n, err := redisclient.Rdb.Del(ctx, challengeKey(token)).Result()
+	return n > 0, err
+}
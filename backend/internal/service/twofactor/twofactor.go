@@ -0,0 +1,249 @@
+package twofactor
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"wsai/backend/internal/common/code"
+	redisclient "wsai/backend/internal/common/redis"
+	"wsai/backend/internal/logger"
+	"wsai/backend/internal/model"
+	"wsai/backend/internal/repository/recoverycode"
+	"wsai/backend/internal/repository/user"
+
+	"github.com/pquerna/otp/totp"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// Issuer 显示在验证器 App 中的服务名
+	Issuer = "wsai"
+	// RecoveryCodeCount 每次生成的恢复码数量
+	RecoveryCodeCount = 10
+	// usedKeyPrefix 已使用过的动态验证码，防止同一个验证码在有效窗口内被重放
+	usedKeyPrefix = "2fa:used:"
+	usedTTL       = 2 * time.Minute
+)
+
+// Enrollment 登记两步验证时返回给客户端的信息
+type Enrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"` // 可生成二维码供验证器 App 扫描
+}
+
+// Status 两步验证状态
+type Status struct {
+	Enabled               bool  `json:"enabled"`
+	RemainingRecoveryCode int64 `json:"remaining_recovery_codes"`
+}
+
+func loadUser(userID int64) (*model.User, code.Code) {
+	u, err := user.GetUserByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, code.CodeUserNotExist
+		}
+		logger.L().Error("user.GetUserByID error",
+			zap.Int64("user_id", userID),
+			zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+	return u, code.CodeSuccess
+}
+
+// GetStatus 查询用户两步验证状态
+func GetStatus(userID int64) (*Status, code.Code) {
+	u, code_ := loadUser(userID)
+	if code_ != code.CodeSuccess {
+		return nil, code_
+	}
+	status := &Status{Enabled: u.TOTPEnabled}
+	if u.TOTPEnabled {
+		n, err := recoverycode.CountUnusedRecoveryCodes(u.ID)
+		if err != nil {
+			logger.L().Error("recoverycode.CountUnusedRecoveryCodes error",
+				zap.Int64("user_id", u.ID),
+				zap.Error(err))
+			return nil, code.CodeServerBusy
+		}
+		status.RemainingRecoveryCode = n
+	}
+	return status, code.CodeSuccess
+}
+
+// Enroll 为用户生成新的 TOTP 密钥，需调用 Activate 验证一次后才会生效
+func Enroll(userID int64) (*Enrollment, code.Code) {
+	u, code_ := loadUser(userID)
+	if code_ != code.CodeSuccess {
+		return nil, code_
+	}
+	if u.TOTPEnabled {
+		return nil, code.CodeTwoFactorEnabled
+	}
+	account := u.Email
+	if account == "" {
+		account = u.Username
+	}
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      Issuer,
+		AccountName: account,
+	})
+	if err != nil {
+		logger.L().Error("totp.Generate error", zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+	if err := user.UpdateTOTP(u.ID, key.Secret(), false); err != nil {
+		logger.L().Error("user.UpdateTOTP error",
+			zap.Int64("user_id", u.ID),
+			zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+	return &Enrollment{Secret: key.Secret(), URI: key.URL()}, code.CodeSuccess
+}
+
+// Activate 用验证器 App 生成的验证码确认登记，开启两步验证并返回恢复码明文（仅此一次）
+func Activate(ctx context.Context, userID int64, passcode string) ([]string, code.Code) {
+	u, code_ := loadUser(userID)
+	if code_ != code.CodeSuccess {
+		return nil, code_
+	}
+	if u.TOTPEnabled {
+		return nil, code.CodeTwoFactorEnabled
+	}
+	if u.TOTPSecret == "" {
+		return nil, code.CodeTwoFactorOff
+	}
+	if !validateTOTP(ctx, u, passcode) {
+		return nil, code.CodeInvalidOTP
+	}
+
+	codes, code_ := regenerateRecoveryCodes(u.ID)
+	if code_ != code.CodeSuccess {
+		return nil, code_
+	}
+	if err := user.UpdateTOTP(u.ID, u.TOTPSecret, true); err != nil {
+		logger.L().Error("user.UpdateTOTP error",
+			zap.Int64("user_id", u.ID),
+			zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+	return codes, code.CodeSuccess
+}
+
+// Disable 验证动态验证码或恢复码后关闭两步验证
+func Disable(ctx context.Context, userID int64, passcode string) code.Code {
+	u, code_ := loadUser(userID)
+	if code_ != code.CodeSuccess {
+		return code_
+	}
+	if !u.TOTPEnabled {
+		return code.CodeTwoFactorOff
+	}
+	if !Verify(ctx, u, passcode) {
+		return code.CodeInvalidOTP
+	}
+	if err := user.UpdateTOTP(u.ID, "", false); err != nil {
+		logger.L().Error("user.UpdateTOTP error",
+			zap.Int64("user_id", u.ID),
+			zap.Error(err))
+		return code.CodeServerBusy
+	}
+	if err := recoverycode.DeleteRecoveryCodes(u.ID); err != nil {
+		logger.L().Warn("recoverycode.DeleteRecoveryCodes error",
+			zap.Int64("user_id", u.ID),
+			zap.Error(err))
+	}
+	return code.CodeSuccess
+}
+
+// RegenerateRecoveryCodes 验证后重新生成恢复码，旧的全部作废
+func RegenerateRecoveryCodes(ctx context.Context, userID int64, passcode string) ([]string, code.Code) {
+	u, code_ := loadUser(userID)
+	if code_ != code.CodeSuccess {
+		return nil, code_
+	}
+	if !u.TOTPEnabled {
+		return nil, code.CodeTwoFactorOff
+	}
+	if !validateTOTP(ctx, u, passcode) {
+		return nil, code.CodeInvalidOTP
+	}
+	return regenerateRecoveryCodes(u.ID)
+}
+
+// Verify 校验 6 位动态验证码，或一次性恢复码（使用后作废）
+func Verify(ctx context.Context, u *model.User, passcode string) bool {
+	passcode = strings.TrimSpace(passcode)
+	if len(passcode) == 6 {
+		return validateTOTP(ctx, u, passcode)
+	}
+	ok, err := recoverycode.UseRecoveryCode(u.ID, hashRecoveryCode(passcode))
+	if err != nil {
+		logger.L().Error("recoverycode.UseRecoveryCode error",
+			zap.Int64("user_id", u.ID),
+			zap.Error(err))
+		return false
+	}
+	return ok
+}
+
+// validateTOTP 校验动态验证码，同一验证码只能使用一次
+func validateTOTP(ctx context.Context, u *model.User, passcode string) bool {
+	if u.TOTPSecret == "" || !totp.Validate(strings.TrimSpace(passcode), u.TOTPSecret) {
+		return false
+	}
+	if redisclient.Rdb == nil {
+		return true
+	}
+	key := usedKeyPrefix + strconv.FormatInt(u.ID, 10) + ":" + strings.TrimSpace(passcode)
+	first, err := redisclient.Rdb.SetNX(ctx, key, "1", usedTTL).Result()
+	if err != nil {
+		logger.L().Warn("记录已使用的动态验证码失败", zap.Error(err))
+		return true
+	}
+	return first
+}
+
+func regenerateRecoveryCodes(userID int64) ([]string, code.Code) {
+	codes := make([]string, 0, RecoveryCodeCount)
+	hashes := make([]string, 0, RecoveryCodeCount)
+	for range RecoveryCodeCount {
+		c, err := newRecoveryCode()
+		if err != nil {
+			logger.L().Error("generate recovery code error", zap.Error(err))
+			return nil, code.CodeServerBusy
+		}
+		codes = append(codes, c)
+		hashes = append(hashes, hashRecoveryCode(c))
+	}
+	if err := recoverycode.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		logger.L().Error("recoverycode.ReplaceRecoveryCodes error",
+			zap.Int64("user_id", userID),
+			zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+	return codes, code.CodeSuccess
+}
+
+// newRecoveryCode 生成形如 3f9a-12bc-77de 的恢复码
+func newRecoveryCode() (string, error) {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	h := hex.EncodeToString(buf)
+	return h[0:4] + "-" + h[4:8] + "-" + h[8:12], nil
+}
+
+// hashRecoveryCode 恢复码是随机串，忽略大小写和分隔符后做 SHA-256 即可
+func hashRecoveryCode(c string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(c), "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
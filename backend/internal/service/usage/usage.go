@@ -0,0 +1,68 @@
+package usage
+
+import (
+	"time"
+	"wsai/backend/internal/common/code"
+	"wsai/backend/internal/logger"
+	"wsai/backend/internal/repository/usage"
+
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultDays = 30
+	MaxDays     = 365
+)
+
+// Report 用户用量报表
+type Report struct {
+	From     string               `json:"from"`
+	To       string               `json:"to"`
+	Total    usage.Summary        `json:"total"`
+	Daily    []usage.DailyUsage   `json:"daily"`
+	Sessions []usage.SessionUsage `json:"sessions"`
+}
+
+// GetUserUsage 统计用户最近 days 天（含今天）的 token 用量与估算费用
+func GetUserUsage(username string, days int) (*Report, code.Code) {
+	if days <= 0 {
+		days = DefaultDays
+	}
+	if days > MaxDays {
+		return nil, code.CodeInvalidParams
+	}
+
+	now := time.Now()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, 1)
+	from := to.AddDate(0, 0, -days)
+
+	total, err := usage.GetUserSummary(username, from, to)
+	if err != nil {
+		logger.L().Error("usage.GetUserSummary error",
+			zap.String("username", username),
+			zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+	daily, err := usage.GetUserDailyUsage(username, from, to)
+	if err != nil {
+		logger.L().Error("usage.GetUserDailyUsage error",
+			zap.String("username", username),
+			zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+	sessions, err := usage.GetUserSessionUsage(username, from, to)
+	if err != nil {
+		logger.L().Error("usage.GetUserSessionUsage error",
+			zap.String("username", username),
+			zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+
+	return &Report{
+		From:     from.Format(time.DateOnly),
+		To:       to.AddDate(0, 0, -1).Format(time.DateOnly),
+		Total:    *total,
+		Daily:    daily,
+		Sessions: sessions,
+	}, code.CodeSuccess
+}
@@ -0,0 +1,49 @@
+package user
+
+import (
+	"context"
+	"wsai/backend/internal/common/code"
+	"wsai/backend/internal/logger"
+	"wsai/backend/internal/repository/user"
+	"wsai/backend/internal/service/captcha"
+	myemail "wsai/backend/internal/service/email"
+	"wsai/backend/internal/service/loginsession"
+
+	"go.uber.org/zap"
+)
+
+// SendLoginCode 向邮箱发送免密登录验证码；邮箱未注册时同样返回成功，避免探测账号
+func SendLoginCode(email, ip string, image ImageAnswer) code.Code {
+	if code_ := checkImageCaptcha(image); code_ != code.CodeSuccess {
+		return code_
+	}
+	ok, u := user.IsExistUserWithEmail(email)
+	if !ok {
+		return code.CodeSuccess
+	}
+	return sendCaptcha(captcha.Target{Purpose: captcha.PurposeLogin, Email: email}, ip, myemail.KindLoginCode, u.Language)
+}
+
+// LoginWithCode 凭邮箱收到的一次性验证码登录，与密码登录共用失败计数和锁定；
+// 开启了两步验证的账号同样需要完成挑战
+func LoginWithCode(email, loginCode string, client loginsession.Client) (*LoginResult, code.Code) {
+	ok, u := user.IsExistUserWithEmail(email)
+	a := loginAttempt{
+		method:     "email_code",
+		identifier: email,
+		exist:      ok,
+		user:       u,
+		client:     client,
+		unknown:    code.CodeInvalidCaptcha,
+		invalid:    code.CodeInvalidCaptcha,
+	}
+	result, code_ := guardedLogin(a, func(ctx context.Context) (bool, error) {
+		return captcha.Verify(ctx, captcha.Target{Purpose: captcha.PurposeLogin, Email: email}, loginCode)
+	})
+	if code_ == code.CodeSuccess {
+		logger.L().Info("邮箱验证码登录",
+			zap.Int64("user_id", u.ID),
+			zap.String("ip", client.IP))
+	}
+	return result, code_
+}
@@ -0,0 +1,174 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+	"wsai/backend/internal/common/code"
+	"wsai/backend/internal/logger"
+	"wsai/backend/internal/model"
+	"wsai/backend/internal/service/audit"
+	myemail "wsai/backend/internal/service/email"
+	"wsai/backend/internal/service/loginguard"
+	"wsai/backend/internal/service/loginsession"
+
+	"go.uber.org/zap"
+)
+
+// loginAttempt 一次受防暴力破解保护的登录尝试
+type loginAttempt struct {
+	method     string      // 登录方式，写入审计日志
+	identifier string      // 用户填写的用户名或邮箱
+	exist      bool        // 账号是否存在
+	user       *model.User // 账号不存在时为 nil
+	client     loginsession.Client
+	unknown    code.Code // 账号不存在时返回的错误码
+	invalid    code.Code // 凭证错误时返回的错误码
+}
+
+// passwordLogin 带防暴力破解的密码登录，identifier 为用户填写的用户名或邮箱
+func passwordLogin(identifier string, exist bool, u *model.User, password string, client loginsession.Client) (*LoginResult, code.Code) {
+	a := loginAttempt{
+		method:     "password",
+		identifier: identifier,
+		exist:      exist,
+		user:       u,
+		client:     client,
+		unknown:    code.CodeUserNotExist,
+		invalid:    code.CodeInvalidPassword,
+	}
+	return guardedLogin(a, func(context.Context) (bool, error) {
+		return checkPassword(u, password), nil
+	})
+}
+
+// guardedLogin 先检查 IP 与账号是否被限制，再用 verify 校验凭证；
+// 失败计入账号和 IP，成功后清空账号的失败计数并进入 completeLogin；
+// 开启两步验证的账号要等 LoginWithTwoFactor 通过后才清空。
+// 无论成败都会记录一条登录审计
+func guardedLogin(a loginAttempt, verify func(ctx context.Context) (bool, error)) (*LoginResult, code.Code) {
+	result, code_ := checkAttempt(a, verify)
+	var u *model.User
+	if a.exist {
+		u = a.user
+	}
+	auditLogin(a.method, a.identifier, u, a.client, result, code_)
+	return result, code_
+}
+
+func checkAttempt(a loginAttempt, verify func(ctx context.Context) (bool, error)) (*LoginResult, code.Code) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	u, client := a.user, a.client
+	var userID int64
+	if a.exist {
+		userID = u.ID
+	}
+	if err := loginguard.Check(ctx, userID, client.IP); err != nil {
+		return nil, guardCode(err)
+	}
+	if !a.exist {
+		recordFailure(ctx, nil, client.IP)
+		return nil, a.unknown
+	}
+	ok, err := verify(ctx)
+	if err != nil {
+		logger.L().Error("登录凭证校验失败",
+			zap.Int64("user_id", u.ID),
+			zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+	if !ok {
+		if recordFailure(ctx, u, client.IP) {
+			return nil, code.CodeAccountLocked
+		}
+		return nil, a.invalid
+	}
+	// 否则知道密码的人可以反复申请挑战，无限次猜测动态验证码
+	if !u.TOTPEnabled {
+		loginguard.Succeed(ctx, u.ID)
+	}
+	return completeLogin(u, client)
+}
+
+// auditLogin 记录一次登录尝试；账号不存在时只记录用户填写的标识
+func auditLogin(method, identifier string, u *model.User, client loginsession.Client, result *LoginResult, code_ code.Code) {
+	e := audit.Entry{
+		Actor: audit.Actor{
+			Username:  identifier,
+			IP:        client.IP,
+			UserAgent: client.UserAgent,
+		},
+		Action:     audit.ActionLogin,
+		TargetType: audit.TargetUser,
+		Success:    code_ == code.CodeSuccess,
+		Detail:     "method=" + method,
+	}
+	if u != nil {
+		e.UserID = u.ID
+		e.Username = u.Username
+		e.TargetID = strconv.FormatInt(u.ID, 10)
+	}
+	switch {
+	case !e.Success:
+		e.Detail += "; " + code_.Msg()
+	case result != nil && result.ChallengeToken != "":
+		e.Detail += "; 待两步验证"
+	}
+	audit.Record(e)
+}
+
+func guardCode(err error) code.Code {
+	switch {
+	case errors.Is(err, loginguard.ErrAccountLocked):
+		return code.CodeAccountLocked
+	case errors.Is(err, loginguard.ErrThrottled):
+		return code.CodeLoginThrottled
+	case errors.Is(err, loginguard.ErrIPBlocked):
+		return code.CodeIPBlocked
+	}
+	return code.CodeServerBusy
+}
+
+// recordFailure 记录一次失败，返回是否因此锁定了账号；锁定时邮件通知用户
+func recordFailure(ctx context.Context, u *model.User, ip string) bool {
+	var userID int64
+	if u != nil {
+		userID = u.ID
+	}
+	locked, err := loginguard.Fail(ctx, userID, ip)
+	if err != nil {
+		logger.L().Warn("记录登录失败次数失败",
+			zap.Int64("user_id", userID),
+			zap.Error(err))
+		return false
+	}
+	if !locked {
+		return false
+	}
+	logger.L().Warn("登录失败次数过多，账号已临时锁定",
+		zap.Int64("user_id", u.ID),
+		zap.String("ip", ip))
+	if u.Email != "" {
+		until, err := loginguard.LockedUntil(ctx, u.ID)
+		if err != nil || until == nil {
+			return true
+		}
+		job := myemail.Job{
+			To:     u.Email,
+			Kind:   myemail.KindAccountLocked,
+			Locale: u.Language,
+			Data:   myemail.Data{Until: until.Format("2006-01-02 15:04")},
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if err := myemail.Send(ctx, job); err != nil {
+				logger.L().Warn("发送账号锁定通知失败", zap.Error(err))
+			}
+		}()
+	}
+	return true
+}
@@ -0,0 +1,53 @@
+package user
+
+import (
+	"context"
+	"time"
+	"wsai/backend/config"
+	"wsai/backend/internal/common/code"
+	"wsai/backend/internal/logger"
+	"wsai/backend/internal/service/imagecaptcha"
+
+	"go.uber.org/zap"
+)
+
+// ImageAnswer 图形验证码 ID 与用户识别的字符
+type ImageAnswer struct {
+	ID     string
+	Answer string
+}
+
+// ImageCaptchaEnabled 是否要求先通过图形验证码才能发送邮箱验证码和注册
+func ImageCaptchaEnabled() bool {
+	return config.C.CaptchaConfig.ImageEnabled
+}
+
+// NewImageCaptcha 生成图形验证码
+func NewImageCaptcha() (*imagecaptcha.Challenge, code.Code) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	challenge, err := imagecaptcha.New(ctx)
+	if err != nil {
+		logger.L().Error("imagecaptcha.New error", zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+	return challenge, code.CodeSuccess
+}
+
+// checkImageCaptcha 校验图形验证码，未开启时直接通过
+func checkImageCaptcha(a ImageAnswer) code.Code {
+	if !ImageCaptchaEnabled() {
+		return code.CodeSuccess
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ok, err := imagecaptcha.Verify(ctx, a.ID, a.Answer)
+	if err != nil {
+		logger.L().Error("imagecaptcha.Verify error", zap.Error(err))
+		return code.CodeServerBusy
+	}
+	if !ok {
+		return code.CodeImageCaptcha
+	}
+	return code.CodeSuccess
+}
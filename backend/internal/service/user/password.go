@@ -0,0 +1,95 @@
+package user
+
+import (
+	"context"
+	"time"
+	"wsai/backend/internal/common/code"
+	"wsai/backend/internal/logger"
+	"wsai/backend/internal/middleware/jwt"
+	"wsai/backend/internal/model"
+	"wsai/backend/internal/repository/user"
+	"wsai/backend/internal/service/captcha"
+	myemail "wsai/backend/internal/service/email"
+	"wsai/backend/internal/service/loginguard"
+	"wsai/backend/internal/service/loginsession"
+	pwd "wsai/backend/utils/password"
+
+	"go.uber.org/zap"
+)
+
+// SendPasswordResetCode 向邮箱发送密码重置验证码；邮箱未注册时同样返回成功，避免探测账号
+func SendPasswordResetCode(email, ip string, image ImageAnswer) code.Code {
+	if code_ := checkImageCaptcha(image); code_ != code.CodeSuccess {
+		return code_
+	}
+	ok, u := user.IsExistUserWithEmail(email)
+	if !ok {
+		return code.CodeSuccess
+	}
+	return sendCaptcha(captcha.Target{Purpose: captcha.PurposeReset, Email: email}, ip, myemail.KindResetCode, u.Language)
+}
+
+// ResetPassword 凭邮箱验证码重置密码，成功后该用户的全部登录失效
+func ResetPassword(email, resetCode, newPassword string) code.Code {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ok, err := captcha.Verify(ctx, captcha.Target{Purpose: captcha.PurposeReset, Email: email}, resetCode)
+	if err != nil {
+		logger.L().Error("captcha.Verify error", zap.Error(err))
+		return code.CodeServerBusy
+	}
+	if !ok {
+		return code.CodeInvalidCaptcha
+	}
+	exist, u := user.IsExistUserWithEmail(email)
+	if !exist {
+		return code.CodeInvalidCaptcha
+	}
+	if code_ := setPassword(ctx, u, newPassword); code_ != code.CodeSuccess {
+		return code_
+	}
+	// 通过邮箱重置密码即证明是本人，顺带解除登录锁定
+	if err := loginguard.Unlock(ctx, u.ID); err != nil {
+		logger.L().Warn("loginguard.Unlock error",
+			zap.Int64("user_id", u.ID),
+			zap.Error(err))
+	}
+	return code.CodeSuccess
+}
+
+// ChangePassword 校验旧密码后修改密码，全部登录失效，为当前设备换发新的 token
+func ChangePassword(username, oldPassword, newPassword string, client loginsession.Client) (*jwt.TokenPair, code.Code) {
+	exist, u := user.IsExistUser(username)
+	if !exist {
+		return nil, code.CodeUserNotExist
+	}
+	if !checkPassword(u, oldPassword) {
+		return nil, code.CodeInvalidPassword
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if code_ := setPassword(ctx, u, newPassword); code_ != code.CodeSuccess {
+		return nil, code_
+	}
+	return issueTokens(u, client)
+}
+
+// setPassword 写入新密码并吊销该用户的全部登录
+func setPassword(ctx context.Context, u *model.User, newPassword string) code.Code {
+	hash, err := pwd.Hash(newPassword)
+	if err != nil {
+		return code.CodeServerBusy
+	}
+	if err := user.UpdatePassword(u.ID, hash); err != nil {
+		logger.L().Error("user.UpdatePassword error",
+			zap.Int64("user_id", u.ID),
+			zap.Error(err))
+		return code.CodeServerBusy
+	}
+	if _, code_ := loginsession.RevokeAll(ctx, u.ID, ""); code_ != code.CodeSuccess {
+		return code_
+	}
+	return code.CodeSuccess
+}
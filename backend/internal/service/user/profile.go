@@ -0,0 +1,212 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"wsai/backend/internal/ai"
+	"wsai/backend/internal/common/code"
+	"wsai/backend/internal/logger"
+	"wsai/backend/internal/model"
+	"wsai/backend/internal/repository/user"
+	"wsai/backend/internal/service/captcha"
+	myemail "wsai/backend/internal/service/email"
+	"wsai/backend/internal/service/loginsession"
+	"wsai/backend/internal/service/rag"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Languages 支持的界面语言
+var Languages = []string{"zh-CN", "en-US"}
+
+// ProfileUpdate 可修改的个人资料，nil 表示不修改
+type ProfileUpdate struct {
+	Name         *string
+	Avatar       *string
+	DefaultModel *string
+	Language     *string
+}
+
+func getUserByID(id int64) (*model.User, code.Code) {
+	u, err := user.GetUserByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, code.CodeUserNotExist
+		}
+		logger.L().Error("user.GetUserByID error",
+			zap.Int64("user_id", id),
+			zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+	return u, code.CodeSuccess
+}
+
+// GetProfile 查询个人资料
+func GetProfile(userID int64) (*model.User, code.Code) {
+	return getUserByID(userID)
+}
+
+// UpdateProfile 修改昵称、头像和偏好设置
+func UpdateProfile(userID int64, update ProfileUpdate) (*model.User, code.Code) {
+	fields := map[string]interface{}{}
+	if update.Name != nil {
+		name := strings.TrimSpace(*update.Name)
+		if name == "" {
+			return nil, code.CodeInvalidParams
+		}
+		fields["name"] = name
+	}
+	if update.Avatar != nil {
+		avatar := strings.TrimSpace(*update.Avatar)
+		if avatar != "" {
+			u, err := url.Parse(avatar)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return nil, code.CodeInvalidParams
+			}
+		}
+		fields["avatar"] = avatar
+	}
+	if update.DefaultModel != nil {
+		if *update.DefaultModel != "" && !slices.Contains(ai.GetGlobalFactory().ModelTypes(), *update.DefaultModel) {
+			return nil, code.CodeInvalidParams
+		}
+		fields["default_model"] = *update.DefaultModel
+	}
+	if update.Language != nil {
+		if *update.Language != "" && !slices.Contains(Languages, *update.Language) {
+			return nil, code.CodeInvalidParams
+		}
+		fields["language"] = *update.Language
+	}
+	if len(fields) > 0 {
+		if err := user.UpdateUser(userID, fields); err != nil {
+			logger.L().Error("user.UpdateUser error",
+				zap.Int64("user_id", userID),
+				zap.Error(err))
+			return nil, code.CodeServerBusy
+		}
+	}
+	return getUserByID(userID)
+}
+
+// DefaultModelType 请求未指定模型时使用用户偏好的模型，未设置返回 fallback
+func DefaultModelType(username string, fallback string) string {
+	if ok, u := user.IsExistUser(username); ok && u.DefaultModel != "" {
+		return u.DefaultModel
+	}
+	return fallback
+}
+
+// verifyOwner 敏感操作前再次校验密码；只通过单点登录的账号没有密码，跳过校验
+func verifyOwner(u *model.User, password string) bool {
+	if u.Password == "" {
+		return true
+	}
+	return checkPassword(u, password)
+}
+
+// RequestEmailChange 校验密码后向新邮箱发送验证码
+func RequestEmailChange(userID int64, newEmail, password, ip string) code.Code {
+	u, code_ := getUserByID(userID)
+	if code_ != code.CodeSuccess {
+		return code_
+	}
+	if !verifyOwner(u, password) {
+		return code.CodeInvalidPassword
+	}
+	newEmail = strings.ToLower(strings.TrimSpace(newEmail))
+	if strings.EqualFold(newEmail, u.Email) {
+		return code.CodeInvalidParams
+	}
+	if exist, _ := user.IsExistUserWithEmail(newEmail); exist {
+		return code.CodeUserExist
+	}
+
+	return sendCaptcha(emailChangeTarget(userID, newEmail), ip, myemail.KindEmailChangeCode, u.Language)
+}
+
+// emailChangeTarget 验证码绑定用户和新邮箱，换一个邮箱或用户提交无效
+func emailChangeTarget(userID int64, newEmail string) captcha.Target {
+	return captcha.Target{Purpose: captcha.PurposeEmailChange, Email: newEmail, Scope: captcha.ScopeUser(userID)}
+}
+
+// ConfirmEmailChange 凭新邮箱收到的验证码完成更换，并通知旧邮箱
+func ConfirmEmailChange(userID int64, newEmail, verifyCode string) (*model.User, code.Code) {
+	u, code_ := getUserByID(userID)
+	if code_ != code.CodeSuccess {
+		return nil, code_
+	}
+	newEmail = strings.ToLower(strings.TrimSpace(newEmail))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ok, err := captcha.Verify(ctx, emailChangeTarget(userID, newEmail), verifyCode)
+	if err != nil {
+		logger.L().Error("captcha.Verify error", zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+	if !ok {
+		return nil, code.CodeInvalidCaptcha
+	}
+	if exist, _ := user.IsExistUserWithEmail(newEmail); exist {
+		return nil, code.CodeUserExist
+	}
+	if err := user.UpdateUser(userID, map[string]interface{}{"email": newEmail}); err != nil {
+		logger.L().Error("user.UpdateUser error",
+			zap.Int64("user_id", userID),
+			zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+	if u.Email != "" {
+		if err := myemail.Send(ctx, myemail.Job{
+			To:     u.Email,
+			Kind:   myemail.KindEmailChanged,
+			Locale: u.Language,
+			Data:   myemail.Data{NewEmail: newEmail},
+		}); err != nil {
+			logger.L().Warn("通知旧邮箱失败", zap.Int64("user_id", userID), zap.Error(err))
+		}
+	}
+	return getUserByID(userID)
+}
+
+// DeleteAccount 注销账号：confirm 必须等于用户名，有密码的账号还需校验密码。
+// 会话和消息被删除，用户信息匿名化后软删除，全部登录随即失效
+func DeleteAccount(userID int64, password, confirm string) code.Code {
+	u, code_ := getUserByID(userID)
+	if code_ != code.CodeSuccess {
+		return code_
+	}
+	if confirm != u.Username {
+		return code.CodeInvalidParams
+	}
+	if !verifyOwner(u, password) {
+		return code.CodeInvalidPassword
+	}
+
+	// 先吊销登录，删除失败时用户最多需要重新登录
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, code_ := loginsession.RevokeAll(ctx, userID, ""); code_ != code.CodeSuccess {
+		return code_
+	}
+
+	sessionIDs := ai.GetGlobalManager().GetUserSessions(u.Username)
+	if err := user.DeleteAccount(u); err != nil {
+		logger.L().Error("user.DeleteAccount error",
+			zap.Int64("user_id", userID),
+			zap.Error(err))
+		return code.CodeServerBusy
+	}
+	for _, sessionID := range sessionIDs {
+		ai.GetGlobalManager().RemoveAIHelper(u.Username, sessionID)
+	}
+	rag.RemoveUserFiles(userID)
+	logger.L().Info("用户注销账号", zap.Int64("user_id", userID))
+	return code.CodeSuccess
+}
@@ -0,0 +1,113 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"time"
+	"wsai/backend/internal/common/code"
+	"wsai/backend/internal/logger"
+	"wsai/backend/internal/model"
+	"wsai/backend/internal/repository/user"
+	"wsai/backend/internal/repository/useridentity"
+	"wsai/backend/internal/service/loginsession"
+	"wsai/backend/internal/service/sso"
+	"wsai/backend/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// LoginWithOIDC 处理 IdP 回调：已绑定的身份直接登录，否则按邮箱绑定已有账号，
+// 邮箱未注册且提供方允许时自动创建账号
+func LoginWithOIDC(provider, authCode, state, binding string, client loginsession.Client) (result *LoginResult, code_ code.Code) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	var identifier string
+	var u *model.User
+	defer func() { auditLogin("oidc:"+provider, identifier, u, client, result, code_) }()
+
+	identity, code_ := sso.Exchange(ctx, provider, authCode, state, binding)
+	if code_ != code.CodeSuccess {
+		return nil, code_
+	}
+	identifier = identity.Email
+	if u, code_ = resolveIdentity(identity); code_ != code.CodeSuccess {
+		return nil, code_
+	}
+	return completeLogin(u, client)
+}
+
+func resolveIdentity(identity *sso.Identity) (*model.User, code.Code) {
+	linked, err := useridentity.GetIdentity(identity.Provider, identity.Subject)
+	if err == nil {
+		u, err := user.GetUserByID(linked.UserID)
+		if err != nil {
+			logger.L().Error("user.GetUserByID error",
+				zap.Int64("user_id", linked.UserID),
+				zap.Error(err))
+			return nil, code.CodeUserNotExist
+		}
+		if err := useridentity.TouchIdentity(linked.ID, identity.Email, time.Now()); err != nil {
+			logger.L().Warn("useridentity.TouchIdentity error", zap.Error(err))
+		}
+		return u, code.CodeSuccess
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.L().Error("useridentity.GetIdentity error", zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+
+	// 首次登录：邮箱必须由 IdP 验证过，才能与同邮箱的本地账号绑定或以此创建账号
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, code.CodeOIDCFailed
+	}
+	u, err := user.GetUserByEmail(identity.Email)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.L().Error("user.GetUserByEmail error", zap.Error(err))
+			return nil, code.CodeServerBusy
+		}
+		if !sso.AutoProvision(identity.Provider) {
+			return nil, code.CodeUserNotExist
+		}
+		if u, err = provisionUser(identity); err != nil {
+			logger.L().Error("自动创建单点登录用户失败",
+				zap.String("provider", identity.Provider),
+				zap.Error(err))
+			return nil, code.CodeServerBusy
+		}
+	}
+
+	_, err = useridentity.CreateIdentity(&model.UserIdentity{
+		UserID:      u.ID,
+		Provider:    identity.Provider,
+		Subject:     identity.Subject,
+		Email:       identity.Email,
+		LastLoginAt: time.Now(),
+	})
+	if err != nil {
+		logger.L().Error("useridentity.CreateIdentity error",
+			zap.Int64("user_id", u.ID),
+			zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+	return u, code.CodeSuccess
+}
+
+// provisionUser 为单点登录用户创建本地账号，不设置密码（只能通过单点登录或重置密码后登录）
+func provisionUser(identity *sso.Identity) (*model.User, error) {
+	username := utils.GetRandomNumbers(11)
+	name := []rune(identity.Name)
+	if len(name) > 50 {
+		name = name[:50]
+	}
+	if len(name) == 0 {
+		name = []rune(username)
+	}
+	return user.InsertUser(&model.User{
+		Email:    identity.Email,
+		Name:     string(name),
+		Username: username,
+	})
+}
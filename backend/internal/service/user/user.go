@@ -2,105 +2,268 @@ package user
 
 import (
 	"context"
+	"errors"
 	"time"
 	"wsai/backend/internal/common/code"
+	"wsai/backend/internal/logger"
 	"wsai/backend/internal/middleware/jwt"
 	"wsai/backend/internal/model"
 	"wsai/backend/internal/repository/user"
 	"wsai/backend/internal/service/captcha"
 	myemail "wsai/backend/internal/service/email"
+	"wsai/backend/internal/service/loginguard"
+	"wsai/backend/internal/service/loginsession"
+	"wsai/backend/internal/service/twofactor"
 	"wsai/backend/utils"
+	pwd "wsai/backend/utils/password"
+
+	"go.uber.org/zap"
 )
 
-func Login(username, password string) (string, code.Code) {
-	var userInformation *model.User
-	var ok bool
+// LoginResult 登录结果：未开启两步验证时直接签发 token，否则只返回挑战 token
+type LoginResult struct {
+	Tokens         *jwt.TokenPair
+	ChallengeToken string
+}
 
-	if ok, userInformation = user.IsExistUser(username); !ok {
-		return "", code.CodeUserNotExist
+func Login(username, password string, client loginsession.Client) (*LoginResult, code.Code) {
+	ok, userInformation := user.IsExistUser(username)
+	return passwordLogin(username, ok, userInformation, password, client)
+}
+
+// completeLogin 密码或单点登录校验通过后，开启了两步验证的用户先进入挑战环节
+func completeLogin(u *model.User, client loginsession.Client) (*LoginResult, code.Code) {
+	if u.Disabled {
+		return nil, code.CodeUserDisabled
 	}
-	if userInformation.Password != utils.MD5(password) {
-		return "", code.CodeInvalidPassword
+	if u.TOTPEnabled {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		challenge, err := twofactor.NewChallenge(ctx, u.ID)
+		if err != nil {
+			logger.L().Error("twofactor.NewChallenge error",
+				zap.Int64("user_id", u.ID),
+				zap.Error(err))
+			return nil, code.CodeServerBusy
+		}
+		return &LoginResult{ChallengeToken: challenge}, code.CodeSuccess
 	}
 
-	token, err := jwt.GenerateToken(userInformation.ID, userInformation.Username)
-	if err != nil {
-		return "", code.CodeServerBusy
+	pair, code_ := issueTokens(u, client)
+	if code_ != code.CodeSuccess {
+		return nil, code_
 	}
-	return token, code.CodeSuccess
+	return &LoginResult{Tokens: pair}, code.CodeSuccess
 }
 
-func LoginWithEmail(email, password string) (string, code.Code) {
-	var userInformation *model.User
-	var ok bool
+// LoginWithTwoFactor 凭挑战 token 和动态验证码（或恢复码）完成登录
+func LoginWithTwoFactor(challenge, passcode string, client loginsession.Client) (pair *jwt.TokenPair, code_ code.Code) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var u *model.User
+	defer func() { auditLogin("2fa", "", u, client, nil, code_) }()
 
-	if ok, userInformation = user.IsExistUserWithEmail(email); !ok {
-		return "", code.CodeUserNotExist
+	userID, err := twofactor.ChallengeUser(ctx, challenge)
+	if err != nil {
+		if !errors.Is(err, twofactor.ErrChallengeInvalid) {
+			logger.L().Error("twofactor.ChallengeUser error", zap.Error(err))
+			return nil, code.CodeServerBusy
+		}
+		return nil, code.CodeInvalidChallenge
 	}
-	if userInformation.Password != utils.MD5(password) {
-		return "", code.CodeInvalidPassword
+	found, err := user.GetUserByID(userID)
+	if err != nil || !found.TOTPEnabled {
+		return nil, code.CodeInvalidChallenge
 	}
+	u = found
+	// 动态验证码与密码共用失败计数，锁定和逐步延迟同样生效
+	if err := loginguard.Check(ctx, u.ID, client.IP); err != nil {
+		return nil, guardCode(err)
+	}
+	if !twofactor.Verify(ctx, u, passcode) {
+		if recordFailure(ctx, u, client.IP) {
+			return nil, code.CodeAccountLocked
+		}
+		return nil, code.CodeInvalidOTP
+	}
+	if ok, err := twofactor.CompleteChallenge(ctx, challenge); err != nil || !ok {
+		return nil, code.CodeInvalidChallenge
+	}
+	loginguard.Succeed(ctx, u.ID)
+	return issueTokens(u, client)
+}
 
-	token, err := jwt.GenerateToken(userInformation.ID, userInformation.Username)
+// issueTokens 签发 access/refresh token 对，并记录这次登录；所有登录方式最终都经过这里
+func issueTokens(u *model.User, client loginsession.Client) (*jwt.TokenPair, code.Code) {
+	if u.Disabled {
+		return nil, code.CodeUserDisabled
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	pair, err := jwt.IssueTokenPair(ctx, u.ID, u.Username, u.Role)
 	if err != nil {
-		return "", code.CodeServerBusy
+		logger.L().Error("jwt.IssueTokenPair error",
+			zap.Int64("user_id", u.ID),
+			zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+	if err := loginsession.Start(u.ID, u.Username, pair.JTI, client, pair.RefreshExpiresAt); err != nil {
+		logger.L().Error("loginsession.Start error",
+			zap.Int64("user_id", u.ID),
+			zap.Error(err))
+		return nil, code.CodeServerBusy
 	}
-	return token, code.CodeSuccess
+	return pair, code.CodeSuccess
 }
 
-func Register(email, password, captcha_ string) (string, code.Code) {
-	var ok bool
-	var userInformation *model.User
+// RefreshToken 轮换 refresh token；检测到重放时整个登录已被吊销
+func RefreshToken(refresh string) (*jwt.TokenPair, code.Code) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	pair, err := jwt.RotateRefreshToken(ctx, refresh)
+	switch {
+	case err == nil:
+		loginsession.Extend(pair.JTI, pair.RefreshExpiresAt)
+		return pair, code.CodeSuccess
+	case errors.Is(err, jwt.ErrRefreshTokenInvalid):
+		return nil, code.CodeInvalidRefresh
+	case errors.Is(err, jwt.ErrRefreshTokenReused):
+		logger.L().Warn("检测到 refresh token 重放，已吊销对应登录")
+		return nil, code.CodeRefreshReused
+	default:
+		logger.L().Error("jwt.RotateRefreshToken error", zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+}
 
-	if ok, _ = user.IsExistUserWithEmail(email); ok {
-		return "", code.CodeUserExist
+// checkPassword 校验密码，旧格式（MD5）或旧参数的哈希在校验通过后透明地重算为当前算法
+func checkPassword(u *model.User, password string) bool {
+	ok, needsRehash := pwd.Verify(password, u.Password)
+	if !ok {
+		return false
 	}
+	if needsRehash {
+		if newHash, err := pwd.Hash(password); err == nil {
+			if err := user.UpdatePassword(u.ID, newHash); err != nil {
+				logger.L().Warn("密码哈希升级失败",
+					zap.Int64("user_id", u.ID),
+					zap.Error(err))
+			}
+		}
+	}
+	return true
+}
+
+func LoginWithEmail(email, password string, client loginsession.Client) (*LoginResult, code.Code) {
+	ok, userInformation := user.IsExistUserWithEmail(email)
+	return passwordLogin(email, ok, userInformation, password, client)
+}
+
+func Register(email, password, captcha_, locale string, image ImageAnswer, client loginsession.Client) (*jwt.TokenPair, code.Code) {
+	var ok bool
+	var userInformation *model.User
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	if ok, _ := captcha.CheckCaptchaForEmail(ctx, email, captcha_); !ok {
-		return "", code.CodeInvalidCaptcha
+	if code_ := checkImageCaptcha(image); code_ != code.CodeSuccess {
+		return nil, code_
+	}
+	if ok, _ = user.IsExistUserWithEmail(email); ok {
+		return nil, code.CodeUserExist
+	}
+	ok, err := captcha.Verify(ctx, captcha.Target{Purpose: captcha.PurposeRegister, Email: email}, captcha_)
+	if err != nil {
+		logger.L().Error("captcha.Verify error", zap.Error(err))
+		return nil, code.CodeServerBusy
+	}
+	if !ok {
+		return nil, code.CodeInvalidCaptcha
 	}
 
+	passwordHash, err := pwd.Hash(password)
+	if err != nil {
+		return nil, code.CodeServerBusy
+	}
 	username := utils.GetRandomNumbers(11)
-	if userInformation, ok = user.Register(username, email, password); !ok {
-		return "", code.CodeServerBusy
+	if userInformation, ok = user.Register(username, email, passwordHash); !ok {
+		return nil, code.CodeServerBusy
 	}
-	if err := myemail.SendCaptcha(email, username, user.UserNameMsg); err != nil {
-		return "", code.CodeServerBusy
+	if err := myemail.Send(ctx, myemail.Job{
+		To:     email,
+		Kind:   myemail.KindWelcome,
+		Locale: locale,
+		Data:   myemail.Data{Username: username},
+	}); err != nil {
+		// 账号已创建，用户名同样可以在个人资料中查看，不影响注册结果
+		logger.L().Warn("发送注册成功邮件失败",
+			zap.Int64("user_id", userInformation.ID),
+			zap.Error(err))
 	}
 
-	token, err := jwt.GenerateToken(userInformation.ID, userInformation.Username)
-	if err != nil {
-		return "", code.CodeServerBusy
+	return issueTokens(userInformation, client)
+}
+
+// SendCaptcha 发送注册验证码，locale 取自请求的 Accept-Language
+func SendCaptcha(email_, ip, locale string, image ImageAnswer) code.Code {
+	if code_ := checkImageCaptcha(image); code_ != code.CodeSuccess {
+		return code_
 	}
-	return token, code.CodeSuccess
+	return sendCaptcha(captcha.Target{Purpose: captcha.PurposeRegister, Email: email_}, ip, myemail.KindRegisterCode, locale)
 }
 
-func SendCaptcha(email_ string) code.Code {
-	sendCode := utils.GetRandomNumbers(6)
+// sendCaptcha 生成验证码并发往 t.Email，受冷却时间和每日上限约束
+func sendCaptcha(t captcha.Target, ip string, kind myemail.Kind, locale string) code.Code {
+	sendCode, err := captcha.NewCode()
+	if err != nil {
+		logger.L().Error("captcha.NewCode error", zap.Error(err))
+		return code.CodeServerBusy
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	if err := captcha.SetCaptchaForEmail(ctx, email_, sendCode); err != nil {
+	err = captcha.Issue(ctx, t, ip, sendCode)
+	switch {
+	case errors.Is(err, captcha.ErrCooldown):
+		return code.CodeCaptchaCooldown
+	case errors.Is(err, captcha.ErrDailyLimit):
+		return code.CodeCaptchaLimit
+	case err != nil:
+		logger.L().Error("captcha.Issue error",
+			zap.String("purpose", string(t.Purpose)),
+			zap.Error(err))
 		return code.CodeServerBusy
 	}
 
-	if err := myemail.SendCaptcha(email_, sendCode, myemail.CodeMsg); err != nil {
+	err = myemail.Send(ctx, myemail.Job{
+		To:     t.Email,
+		Kind:   kind,
+		Locale: locale,
+		Data: myemail.Data{
+			Code:    sendCode,
+			Minutes: int(captcha.TTL(t.Purpose).Minutes()),
+		},
+	})
+	if err != nil {
+		logger.L().Error("发送验证码邮件失败",
+			zap.String("purpose", string(t.Purpose)),
+			zap.Error(err))
 		return code.CodeServerBusy
 	}
 	return code.CodeSuccess
 }
 
+// Logout 吊销当前登录，该登录签发的 access/refresh token 一并失效
 func Logout(token string) code.Code {
 	claims, ok := jwt.ParseTokenClaims(token)
-	if !ok || claims.ExpiresAt == nil {
+	if !ok || claims.ID == "" {
 		return code.CodeInvalidToken
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if err := jwt.AddTokenToBlacklist(ctx, token, claims.ExpiresAt.Time); err != nil {
+	if err := loginsession.RevokeJTI(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		logger.L().Error("loginsession.RevokeJTI error", zap.Error(err))
 		return code.CodeServerBusy
 	}
 	return code.CodeSuccess
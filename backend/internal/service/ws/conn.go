@@ -0,0 +1,206 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+	"wsai/backend/internal/ai"
+	"wsai/backend/internal/common/code"
+	"wsai/backend/internal/logger"
+	"wsai/backend/internal/middleware/ratelimit"
+	"wsai/backend/internal/service/quota"
+	"wsai/backend/internal/service/session"
+	"wsai/backend/internal/service/user"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+const (
+	writeWait      = 10 * time.Second
+	pongWait       = 60 * time.Second
+	pingPeriod     = pongWait * 9 / 10
+	maxMessageSize = 64 * 1024
+)
+
+// Conn 一个用户的 WebSocket 连接
+type Conn struct {
+	ws       *websocket.Conn
+	userID   int64
+	userName string
+
+	writeMu sync.Mutex
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+// Serve 处理一个已升级的连接，阻塞直到连接关闭；连接关闭不会中断进行中的生成
+func Serve(wsConn *websocket.Conn, userID int64, userName string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &Conn{
+		ws:       wsConn,
+		userID:   userID,
+		userName: userName,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+	defer c.close()
+
+	go c.keepalive()
+
+	c.ws.SetReadLimit(maxMessageSize)
+	_ = c.ws.SetReadDeadline(time.Now().Add(pongWait))
+	c.ws.SetPongHandler(func(string) error {
+		return c.ws.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		frame := new(ClientFrame)
+		if err := c.ws.ReadJSON(frame); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				logger.L().Warn("WebSocket read error",
+					zap.String("username", userName),
+					zap.Error(err))
+			}
+			return
+		}
+		c.dispatch(frame)
+	}
+}
+
+func (c *Conn) close() {
+	c.cancel()
+	_ = c.ws.Close()
+}
+
+func (c *Conn) dispatch(frame *ClientFrame) {
+	switch frame.Type {
+	case FrameChat:
+		c.handleChat(frame)
+	case FrameCancel:
+		if code_ := session.CancelGeneration(c.userName, frame.GenerationID); code_ != code.CodeSuccess {
+			c.sendError(frame.RequestID, code_)
+			return
+		}
+		c.send(&ServerFrame{Type: FrameAck, RequestID: frame.RequestID, GenerationID: frame.GenerationID})
+	case FrameSessions:
+		c.pushSessions(frame.RequestID)
+	case FramePing:
+		c.send(&ServerFrame{Type: FramePong, RequestID: frame.RequestID})
+	default:
+		c.sendError(frame.RequestID, code.CodeInvalidParams)
+	}
+}
+
+func (c *Conn) handleChat(frame *ClientFrame) {
+	if strings.TrimSpace(frame.Question) == "" {
+		c.sendError(frame.RequestID, code.CodeInvalidParams)
+		return
+	}
+	// 每个聊天帧与 SSE 接口共用 chat 限流规则
+	if !ratelimit.AllowUser("chat", c.userID) {
+		c.sendError(frame.RequestID, code.CodeTooManyRequests)
+		return
+	}
+	if frame.ModelType == "" {
+		frame.ModelType = user.DefaultModelType(c.userName, ai.ModelTypeOpenAI)
+	}
+	reservation, _, code_ := quota.Reserve(c.ctx, c.userName)
+	if code_ != code.CodeSuccess {
+		c.sendError(frame.RequestID, code_)
+		return
+	}
+
+	isNew := frame.SessionID == ""
+	var gen *session.Generation
+	if isNew {
+		gen, code_ = session.CreateStreamSession(c.userName, frame.Question, frame.ModelType, reservation)
+	} else {
+		gen, code_ = session.ChatStreamSend(c.userName, frame.SessionID, frame.Question, frame.ModelType, reservation)
+	}
+	if code_ != code.CodeSuccess {
+		c.sendError(frame.RequestID, code_)
+		return
+	}
+
+	c.send(&ServerFrame{
+		Type:         FrameAck,
+		RequestID:    frame.RequestID,
+		GenerationID: gen.ID,
+		SessionID:    gen.SessionID,
+	})
+	if isNew {
+		c.pushSessions("")
+	}
+
+	go func() {
+		sink := &generationSink{conn: c, gen: gen}
+		if err := gen.Stream(c.ctx, sink, 0); err != nil {
+			logger.L().Debug("WebSocket generation stream stopped",
+				zap.String("generation_id", gen.ID),
+				zap.Error(err))
+		}
+	}()
+}
+
+func (c *Conn) pushSessions(requestID string) {
+	sessions, err := session.GetUserSessionsByUsername(c.userName)
+	if err != nil {
+		c.sendError(requestID, code.CodeServerBusy)
+		return
+	}
+	c.send(&ServerFrame{Type: FrameSessions, RequestID: requestID, Sessions: sessions})
+}
+
+func (c *Conn) sendError(requestID string, code_ code.Code) {
+	c.send(&ServerFrame{Type: FrameError, RequestID: requestID, Code: code_, Message: code_.Msg()})
+}
+
+func (c *Conn) send(frame *ServerFrame) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_ = c.ws.SetWriteDeadline(time.Now().Add(writeWait))
+	if err := c.ws.WriteJSON(frame); err != nil {
+		c.cancel()
+		return err
+	}
+	return nil
+}
+
+func (c *Conn) keepalive() {
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			c.writeMu.Lock()
+			err := c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait))
+			c.writeMu.Unlock()
+			if err != nil {
+				c.cancel()
+				return
+			}
+		}
+	}
+}
+
+// generationSink 把生成事件包装成 event 帧
+type generationSink struct {
+	conn *Conn
+	gen  *session.Generation
+}
+
+func (s *generationSink) SendEvent(id int64, event string, payload []byte) error {
+	return s.conn.send(&ServerFrame{
+		Type:         FrameEvent,
+		GenerationID: s.gen.ID,
+		SessionID:    s.gen.SessionID,
+		ID:           id,
+		Event:        event,
+		Data:         json.RawMessage(payload),
+	})
+}
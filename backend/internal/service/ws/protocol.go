@@ -0,0 +1,73 @@
+// Package ws 实现聊天 WebSocket 协议：一个连接复用多个会话，与 SSE 共用同一条 AIHelper 流式生成路径。
+//
+// 连接地址：GET /api/v1/AI/ws?token=<JWT>，所有帧都是 JSON 文本帧。
+//
+// 客户端 -> 服务端：
+//
+//	{"type":"chat","requestId":"r1","sessionId":"","question":"你好","modelType":"openai"}
+//	    发送问题；sessionId 为空时新建会话，modelType 为空时使用个人资料中的默认模型。
+//	    与 SSE 接口共用 chat 限流规则，超限时返回 error 帧。
+//	{"type":"cancel","requestId":"r2","generationId":"..."}
+//	    取消进行中的生成。
+//	{"type":"sessions","requestId":"r3"}
+//	    获取会话列表。
+//	{"type":"ping","requestId":"r4"}
+//
+// 服务端 -> 客户端：
+//
+//	{"type":"ack","requestId":"r1","generationId":"...","sessionId":"..."}
+//	    chat/cancel 请求已受理；新建会话时 sessionId 为新会话 ID。
+//	{"type":"event","generationId":"...","sessionId":"...","id":3,"event":"delta","data":{"content":"..."}}
+//	    生成事件，event/data 与 SSE 完全一致（generation、session、title、delta、usage、error、done）。
+//	{"type":"sessions","requestId":"r3","sessions":[...]}
+//	    会话列表；新建会话后服务端也会主动推送（此时 requestId 为空）。
+//	{"type":"error","requestId":"r1","code":3002,"message":"额度已用完"}
+//	    请求处理失败。
+//	{"type":"pong","requestId":"r4"}
+package ws
+
+import (
+	"encoding/json"
+	"wsai/backend/internal/common/code"
+	"wsai/backend/internal/model"
+)
+
+// 客户端帧类型
+const (
+	FrameChat     = "chat"
+	FrameCancel   = "cancel"
+	FrameSessions = "sessions"
+	FramePing     = "ping"
+)
+
+// 服务端帧类型
+const (
+	FrameAck   = "ack"
+	FrameEvent = "event"
+	FrameError = "error"
+	FramePong  = "pong"
+)
+
+// ClientFrame 客户端发来的帧
+type ClientFrame struct {
+	Type         string `json:"type"`
+	RequestID    string `json:"requestId,omitempty"`
+	SessionID    string `json:"sessionId,omitempty"`
+	Question     string `json:"question,omitempty"`
+	ModelType    string `json:"modelType,omitempty"`
+	GenerationID string `json:"generationId,omitempty"`
+}
+
+// ServerFrame 服务端下发的帧
+type ServerFrame struct {
+	Type         string              `json:"type"`
+	RequestID    string              `json:"requestId,omitempty"`
+	GenerationID string              `json:"generationId,omitempty"`
+	SessionID    string              `json:"sessionId,omitempty"`
+	ID           int64               `json:"id,omitempty"`
+	Event        string              `json:"event,omitempty"`
+	Data         json.RawMessage     `json:"data,omitempty"`
+	Sessions     []model.SessionInfo `json:"sessions,omitempty"`
+	Code         code.Code           `json:"code,omitempty"`
+	Message      string              `json:"message,omitempty"`
+}
@@ -0,0 +1,98 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"wsai/backend/utils"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// argon2id 参数，调整后旧哈希会在下次登录时自动按新参数重算
+const (
+	argonTime    uint32 = 3
+	argonMemory  uint32 = 64 * 1024
+	argonThreads uint8  = 2
+	argonKeyLen  uint32 = 32
+	saltLen             = 16
+)
+
+var b64 = base64.RawStdEncoding
+
+// Hash 使用 argon2id 和随机盐计算密码哈希，输出 PHC 格式：
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+func Hash(password string) (string, error) {
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, argonTime, argonMemory, argonThreads, argonKeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, argonMemory, argonTime, argonThreads,
+		b64.EncodeToString(salt), b64.EncodeToString(key)), nil
+}
+
+// Verify 校验密码；needsRehash 为 true 表示存储的是旧格式（MD5）或旧参数，应在登录成功后重算
+func Verify(password, encoded string) (ok bool, needsRehash bool) {
+	if !strings.HasPrefix(encoded, "$argon2id$") {
+		// 历史数据：无盐 MD5
+		return isLegacyMD5(encoded) &&
+			subtle.ConstantTimeCompare([]byte(utils.MD5(password)), []byte(strings.ToLower(encoded))) == 1, true
+	}
+
+	params, salt, key, err := decode(encoded)
+	if err != nil {
+		return false, false
+	}
+	actual := argon2.IDKey([]byte(password), salt, params.time, params.memory, params.threads, uint32(len(key)))
+	if subtle.ConstantTimeCompare(actual, key) != 1 {
+		return false, false
+	}
+	return true, params != (argonParams{argonTime, argonMemory, argonThreads}) || len(key) != int(argonKeyLen)
+}
+
+type argonParams struct {
+	time    uint32
+	memory  uint32
+	threads uint8
+}
+
+func decode(encoded string) (argonParams, []byte, []byte, error) {
+	var params argonParams
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, errors.New("invalid argon2id hash")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, errors.New("unsupported argon2 version")
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.time, &params.threads); err != nil {
+		return params, nil, nil, err
+	}
+	salt, err := b64.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, err
+	}
+	key, err := b64.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, err
+	}
+	return params, salt, key, nil
+}
+
+func isLegacyMD5(encoded string) bool {
+	if len(encoded) != 32 {
+		return false
+	}
+	for _, ch := range strings.ToLower(encoded) {
+		if (ch < '0' || ch > '9') && (ch < 'a' || ch > 'f') {
+			return false
+		}
+	}
+	return true
+}
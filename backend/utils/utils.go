@@ -13,6 +13,7 @@ import (
 	"github.com/google/uuid"
 )
 
+// GetRandomNumbers 生成随机数字串，只用于填充用户名等不涉及安全的场景，验证码用 captcha.NewCode
 func GetRandomNumbers(num int) string {
 	r := rand.New(rand.NewSource(time.Now().UnixNano()))
 	var builder strings.Builder
@@ -1,4 +1,5 @@
 import { createRouter, createWebHistory } from 'vue-router'
+import AuthCallbackView from '../views/AuthCallbackView.vue'
 import AuthView from '../views/AuthView.vue'
 import ChatView from '../views/ChatView.vue'
 import { authStore } from '../stores/auth'
@@ -16,6 +17,11 @@ const router = createRouter({
       component: AuthView,
       meta: { guestOnly: true }
     },
+    {
+      path: '/auth/callback',
+      name: 'auth-callback',
+      component: AuthCallbackView
+    },
     {
       path: '/chat',
       name: 'chat',
@@ -21,10 +21,45 @@ async function parseJsonResponse(response) {
   return text ? JSON.parse(text) : {}
 }
 
-async function request(path, options = {}) {
+let refreshPromise = null
+
+// 使用 refresh token 换取新的登录凭证，并发请求共享同一次刷新
+async function refreshSession() {
+  if (!authStore.refreshToken.value) {
+    return false
+  }
+
+  if (!refreshPromise) {
+    refreshPromise = (async () => {
+      try {
+        const response = await fetch(`${authStore.apiBaseUrl.value}/user/token/refresh`, {
+          method: 'POST',
+          headers: { 'Content-Type': 'application/json' },
+          body: JSON.stringify({ refresh_token: authStore.refreshToken.value })
+        })
+        const data = await parseJsonResponse(response)
+        if (!response.ok || data.status_code !== 1000 || !data.token) {
+          return false
+        }
+        authStore.setToken(data.token)
+        authStore.setRefreshToken(data.refresh_token)
+        return true
+      } catch {
+        return false
+      } finally {
+        refreshPromise = null
+      }
+    })()
+  }
+
+  return refreshPromise
+}
+
+async function request(path, options = {}, retried = false) {
   const response = await fetch(`${authStore.apiBaseUrl.value}${path}`, {
     ...options,
-    headers: buildAuthHeaders(options.headers)
+    // FormData 需要浏览器自动设置 multipart 边界，不能带 JSON 的 Content-Type
+    headers: buildAuthHeaders(options.headers, !(options.body instanceof FormData))
   })
 
   const data = await parseJsonResponse(response)
@@ -34,6 +69,9 @@ async function request(path, options = {}) {
   }
 
   if (data.status_code && data.status_code !== 1000) {
+    if (data.status_code === 2006 && !retried && (await refreshSession())) {
+      return request(path, options, true)
+    }
     if (data.status_code === 2006 || data.status_code === 2007) {
       authStore.clear()
     }
@@ -45,10 +83,13 @@ async function request(path, options = {}) {
 
 function parseSseEvent(block) {
   const lines = block.split('\n')
-  const event = { type: 'message', data: '' }
+  const event = { id: '', type: 'message', data: '' }
   let hasData = false
 
   for (const line of lines) {
+    if (line.startsWith('id:')) {
+      event.id = line.slice(3).trim()
+    }
     if (line.startsWith('event:')) {
       event.type = line.slice(6).trim()
     }
@@ -79,6 +120,23 @@ export async function loginWithEmail(payload) {
   })
 }
 
+export async function loginWithTwoFactor(payload) {
+  return request('/user/login/2fa', {
+    method: 'POST',
+    body: JSON.stringify(payload)
+  })
+}
+
+export async function fetchOidcProviders() {
+  return request('/user/oidc/providers', {
+    method: 'GET'
+  })
+}
+
+export function oidcLoginUrl(provider) {
+  return `${authStore.apiBaseUrl.value}/user/oidc/${encodeURIComponent(provider)}/login`
+}
+
 export async function register(payload) {
   return request('/user/users', {
     method: 'POST',
@@ -86,10 +144,30 @@ export async function register(payload) {
   })
 }
 
-export async function sendCaptcha(email) {
+export async function fetchImageCaptcha() {
+  return request('/user/captcha/image', {
+    method: 'GET'
+  })
+}
+
+export async function sendCaptcha(payload) {
   return request('/user/captcha', {
     method: 'POST',
-    body: JSON.stringify({ email })
+    body: JSON.stringify(payload)
+  })
+}
+
+export async function sendLoginCode(payload) {
+  return request('/user/login/code/send', {
+    method: 'POST',
+    body: JSON.stringify(payload)
+  })
+}
+
+export async function loginWithCode(payload) {
+  return request('/user/login/code', {
+    method: 'POST',
+    body: JSON.stringify(payload)
   })
 }
 
@@ -111,6 +189,54 @@ export async function fetchHistory(sessionId) {
   })
 }
 
+export async function setSessionKnowledgeBase(sessionId, knowledgeBaseId) {
+  return request(`/AI/chatMessage/sessions/${sessionId}/knowledge-base`, {
+    method: 'PUT',
+    body: JSON.stringify({ knowledge_base_id: knowledgeBaseId })
+  })
+}
+
+export async function fetchKnowledgeBases() {
+  return request('/knowledge-bases', {
+    method: 'GET'
+  })
+}
+
+export async function createKnowledgeBase(payload) {
+  return request('/knowledge-bases', {
+    method: 'POST',
+    body: JSON.stringify(payload)
+  })
+}
+
+export async function deleteKnowledgeBase(id) {
+  return request(`/knowledge-bases/${id}`, {
+    method: 'DELETE'
+  })
+}
+
+export async function fetchDocuments(knowledgeBaseId) {
+  return request(`/knowledge-bases/${knowledgeBaseId}/documents`, {
+    method: 'GET'
+  })
+}
+
+export async function uploadDocument(knowledgeBaseId, file) {
+  const formData = new FormData()
+  formData.append('file', file)
+
+  return request(`/knowledge-bases/${knowledgeBaseId}/documents`, {
+    method: 'POST',
+    body: formData
+  })
+}
+
+export async function deleteDocument(documentId) {
+  return request(`/knowledge-bases/documents/${documentId}`, {
+    method: 'DELETE'
+  })
+}
+
 export async function recognizeImage(file) {
   const formData = new FormData()
   formData.append('image', file)
@@ -134,24 +260,14 @@ export async function recognizeImage(file) {
   return data
 }
 
-export async function streamSessionMessage({ sessionId, question, modelType }, handlers) {
-  const isNewSession = !sessionId
-  const path = isNewSession
-    ? '/AI/chatMessage/sessions/stream'
-    : `/AI/chatMessage/sessions/${sessionId}/messages/stream`
-
-  const body = isNewSession
-    ? { question, modelType }
-    : { sessionId, question, modelType }
-
-  const response = await fetch(`${authStore.apiBaseUrl.value}${path}`, {
-    method: 'POST',
-    headers: buildAuthHeaders(),
-    body: JSON.stringify(body)
-  })
+const STREAM_RESUME_ATTEMPTS = 3
+const STREAM_RESUME_DELAY_MS = 1000
 
-  if (!response.ok || !response.body) {
-    throw new Error(`流式请求失败：HTTP ${response.status}`)
+async function readSseStream(response, handlers, state) {
+  // 额度不足等情况在建立事件流之前就以 JSON 返回
+  if (!response.headers.get('Content-Type')?.includes('text/event-stream')) {
+    const data = await parseJsonResponse(response)
+    throw new Error(data.status_msg || '流式请求失败')
   }
 
   const reader = response.body.getReader()
@@ -162,7 +278,7 @@ export async function streamSessionMessage({ sessionId, question, modelType }, h
     const { value, done } = await reader.read()
 
     if (done) {
-      break
+      return false
     }
 
     buffer += decoder.decode(value, { stream: true })
@@ -174,32 +290,98 @@ export async function streamSessionMessage({ sessionId, question, modelType }, h
       if (!event.data) {
         continue
       }
-
-      if (event.type === 'error') {
-        let message = '流式响应失败'
-        try {
-          message = JSON.parse(event.data).message || message
-        } catch {
-          message = event.data
-        }
-        throw new Error(message)
+      if (event.id) {
+        state.lastEventId = event.id
       }
 
-      if (event.data === '[DONE]') {
-        handlers.onDone?.()
-        return
+      let payload = {}
+      try {
+        payload = JSON.parse(event.data)
+      } catch {
+        continue
       }
 
-      try {
-        const payload = JSON.parse(event.data)
-        if (payload.sessionId) {
+      switch (event.type) {
+        case 'generation':
+          state.generationId = payload.generationId
+          state.sessionId = payload.sessionId
+          break
+        case 'session':
           handlers.onSession?.(payload.sessionId)
-          continue
-        }
-      } catch {
+          break
+        case 'title':
+          handlers.onTitle?.(payload)
+          break
+        case 'citations':
+          handlers.onCitations?.(payload.citations || [])
+          break
+        case 'delta':
+          handlers.onChunk?.(payload.content)
+          break
+        case 'usage':
+          handlers.onUsage?.(payload)
+          break
+        case 'error':
+          state.failed = true
+          throw new Error(payload.message || '流式响应失败')
+        case 'done':
+          return true
       }
+    }
+  }
+}
+
+export async function streamSessionMessage({ sessionId, question, modelType }, handlers) {
+  const isNewSession = !sessionId
+  const path = isNewSession
+    ? '/AI/chatMessage/sessions/stream'
+    : `/AI/chatMessage/sessions/${sessionId}/messages/stream`
 
-      handlers.onChunk?.(event.data)
+  const body = isNewSession
+    ? { question, modelType }
+    : { sessionId, question, modelType }
+
+  const response = await fetch(`${authStore.apiBaseUrl.value}${path}`, {
+    method: 'POST',
+    headers: buildAuthHeaders(),
+    body: JSON.stringify(body)
+  })
+
+  if (!response.ok || !response.body) {
+    throw new Error(`流式请求失败：HTTP ${response.status}`)
+  }
+
+  const state = { generationId: '', sessionId: '', lastEventId: '', failed: false }
+  let attempts = 0
+  let current = response
+
+  while (true) {
+    try {
+      if (await readSseStream(current, handlers, state)) {
+        break
+      }
+    } catch (error) {
+      // 服务端明确返回错误，或还没拿到 generationId 时无法续传
+      if (state.failed || !state.generationId || attempts >= STREAM_RESUME_ATTEMPTS) {
+        throw error
+      }
+    }
+    if (!state.generationId || attempts >= STREAM_RESUME_ATTEMPTS) {
+      break
+    }
+
+    // 连接中断：凭 generationId 和 Last-Event-ID 续传
+    attempts += 1
+    await new Promise((resolve) => setTimeout(resolve, STREAM_RESUME_DELAY_MS))
+    current = await fetch(
+      `${authStore.apiBaseUrl.value}/AI/chatMessage/sessions/${state.sessionId}/messages/stream/${state.generationId}`,
+      {
+        method: 'GET',
+        headers: buildAuthHeaders({ 'Last-Event-ID': state.lastEventId }, false)
+      }
+    )
+    if (!current.ok || !current.body) {
+      throw new Error(`续传失败：HTTP ${current.status}`)
     }
   }
 
@@ -1,10 +1,12 @@
 import { computed, ref } from 'vue'
 
 const TOKEN_KEY = 'wsai-token'
+const REFRESH_TOKEN_KEY = 'wsai-refresh-token'
 const API_BASE_KEY = 'wsai-api-base'
 const DEFAULT_API_BASE = import.meta.env.VITE_API_BASE_URL || 'http://127.0.0.1:9091/api/v1'
 
 const token = ref(localStorage.getItem(TOKEN_KEY) || '')
+const refreshToken = ref(localStorage.getItem(REFRESH_TOKEN_KEY) || '')
 const apiBaseUrl = ref(localStorage.getItem(API_BASE_KEY) || DEFAULT_API_BASE)
 const username = ref(extractUsername(token.value))
 
@@ -33,6 +35,16 @@ function setToken(nextToken) {
   }
 }
 
+function setRefreshToken(nextRefreshToken) {
+  refreshToken.value = nextRefreshToken || ''
+
+  if (refreshToken.value) {
+    localStorage.setItem(REFRESH_TOKEN_KEY, refreshToken.value)
+  } else {
+    localStorage.removeItem(REFRESH_TOKEN_KEY)
+  }
+}
+
 function setApiBaseUrl(nextApiBase) {
   apiBaseUrl.value = nextApiBase.trim().replace(/\/$/, '')
   localStorage.setItem(API_BASE_KEY, apiBaseUrl.value)
@@ -40,12 +52,15 @@ function setApiBaseUrl(nextApiBase) {
 
 export const authStore = {
   token,
+  refreshToken,
   username,
   apiBaseUrl,
   isAuthenticated: computed(() => Boolean(token.value)),
   setToken,
+  setRefreshToken,
   setApiBaseUrl,
   clear() {
     setToken('')
+    setRefreshToken('')
   }
 }